@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const airDateLayout = "2006-01-02"
+
+// airDateReg matches the air dates of daily shows in file names, with dots
+// or dashes between year, month and day, ie. "2023.05.01"
+var airDateReg = regexp.MustCompile(`((?:19|20)\d{2})[.-](\d{2})[.-](\d{2})`)
+
+// parseAirDate returns the air date of year, month and day as "2023-05-01",
+// or "" when it is not a valid date
+func parseAirDate(year, month, day string) string {
+	y, _ := strconv.Atoi(year)
+	if y > time.Now().Year()+1 {
+		return ""
+	}
+	date := fmt.Sprintf("%s-%s-%s", year, month, day)
+	if _, err := time.Parse(airDateLayout, date); err != nil {
+		return ""
+	}
+	return date
+}
+
+// splitAirDates splits name around the air dates it has, returning the air
+// dates as "2023-05-01" and whether each part is one. Dates that are part of
+// a longer number are not split.
+func splitAirDates(name string) ([]string, []bool) {
+	parts, dates := []string{}, []bool{}
+	start := 0
+	for _, m := range airDateReg.FindAllStringSubmatchIndex(name, -1) {
+		if (m[0] > 0 && isDigit(name[m[0]-1])) || (m[1] < len(name) && isDigit(name[m[1]])) {
+			continue
+		}
+		date := parseAirDate(name[m[2]:m[3]], name[m[4]:m[5]], name[m[6]:m[7]])
+		if date == "" {
+			continue
+		}
+		parts, dates = append(parts, name[start:m[0]], date), append(dates, false, true)
+		start = m[1]
+	}
+	return append(parts, name[start:]), append(dates, false)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// extractAirDate splits the air date of daily shows from a query, ie. the
+// "2023-05-01" of "the daily show 2023-05-01". Queries built from file names
+// keep air dates as one field, see buildQueryTokens.
+func extractAirDate(query string) (string, string) {
+	fields := strings.Fields(query)
+	for i, field := range fields {
+		if parts, dates := splitAirDates(field); len(parts) == 3 && dates[1] && parts[0] == "" && parts[2] == "" {
+			rest := append(append([]string{}, fields[:i]...), fields[i+1:]...)
+			return strings.Join(rest, " "), parts[1]
+		}
+	}
+	return query, ""
+}
+
+// airDateEpisode returns the number of the episode of the season that aired
+// on airDate, 0 when none did
+func (r TvSeason) airDateEpisode(airDate string) int {
+	for i, episode := range r.Episodes {
+		if episode.AirDate == airDate {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// airDateSeason returns the number of the season of a tv show with an
+// episode that aired on airDate, searching from the latest season back
+func (s *Selector) airDateSeason(tvId int64, airDate string) (int, error) {
+	tv, err := s.provider.GetTv(tvId)
+	if err != nil {
+		return 0, err
+	}
+
+	for n := tv.NumberOfSeasons; n >= 1; n-- {
+		season, err := s.provider.GetTvSeason(tv, n)
+		if err != nil {
+			return 0, err
+		}
+		if season.airDateEpisode(airDate) > 0 {
+			return n, nil
+		}
+		if season.AirDate != "" && season.AirDate < airDate {
+			// earlier seasons aired before this one
+			break
+		}
+	}
+
+	return 0, fmt.Errorf("no episode of %s aired on %s", tv.Name, airDate)
+}
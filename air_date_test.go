@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExtractAirDate(t *testing.T) {
+	tests := []struct {
+		in    string
+		query string
+		date  string
+	}{
+		{"the daily show 2023-05-01", "the daily show", "2023-05-01"},
+		{"the daily show 2023.05.01 720p", "the daily show 720p", "2023-05-01"},
+		{"the daily show 2023-02-30", "the daily show 2023-02-30", ""},
+		{"the daily show 2023 05 01", "the daily show 2023 05 01", ""},
+		{"inception 2010 5 1", "inception 2010 5 1", ""},
+		{"show 20230-05-01", "show 20230-05-01", ""},
+	}
+	for _, test := range tests {
+		query, date := extractAirDate(test.in)
+		if query != test.query || date != test.date {
+			t.Errorf("extractAirDate(%q) = %q, %q, want %q, %q", test.in, query, date, test.query, test.date)
+		}
+	}
+}
+
+func TestBuildQueryAirDate(t *testing.T) {
+	stopWords := []string{"bluray", "720p", "x264"}
+	tests := []struct {
+		in    string
+		query string
+		date  string
+	}{
+		{"The.Daily.Show.2023.05.01.720p", "the daily show", "2023-05-01"},
+		{"The Daily Show - 2023-05-01", "the daily show", "2023-05-01"},
+		{"Inception.2010.BluRay.5.1", "inception 2010 5 1", ""},
+		{"Inception.2010.05.1.x264", "inception 2010 05 1", ""},
+		{"Show.2023.13.01", "show 2023 13 01", ""},
+	}
+	for _, test := range tests {
+		query, date := extractAirDate(buildQuery(test.in, stopWords))
+		if query != test.query || date != test.date {
+			t.Errorf("extractAirDate(buildQuery(%q)) = %q, %q, want %q, %q", test.in, query, date, test.query, test.date)
+		}
+	}
+}
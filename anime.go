@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// seasons searched for an absolute episode when the episode order of a show
+// comes from an episode group, whose number of seasons isn't known upfront
+const maxGroupSeasons = 100
+
+// extractAbsoluteEpisode splits the absolute episode number of anime from a
+// query, ie. the "1045" of "one piece 1045", the last number following the
+// show name
+func extractAbsoluteEpisode(query string) (string, int) {
+	fields := strings.Fields(query)
+	for i := len(fields) - 1; i > 0; i-- {
+		if len(fields[i]) > 4 || !intReg.MatchString(fields[i]) {
+			continue
+		}
+		absolute, _ := strconv.Atoi(fields[i])
+		if absolute < 1 {
+			continue
+		}
+		rest := append(append([]string{}, fields[:i]...), fields[i+1:]...)
+		return strings.Join(rest, " "), absolute
+	}
+	return query, 0
+}
+
+// absoluteEpisode maps an absolute episode number of a tv show to its season
+// and episode by adding up the lengths of the seasons, in the episode group
+// configured for the show or in aired order
+func (s *Selector) absoluteEpisode(tvId int64, absolute int) (int, int, error) {
+	tv, err := s.provider.GetTv(tvId)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	groupId := s.config.episodeGroup(s.provider.Name(), tvId)
+	seasons := tv.NumberOfSeasons
+	if groupId != "" {
+		seasons = maxGroupSeasons
+	}
+
+	episode := absolute
+	for n := 1; n <= seasons; n++ {
+		var season TvSeason
+		if groupId != "" {
+			season, err = s.provider.GetEpisodeGroupSeason(tv, groupId, n)
+			if err != nil && n > 1 {
+				// past the last season of the group
+				break
+			}
+		} else {
+			season, err = s.provider.GetTvSeason(tv, n)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if episode <= len(season.Episodes) {
+			return n, episode, nil
+		}
+		episode -= len(season.Episodes)
+	}
+
+	return 0, 0, fmt.Errorf("%s has no absolute episode %d", tv.Name, absolute)
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestExtractAbsoluteEpisode(t *testing.T) {
+	tests := []struct {
+		query    string
+		want     string
+		absolute int
+	}{
+		{"one piece 1045", "one piece", 1045},
+		{"one piece 1045 1080p", "one piece 1080p", 1045},
+		{"naruto shippuden 12 x264", "naruto shippuden x264", 12},
+		{"akira 1988", "akira", 1988},
+		{"1999", "1999", 0},
+		{"show 0", "show 0", 0},
+		{"show 12345", "show 12345", 0},
+		{"show", "show", 0},
+	}
+	for _, test := range tests {
+		query, absolute := extractAbsoluteEpisode(test.query)
+		if query != test.want || absolute != test.absolute {
+			t.Errorf("extractAbsoluteEpisode(%q) = %q, %d, want %q, %d", test.query, query, absolute, test.want, test.absolute)
+		}
+	}
+}
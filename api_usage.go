@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+const apiUsageDayFormat = "2006-01-02"
+
+// days of api usage kept in the usage file
+const apiUsageRetentionDays = 30
+
+// ApiUsage counts the requests sent to the metadata provider during
+// the run and per day, days are kept in the api usage file across runs
+type ApiUsage struct {
+	Days map[string]int `json:"days"`
+
+	mu    sync.Mutex
+	run   int
+	limit int
+	// the day and level (1 approaching, 2 over the limit) last warned about
+	warnedDay   string
+	warnedLevel int
+}
+
+// apiUsage is nil when requests are not counted, ie. when replaying
+var apiUsage *ApiUsage
+
+func readApiUsage(usagePath string, limit int) (*ApiUsage, error) {
+	usage := &ApiUsage{Days: make(map[string]int), limit: limit}
+
+	exists, err := fileExists(usagePath)
+	if err != nil || !exists {
+		return usage, err
+	}
+
+	b, err := ioutil.ReadFile(usagePath)
+	if err != nil {
+		return usage, err
+	}
+
+	err = json.Unmarshal(b, usage)
+	if err != nil {
+		return usage, fmt.Errorf("parsing %s: %w", usagePath, err)
+	}
+
+	if usage.Days == nil {
+		usage.Days = make(map[string]int)
+	}
+	return usage, nil
+}
+
+func writeApiUsage(usagePath string, usage *ApiUsage) error {
+	usage.mu.Lock()
+	cutoff := time.Now().AddDate(0, 0, -apiUsageRetentionDays).Format(apiUsageDayFormat)
+	for day := range usage.Days {
+		if day < cutoff {
+			delete(usage.Days, day)
+		}
+	}
+	usageJson, err := json.MarshalIndent(usage, "", "    ")
+	usage.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(usagePath, usageJson, 0644)
+}
+
+// Count records a request, warning once when today's requests reach 90%
+// of the daily limit and once when they exceed it
+func (u *ApiUsage) Count() {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	day := time.Now().Format(apiUsageDayFormat)
+	u.run += 1
+	u.Days[day] += 1
+	if u.limit <= 0 {
+		return
+	}
+
+	if u.warnedDay != day {
+		u.warnedDay, u.warnedLevel = day, 0
+	}
+	today := u.Days[day]
+	if today > u.limit && u.warnedLevel < 2 {
+		fmt.Printf(tr("Warning: %d api requests today, over the daily limit of %d\n"), today, u.limit)
+		u.warnedLevel = 2
+	} else if today*10 >= u.limit*9 && u.warnedLevel < 1 {
+		fmt.Printf(tr("Warning: %d api requests today, approaching the daily limit of %d\n"), today, u.limit)
+		u.warnedLevel = 1
+	}
+}
+
+// EndRun returns the usage line of the run report and starts counting
+// the next run, in watch mode
+func (u *ApiUsage) EndRun() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	line := fmt.Sprintf(tr("Api requests: %d this run, %d today"), u.run, u.Days[time.Now().Format(apiUsageDayFormat)])
+	if u.limit > 0 {
+		line += fmt.Sprintf(tr(" of %d"), u.limit)
+	}
+	u.run = 0
+	return line + "\n"
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// artwork kodi and jellyfin read from movie directories, in the moviedb
+// sizes they display without scaling up. Sizes that are not available fall
+// back to the largest one.
+const (
+	posterName = "poster"
+	posterSize = "w780"
+	fanartName = "fanart"
+	fanartSize = "w1280"
+	// episode stills are named after the episode file, ie. "Show S01E01-thumb.jpg"
+	thumbName = "thumb"
+	stillSize = "w300"
+)
+
+// images are served by a cdn, not the api, so they are neither throttled
+// nor recorded, and may take longer than api responses
+var artworkClient = http.Client{Timeout: time.Second * 30}
+
+type artwork struct {
+	url  string
+	file string
+}
+
+// artworkFile returns the file of the named artwork next to outFile,
+// "poster.jpg" in the directory of a movie or named after the out file
+func artworkFile(outFile string, media Media, name string) string {
+	dir := filepath.Dir(outFile)
+	if _, ok := media.(Movie); ok && !*mirrorFlag {
+		return filepath.Join(dir, name+".jpg")
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.jpg", fNameSansExtension(outFile), name))
+}
+
+// artworkUrl returns the url of an image path of provider, tvdb has full
+// urls already
+func artworkUrl(provider MetadataProvider, path, size string, url func(*MovieDb, string, string) (string, error)) (string, error) {
+	if movieDb, ok := provider.(*MovieDb); ok {
+		return url(movieDb, path, size)
+	} else if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path, nil
+	}
+	return "", fmt.Errorf("no %s image url for %s", provider.Name(), path)
+}
+
+// findArtwork returns the poster and fanart of a movie, or the still of an
+// episode, that media has
+func findArtwork(provider MetadataProvider, outFile string, media Media) ([]artwork, error) {
+	artworks := []artwork{}
+	add := func(path, size, name string, url func(*MovieDb, string, string) (string, error)) error {
+		if path == "" {
+			return nil
+		}
+		u, err := artworkUrl(provider, path, size, url)
+		if err != nil {
+			return err
+		}
+		artworks = append(artworks, artwork{url: u, file: artworkFile(outFile, media, name)})
+		return nil
+	}
+
+	var err error
+	switch m := media.(type) {
+	case Movie:
+		err = add(m.PosterPath, posterSize, posterName, (*MovieDb).PosterUrl)
+		if err == nil {
+			err = add(m.BackdropPath, fanartSize, fanartName, (*MovieDb).BackdropUrl)
+		}
+	case TvEpisode:
+		err = add(m.StillPath, stillSize, thumbName, (*MovieDb).StillUrl)
+	}
+	return artworks, err
+}
+
+func downloadFile(url, path string) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	res, err := artworkClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", url, res.Status)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, body, 0644)
+}
+
+// downloadArtwork saves the artwork of media next to outFile, keeping
+// artwork that is already there, ie. placed with -sidecars
+func downloadArtwork(provider MetadataProvider, outFile string, media Media) error {
+	artworks, err := findArtwork(provider, outFile, media)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range artworks {
+		exists, err := fileExists(a.file)
+		if err != nil {
+			return err
+		} else if exists {
+			fmt.Printf(tr("Keeping artwork %s\n"), a.file)
+			continue
+		}
+
+		fmt.Printf("%s %s %s\n", ColorStr(RedColor, a.url), ColorStr(WhiteColor, arrowStr()), ColorStr(GreenColor, a.file))
+		err = downloadFile(a.url, a.file)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reasons in files need attention for
+const (
+	skippedAttention     = "skipped"
+	noResultsAttention   = "no-results"
+	conflictAttention    = "conflict"
+	needsReviewAttention = "needs-review"
+)
+
+const (
+	listAction  = "list"
+	clearAction = "clear"
+)
+
+// AttentionItem is an in file that was left for the user to look at
+type AttentionItem struct {
+	Reason  string    `json:"reason"`
+	Detail  string    `json:"detail,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// Attention is read from the attention file, items are keyed by in file
+type Attention struct {
+	Files   map[string]AttentionItem `json:"files"`
+	changed bool
+}
+
+func readAttention(attentionPath string) (*Attention, error) {
+	attention := &Attention{Files: make(map[string]AttentionItem)}
+
+	exists, err := fileExists(attentionPath)
+	if err != nil || !exists {
+		return attention, err
+	}
+
+	b, err := ioutil.ReadFile(attentionPath)
+	if err != nil {
+		return attention, err
+	}
+
+	err = json.Unmarshal(b, attention)
+	if err != nil {
+		return attention, fmt.Errorf("parsing %s: %w", attentionPath, err)
+	}
+
+	if attention.Files == nil {
+		attention.Files = make(map[string]AttentionItem)
+	}
+	return attention, nil
+}
+
+func writeAttention(attentionPath string, attention *Attention) error {
+	attentionJson, err := json.MarshalIndent(attention, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(attentionPath, attentionJson, 0644)
+	if err == nil {
+		attention.changed = false
+	}
+	return err
+}
+
+// add queues an in file, replacing the reason it was queued for before
+func (a *Attention) add(file, reason, detail string) {
+	if a == nil {
+		return
+	}
+	a.Files[file] = AttentionItem{Reason: reason, Detail: detail, AddedAt: time.Now()}
+	a.changed = true
+}
+
+// remove drops an in file that was handled from the queue
+func (a *Attention) remove(file string) {
+	if a == nil {
+		return
+	}
+	if _, ok := a.Files[file]; ok {
+		delete(a.Files, file)
+		a.changed = true
+	}
+}
+
+// prune drops queued in files that are in the manifest, or that are in one
+// of inDirs and no longer exist. Files of other in dirs, or not listed in
+// movieList, ie. because of -min-age, are kept.
+func (a *Attention) prune(inDirs, movieList []string, manifestIndex *ManifestIndex) {
+	if a == nil {
+		return
+	}
+	listed := make(map[string]bool)
+	for _, moviePath := range movieList {
+		listed[moviePath] = true
+	}
+	for file := range a.Files {
+		if manifestIndex.Seen(file) {
+			a.remove(file)
+			continue
+		}
+		if listed[file] || !inAnyDir(inDirs, file) {
+			continue
+		}
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			a.remove(file)
+		}
+	}
+}
+
+// inAnyDir reports whether path is below one of dirs
+func inAnyDir(dirs []string, path string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the in files of movieList that are queued, in the same order
+func (a *Attention) filter(movieList []string) []string {
+	files := []string{}
+	for _, moviePath := range movieList {
+		if _, ok := a.Files[moviePath]; ok {
+			files = append(files, moviePath)
+		}
+	}
+	return files
+}
+
+// printAttention lists the queued in files, oldest first
+func printAttention(attention *Attention) {
+	files := make([]string, 0, len(attention.Files))
+	for file := range attention.Files {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return attention.Files[files[i]].AddedAt.Before(attention.Files[files[j]].AddedAt)
+	})
+
+	for _, file := range files {
+		item := attention.Files[file]
+		line := fmt.Sprintf("%s %s", ColorStr(YellowColor, fmt.Sprintf("%-12s", item.Reason)), file)
+		if item.Detail != "" {
+			line += fmt.Sprintf(" (%s)", item.Detail)
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf(tr("%d files need attention\n"), len(files))
+}
+
+// runAttentionCommand lists or clears the attention queue, processing the
+// queued in files is left to the organizer
+func runAttentionCommand(action, attentionPath string) error {
+	attention, err := readAttention(attentionPath)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case listAction:
+		printAttention(attention)
+		return nil
+	case clearAction:
+		attention.Files = make(map[string]AttentionItem)
+		return writeAttention(attentionPath, attention)
+	default:
+		return fmt.Errorf("Unknown attention action %q, must be one of: %s, %s", action, listAction, clearAction)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrNeedsReview is returned in batch mode when no result
+// can be selected with enough confidence
+var ErrNeedsReview = errors.New("needs review")
+
+// normalizeTitle lower cases s and reduces it to words of letters and digits
+func normalizeTitle(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(cur[j-1]+1, prev[j]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// titleSimilarity is 1 for titles that are the same after normalizing
+// and decreases towards 0 with their edit distance
+func titleSimilarity(a, b string) float64 {
+	ra, rb := []rune(normalizeTitle(a)), []rune(normalizeTitle(b))
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// originalName returns the original title of movie and tv show results
+func originalName(media Media) string {
+	switch m := media.(type) {
+	case Movie:
+		return m.OriginalTitle
+	case Tv:
+		return m.OriginalName
+	default:
+		return ""
+	}
+}
+
+// matchScore rates how well a search result matches the title and year
+// parsed from a file name
+func matchScore(query string, year int, media Media) float64 {
+	score := titleSimilarity(query, media.GetName())
+	if original := originalName(media); original != "" {
+		if s := titleSimilarity(query, original); s > score {
+			score = s
+		}
+	}
+
+	if year > 0 {
+		resultYear, err := strconv.Atoi(media.GetYear())
+		if err != nil {
+			score *= 0.8
+		} else if diff := resultYear - year; diff == 1 || diff == -1 {
+			// release dates differ between countries
+			score *= 0.9
+		} else if diff != 0 {
+			score *= 0.5
+		}
+	}
+
+	return score
+}
+
+// bestMatch returns the result scoring at least threshold, provided
+// no other result scores as high
+func bestMatch(query string, year int, results []Media, threshold float64) (Media, float64, error) {
+	if len(results) == 0 {
+		return Movie{}, 0, fmt.Errorf("%w: no results for %q", ErrNeedsReview, query)
+	}
+
+	best, bestScore, runnerUp := 0, -1.0, -1.0
+	for i, result := range results {
+		score := matchScore(query, year, result)
+		if score > bestScore {
+			best, bestScore, runnerUp = i, score, bestScore
+		} else if score > runnerUp {
+			runnerUp = score
+		}
+	}
+
+	if bestScore < threshold {
+		return Movie{}, bestScore, fmt.Errorf("%w: best result for %q is %s (%s) with score %.2f", ErrNeedsReview, query, results[best].GetName(), results[best].GetYear(), bestScore)
+	}
+	if runnerUp >= bestScore {
+		return Movie{}, bestScore, fmt.Errorf("%w: several results for %q score %.2f", ErrNeedsReview, query, bestScore)
+	}
+	return results[best], bestScore, nil
+}
+
+// autoSelect selects the movie or tv episode of a file without prompting,
+// returning an ErrNeedsReview error when that isn't possible with confidence
+func (s *Selector) autoSelect(query string, common []string, threshold float64) (Media, error) {
+	dateQuery, airDate := extractAirDate(strings.TrimSpace(query))
+	myQuery, releaseSeason, releaseEpisode, year := extractTvSeasonEpisodeFromQuery(dateQuery)
+	absolute := 0
+	if *animeFlag && releaseSeason == 0 && releaseEpisode == 0 && airDate == "" {
+		myQuery, absolute = extractAbsoluteEpisode(myQuery)
+	}
+	if myQuery == "" && len(common) > 0 {
+		myQuery = strings.Join(common, " ")
+	}
+	if myQuery == "" {
+		return Movie{}, fmt.Errorf("%w: empty query", ErrNeedsReview)
+	}
+
+	if (releaseSeason == 0 && releaseEpisode == 0 && airDate == "" && absolute == 0) || s.forcedType == "movie" {
+		s.setMovieMode(myQuery)
+		response, err := s.provider.SearchMovie(myQuery, 1, year)
+		if err != nil {
+			return Movie{}, err
+		}
+		results := preferLanguages(response.MediaResults(), splitCsv(*preferLanguageFlag), *onlyPreferredLanguageFlag)
+		movie, score, err := bestMatch(myQuery, year, results, threshold)
+		if err == nil {
+			fmt.Printf(tr("Auto-selected %s (%s), score %.2f\n"), movie.GetName(), movie.GetYear(), score)
+			s.match = Match{Method: autoMatch, Score: score}
+		}
+		return movie, err
+	}
+
+	tvId, ok := s.selectedShow(myQuery)
+	if !ok && len(common) > 0 {
+		tvId, ok = s.selectedShow(strings.Join(common, " "))
+	}
+	// episodes of a show matched earlier share its score
+	score := s.showScores[mediaId{s.provider.Name(), tvId}]
+	if !ok {
+		s.setTvMode(myQuery)
+		tv, tvScore, err := s.bestTv(myQuery, year, threshold)
+		if commonQuery := strings.Join(common, " "); errors.Is(err, ErrNeedsReview) && commonQuery != "" && commonQuery != myQuery {
+			fmt.Printf(tr("Using tokens common to files in this directory: %s\n"), commonQuery)
+			tv, tvScore, err = s.bestTv(commonQuery, year, threshold)
+		}
+		if err != nil {
+			return Movie{}, err
+		}
+		tvId = tv.GetId()
+		score = tvScore
+		s.showScores[mediaId{s.provider.Name(), tvId}] = score
+	}
+
+	season, episode := s.config.mapEpisode(s.provider.Name(), tvId, releaseSeason, releaseEpisode)
+	if airDate != "" || absolute > 0 {
+		var err error
+		if airDate != "" {
+			season, err = s.airDateSeason(tvId, airDate)
+		} else {
+			season, episode, err = s.absoluteEpisode(tvId, absolute)
+		}
+		if err != nil {
+			return Movie{}, fmt.Errorf("%w: %s", ErrNeedsReview, err)
+		}
+	}
+	err := s.setTvSeasonEpisodeMode(tvId, season, myQuery)
+	if err != nil {
+		return Movie{}, err
+	}
+	if !ok && airDate == "" {
+		s.prefetchShow(tvId)
+	}
+	if airDate != "" {
+		episode = s.tvSeason.airDateEpisode(airDate)
+	}
+
+	results := s.tvSeason.MediaResults()
+	if episode < 1 || episode > len(results) {
+		return Movie{}, fmt.Errorf("%w: %s has no episode %d in season %d", ErrNeedsReview, s.tvSeason.TvName, episode, season)
+	}
+
+	var media Media = results[episode-1]
+	if lastEpisode := extractLastEpisode(query); lastEpisode > releaseEpisode {
+		last := episode + lastEpisode - releaseEpisode
+		if last > len(results) {
+			return Movie{}, fmt.Errorf("%w: %s has no episode %d in season %d", ErrNeedsReview, s.tvSeason.TvName, last, season)
+		}
+		media = multiEpisode(results[episode-1 : last])
+	}
+
+	fmt.Printf(tr("Auto-selected %s S%02dE%02d %s\n"), s.tvSeason.TvName, season, episode, media.GetName())
+	s.match = Match{Method: autoMatch, Score: score, SeasonMap: s.config.hasSeasonMapping(s.provider.Name(), tvId, releaseSeason)}
+	return media, nil
+}
+
+// prefetchShow fetches all seasons of a newly matched show in the background,
+// so that the other episodes of a show archive are matched from the cache
+func (s *Selector) prefetchShow(tvId int64) {
+	if s.config.episodeGroup(s.provider.Name(), tvId) != "" {
+		return
+	}
+	tv, err := s.provider.GetTv(tvId)
+	if err != nil {
+		return
+	}
+	seasons := make([]int, 0, tv.NumberOfSeasons)
+	for n := 1; n <= tv.NumberOfSeasons; n++ {
+		seasons = append(seasons, n)
+	}
+	s.provider.PrefetchTvSeasons(tv, seasons...)
+}
+
+func (s *Selector) bestTv(query string, year int, threshold float64) (Media, float64, error) {
+	response, err := s.provider.SearchTv(query, 1, year)
+	if err != nil {
+		return Movie{}, 0, err
+	}
+	results := preferLanguages(response.MediaResults(), splitCsv(*preferLanguageFlag), *onlyPreferredLanguageFlag)
+	return bestMatch(query, year, results, threshold)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAutoSelectMovie(t *testing.T) {
+	s := newReplaySelector(t, "")
+	media, err := s.autoSelect("the matrix 1999", []string{}, 0.8)
+	if err != nil {
+		t.Fatalf("autoSelect error: %v", err)
+	}
+	if media.GetId() != 603 {
+		t.Errorf("autoSelect = %s (%d), want The Matrix (603)", media.GetName(), media.GetId())
+	}
+	if s.match.Method != autoMatch || s.match.Score != 1 {
+		t.Errorf("match = %+v, want an auto match with score 1", s.match)
+	}
+}
+
+func TestAutoSelectAmbiguous(t *testing.T) {
+	// remakes with the same title can't be told apart without a year
+	s := newReplaySelector(t, "")
+	_, err := s.autoSelect("the thing", []string{}, 0.8)
+	if !errors.Is(err, ErrNeedsReview) {
+		t.Errorf("autoSelect error = %v, want %v", err, ErrNeedsReview)
+	}
+}
+
+func TestAutoSelectEpisode(t *testing.T) {
+	s := newReplaySelector(t, "")
+	media, err := s.autoSelect("breaking bad s01e02", []string{}, 0.8)
+	if err != nil {
+		t.Fatalf("autoSelect error: %v", err)
+	}
+	if media.GetId() != 62086 {
+		t.Errorf("autoSelect = %s (%d), want Cat's in the Bag... (62086)", media.GetName(), media.GetId())
+	}
+
+	media, err = s.autoSelect("breaking bad s01e01e03", []string{}, 0.8)
+	if err != nil {
+		t.Fatalf("autoSelect error: %v", err)
+	}
+	episode := media.(TvEpisode)
+	if episode.Id != 62085 || episode.LastEpisode != 3 || len(episode.ExtraIds) != 2 {
+		t.Errorf("autoSelect = %+v, want episodes 1-3", episode)
+	}
+
+	_, err = s.autoSelect("breaking bad s01e04", []string{}, 0.8)
+	if !errors.Is(err, ErrNeedsReview) {
+		t.Errorf("autoSelect error = %v, want %v", err, ErrNeedsReview)
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+	results := []Media{
+		Movie{Id: 1, Title: "Heat", ReleaseDate: "1995-12-15"},
+		Movie{Id: 2, Title: "Heat", ReleaseDate: "1986-03-14"},
+		Movie{Id: 3, Title: "The Heat", ReleaseDate: "2013-06-27"},
+	}
+	media, score, err := bestMatch("heat", 1995, results, 0.8)
+	if err != nil || media.GetId() != 1 || score != 1 {
+		t.Errorf("bestMatch = %d, %.2f, %v, want 1, 1.00", media.GetId(), score, err)
+	}
+
+	_, _, err = bestMatch("heat", 0, results, 0.8)
+	if !errors.Is(err, ErrNeedsReview) {
+		t.Errorf("bestMatch without a year error = %v, want %v", err, ErrNeedsReview)
+	}
+
+	_, _, err = bestMatch("heat", 1995, []Media{}, 0.8)
+	if !errors.Is(err, ErrNeedsReview) {
+		t.Errorf("bestMatch without results error = %v, want %v", err, ErrNeedsReview)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	kernelCopy            = "kernel"
+	userspaceCopy         = "userspace"
+	defaultCopyBufferSize = 32 * 1024
+)
+
+// CopySettings are the copy defaults recorded in the config by the bench command
+type CopySettings struct {
+	Strategy    string `json:"strategy,omitempty"`
+	BufferSize  int    `json:"buffer_size,omitempty"`
+	Parallelism int    `json:"parallelism,omitempty"`
+}
+
+func (c CopySettings) String() string {
+	if c.Strategy == userspaceCopy {
+		return fmt.Sprintf("%s, %s buffer, %d parallel", c.Strategy, humanize.IBytes(uint64(c.BufferSize)), c.Parallelism)
+	}
+	return fmt.Sprintf("%s, %d parallel", c.Strategy, c.Parallelism)
+}
+
+// copySettings are used for all copies, from the config when benchmarked
+var copySettings = CopySettings{Strategy: kernelCopy, Parallelism: 1}
+
+// benchCopy copies src into dir parallelism times at once,
+// returning the total throughput in bytes per second
+func benchCopy(src, dir string, size int64, settings CopySettings) (float64, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	start := time.Now()
+	for n := 0; n < settings.Parallelism; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			dst := filepath.Join(dir, fmt.Sprintf(".%s-bench-%d", BinName, n))
+			err := copyFileWith(src, dst, settings, false)
+			os.Remove(dst)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return float64(size*int64(settings.Parallelism)) / time.Since(start).Seconds(), nil
+}
+
+// runBenchCommand measures the throughput of the copy strategies to target
+// and records the fastest one in the config file
+func runBenchCommand(target string, sizeMb int64, configPath string) error {
+	if target == "" {
+		return fmt.Errorf("target is required")
+	}
+	if sizeMb <= 0 {
+		return fmt.Errorf("bench-size must be positive")
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", target)
+	}
+
+	config, err := readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	src, err := ioutil.TempFile("", fmt.Sprintf("%s-bench-", BinName))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(src.Name())
+
+	size := sizeMb * 1024 * 1024
+	fmt.Printf(tr("Writing %s of test data to %s\n"), humanize.IBytes(uint64(size)), src.Name())
+	_, err = io.CopyN(src, rand.Reader, size)
+	if cerr := src.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	candidates := []CopySettings{{Strategy: kernelCopy, Parallelism: 1}}
+	for _, bufferSize := range []int{defaultCopyBufferSize, 256 * 1024, 1024 * 1024, 4 * 1024 * 1024, 16 * 1024 * 1024} {
+		candidates = append(candidates, CopySettings{Strategy: userspaceCopy, BufferSize: bufferSize, Parallelism: 1})
+	}
+
+	best, bestThroughput := CopySettings{}, 0.0
+	measure := func(settings CopySettings) error {
+		throughput, err := benchCopy(src.Name(), target, size, settings)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  %-40s %s/s\n", settings, humanize.IBytes(uint64(throughput)))
+		if throughput > bestThroughput {
+			best, bestThroughput = settings, throughput
+		}
+		return nil
+	}
+
+	fmt.Printf(tr("Copying to %s:\n"), target)
+	for _, settings := range candidates {
+		err = measure(settings)
+		if err != nil {
+			return err
+		}
+	}
+
+	// parallel copies only help if they beat the fastest single copy
+	single := best
+	for _, parallelism := range []int{2, 4} {
+		settings := single
+		settings.Parallelism = parallelism
+		err = measure(settings)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf(tr("Fastest: %s at %s/s\n"), best, humanize.IBytes(uint64(bestThroughput)))
+	config.Copy = &best
+	err = writeConfig(configPath, config)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(tr("Saved copy defaults to %s\n"), configPath)
+	return nil
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	isatty "github.com/mattn/go-isatty"
+)
+
+type cleanCandidate struct {
+	dir    string
+	size   int64
+	files  int
+	newest time.Time
+}
+
+// dirStats sums the size and counts the files of all files under dir
+func dirStats(ctx context.Context, dir string) (cleanCandidate, error) {
+	candidate := cleanCandidate{dir: dir}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.ModTime().After(candidate.newest) {
+			candidate.newest = info.ModTime()
+		}
+		if !info.IsDir() {
+			candidate.size += info.Size()
+			candidate.files += 1
+		}
+		return nil
+	})
+	return candidate, err
+}
+
+func (c cleanCandidate) String() string {
+	newest := "-"
+	if !c.newest.IsZero() {
+		newest = c.newest.Format("2006-01-02 15:04")
+	}
+	return fmt.Sprintf(tr("%s (%s, %d files, newest %s)"), c.dir, humanize.Bytes(uint64(c.size)), c.files, newest)
+}
+
+func isInteractive() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// protectedDirs expands glob patterns, relative to outDir unless absolute,
+// and tags of manifest entries, ie. "tag:kids", to the existing directories
+// clean must never touch
+func protectedDirs(outDir string, patterns []string, manifest []ManifestEntry) ([]string, error) {
+	dirs := []string{}
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, tagProtectPrefix) {
+			for _, dir := range taggedDirs(manifest, strings.TrimPrefix(pattern, tagProtectPrefix)) {
+				if dir != filepath.Clean(outDir) && !stringSliceContains(dirs, dir) {
+					dirs = append(dirs, dir)
+				}
+			}
+			continue
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(outDir, pattern)
+		}
+		matches, err := filepath.Glob(filepath.Clean(pattern))
+		if err != nil {
+			return dirs, fmt.Errorf("invalid clean-protect pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				dirs = append(dirs, match)
+			}
+		}
+	}
+	return dirs, nil
+}
+
+// cleanScan finds the directories under an out dir without out files,
+// reading up to workers directories at the same time
+type cleanScan struct {
+	ctx       context.Context
+	outFiles  []string
+	protected []string
+	workers   chan struct{}
+	scanned   int64
+
+	wg    sync.WaitGroup
+	mutex sync.Mutex
+	dirs  []string
+	err   error
+}
+
+func (s *cleanScan) scan(dir string) {
+	defer s.wg.Done()
+	select {
+	case <-s.ctx.Done():
+		return
+	case s.workers <- struct{}{}:
+	}
+	files, err := ioutil.ReadDir(dir)
+	<-s.workers
+	atomic.AddInt64(&s.scanned, 1)
+	if err != nil {
+		s.mutex.Lock()
+		if s.err == nil {
+			s.err = err
+		}
+		s.mutex.Unlock()
+		return
+	}
+
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name())
+		if !f.IsDir() || stringSliceContains(s.protected, path) {
+			continue
+		}
+		if stringSliceHasPrefix(s.outFiles, path) {
+			s.wg.Add(1)
+			go s.scan(path)
+		} else {
+			s.mutex.Lock()
+			s.dirs = append(s.dirs, path)
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// getCleanDirs returns the highest directories under outDir that do not
+// contain an out file from manifest, protected directories and their parents
+// are never included. The scan stops at the first directory that can't be
+// read, or when ctx is done.
+func getCleanDirs(ctx context.Context, outDir string, manifest []ManifestEntry, protected []string, workers int) ([]string, error) {
+	outFiles := []string{}
+	for _, m := range manifest {
+		if m.OutFile != "" && strings.HasPrefix(m.OutFile, outDir) {
+			outFiles = append(outFiles, m.OutFile)
+		}
+	}
+	for _, p := range protected {
+		outFiles = append(outFiles, p+string(filepath.Separator))
+	}
+	if !stringSliceContains(protected, outDir) && !stringSliceHasPrefix(outFiles, outDir) {
+		return []string{outDir}, nil
+	}
+
+	s := &cleanScan{ctx: ctx, outFiles: outFiles, protected: protected, workers: make(chan struct{}, workers)}
+	stop := startCountProgress(tr("Scanned %d directories"), func() int64 { return atomic.LoadInt64(&s.scanned) })
+	defer stop()
+
+	// a directory read hanging on a slow network share must not keep the
+	// scan from being aborted
+	done := make(chan struct{})
+	s.wg.Add(1)
+	go s.scan(outDir)
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	sort.Strings(s.dirs)
+	return s.dirs, nil
+}
+
+// cleanCandidates returns the stats of dirs, largest first, limited to the
+// top largest unless top is 0. Up to workers dirs are summed at the same time.
+func cleanCandidates(ctx context.Context, dirs []string, top, workers int) ([]cleanCandidate, error) {
+	candidates := make([]cleanCandidate, len(dirs))
+	jobs := make(chan int)
+	var summed int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				candidate, err := dirStats(ctx, dirs[i])
+				if err != nil && ctx.Err() == nil {
+					log.Println("Error getting directory size:", err)
+				}
+				if err != nil {
+					candidate = cleanCandidate{dir: dirs[i]}
+				}
+				candidates[i] = candidate
+				atomic.AddInt64(&summed, 1)
+			}
+		}()
+	}
+
+	stop := startCountProgress(tr("Summed the size of %d directories"), func() int64 { return atomic.LoadInt64(&summed) })
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(jobs)
+		for i := range dirs {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- i:
+			}
+		}
+	}()
+	select {
+	case <-done:
+		wg.Wait()
+	case <-ctx.Done():
+	}
+	stop()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].size > candidates[j].size
+	})
+	if top > 0 && len(candidates) > top {
+		candidates = candidates[:top]
+	}
+	return candidates, nil
+}
+
+// interruptContext returns a context that is done on ctrl-c, until the
+// returned function is called
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(interrupts)
+		cancel()
+	}
+}
+
+// runClean removes directories under outDir that contain no out file from
+// the manifest, largest first. When attached to a terminal each directory is
+// confirmed. Scanning the out dir is aborted with ctrl-c.
+func runClean(outDir string, manifest []ManifestEntry, protect []string, top, workers int, reader *bufio.Reader) error {
+	protected, err := protectedDirs(outDir, protect, manifest)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext()
+	start := time.Now()
+	dirs, err := getCleanDirs(ctx, outDir, manifest, protected, workers)
+	var candidates []cleanCandidate
+	if err == nil {
+		candidates, err = cleanCandidates(ctx, dirs, top, workers)
+	}
+	stop()
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("scan of %s aborted", outDir)
+	} else if err != nil {
+		return err
+	}
+	fmt.Printf(tr("Found %d directories without out files in %s\n"), len(dirs), time.Since(start).Round(time.Millisecond))
+
+	interactive := !*dryRunFlag && isInteractive()
+	all := false
+	var reclaimable, removed int64
+	shownDirs, removedDirs := 0, 0
+	for _, candidate := range candidates {
+		fmt.Println(candidate)
+		reclaimable += candidate.size
+		shownDirs += 1
+
+		if *dryRunFlag {
+			continue
+		}
+
+		if interactive && !all {
+			fmt.Print(promptStr(tr("Remove? [yNaq] (a: all remaining, q: quit)")))
+			raw, err := reader.ReadString('\n')
+			if err != nil {
+				return err
+			}
+			answer := strings.ToLower(strings.TrimSpace(raw))
+			if answer == "q" {
+				break
+			} else if answer == "a" {
+				all = true
+			} else if answer != "y" && answer != tr("y") {
+				continue
+			}
+		}
+
+		err = os.RemoveAll(candidate.dir)
+		if err != nil {
+			log.Println("Error removing directory:", err)
+			continue
+		}
+		removed += candidate.size
+		removedDirs += 1
+	}
+
+	fmt.Printf(tr("Reclaimable: %s in %d directories\n"), humanize.Bytes(uint64(reclaimable)), shownDirs)
+	if !*dryRunFlag {
+		fmt.Printf(tr("Removed: %s in %d directories\n"), humanize.Bytes(uint64(removed)), removedDirs)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	organizeCommand  = "organize"
+	watchCommand     = "watch"
+	cleanCommand     = "clean"
+	verifyCommand    = "verify"
+	undoCommand      = "undo"
+	tokensCommand    = "tokens"
+	manifestCommand  = "manifest"
+	explainCommand   = "explain"
+	benchCommand     = "bench"
+	doctorCommand    = "doctor"
+	versionCommand   = "version"
+	attentionCommand = "attention"
+	reviewCommand    = "review"
+	helpCommand      = "help"
+)
+
+var commands = []string{organizeCommand, watchCommand, cleanCommand, verifyCommand, undoCommand, tokensCommand, manifestCommand, explainCommand, benchCommand, doctorCommand, attentionCommand, reviewCommand, versionCommand, helpCommand}
+
+// commandHelp describes each sub-command in the help text
+var commandHelp = map[string]string{
+	organizeCommand:  "Match in files and copy or move them to the out dir, the default command",
+	watchCommand:     "Organize in files periodically or on a cron-style schedule",
+	cleanCommand:     "List directories in the out dir that are candidates for removal",
+	verifyCommand:    "Verify the checksums recorded in the manifest against the out files",
+	undoCommand:      "Undo the last placements recorded in the manifest",
+	tokensCommand:    "Print all unique tokens used for generated search from the in dirs",
+	manifestCommand:  "Push the manifest to or pull it from a remote, verify, list, edit or rebase its entries",
+	explainCommand:   "Explain how the search query of an in file is built",
+	benchCommand:     "Measure copy throughput to a target dir and save the fastest copy settings",
+	doctorCommand:    "Check the api key, dirs, tools and manifest before a long session",
+	attentionCommand: "List the in files that need attention and organize only those",
+	reviewCommand:    "Select again the auto matches with a low score and move corrected ones",
+	versionCommand:   "Print version information",
+	helpCommand:      "Print the help text of a command",
+}
+
+// commandArgs are the arguments of each sub-command shown in its usage line
+var commandArgs = map[string]string{
+	undoCommand:      "[flags] [count]",
+	manifestCommand:  "push|pull [flags] <remote>, verify|list|rebase [flags], or edit [flags] <file>",
+	explainCommand:   "[flags] <file>",
+	attentionCommand: "[list|clear] [flags]",
+	helpCommand:      "[command]",
+}
+
+// ownFlags are only accepted by the sub-command they belong to,
+// organize, attention and review accept all other flags
+var ownFlags = map[string][]string{
+	watchCommand:    {"interval", "schedule", "health-addr", "scan-timeout"},
+	cleanCommand:    {"clean-protect", "clean-top", "clean-workers"},
+	tokensCommand:   {"token-stats", "token-json", "stop-word-threshold"},
+	manifestCommand: {"force", "untag", "below-score", "from", "to"},
+	benchCommand:    {"target", "bench-size"},
+	reviewCommand:   {"max-confidence"},
+}
+
+// sharedFlags are organize flags that other sub-commands accept as well
+var sharedFlags = map[string][]string{
+	cleanCommand:    {"out", "movie-out", "tv-out", "manifest", "config", "dry-run"},
+	verifyCommand:   {"manifest"},
+	undoCommand:     {"manifest", "dry-run"},
+	tokensCommand:   {"in", "movie-exts", "set-stop-words", "add-stop-words", "manifest"},
+	manifestCommand: {"manifest", "tags", "note", "manifest-ignore-case", "dry-run"},
+	explainCommand:  {"in", "movie-exts", "set-stop-words", "add-stop-words", "no-common-dir", "common-dir-scope", "common-dir-min-peers", "anime"},
+	benchCommand:    {"config"},
+	doctorCommand:   {"in", "movie-exts", "out", "movie-out", "tv-out", "manifest", "config", "provider", "api-key", "link"},
+}
+
+// commonFlags are accepted by every sub-command
+var commonFlags = []string{"lang", "no-color", "plain"}
+
+// deprecatedFlags are the flags replaced by sub-commands, they are still
+// accepted without a sub-command but not shown in the help text
+var deprecatedFlags = map[string]string{
+	"clean": cleanCommand,
+	"p":     tokensCommand,
+	"v":     versionCommand,
+}
+
+// parseCommand splits an optional leading sub-command from the flag arguments,
+// organize when none is given
+func parseCommand(args []string) (string, []string) {
+	if len(args) > 0 && stringSliceContains(commands, args[0]) {
+		return args[0], args[1:]
+	}
+	return deprecatedCommand(args)
+}
+
+// deprecatedCommand returns the sub-command of the first deprecated flag of
+// args, with the deprecated flags removed, organize when there is none
+func deprecatedCommand(args []string) (string, []string) {
+	command := organizeCommand
+	rest := []string{}
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value := strings.TrimLeft(arg, "-"), "true"
+		if j := strings.Index(name, "="); j >= 0 {
+			name, value = name[:j], name[j+1:]
+		}
+		replacement, ok := deprecatedFlags[name]
+		if !ok || !strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+			continue
+		}
+		if on, err := strconv.ParseBool(value); err == nil && on && command == organizeCommand {
+			log.Printf("Warning: -%s is deprecated, use the %s command instead\n", name, replacement)
+			command = replacement
+		}
+	}
+	return command, rest
+}
+
+// parseAction splits the action of a sub-command, ie. "push" in "manifest push"
+func parseAction(args []string) (string, []string) {
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+func isOwnFlag(name string) bool {
+	for _, names := range ownFlags {
+		if stringSliceContains(names, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// commandFlagNames returns the names of the flags a sub-command accepts
+func commandFlagNames(command string) []string {
+	if command == organizeCommand || command == watchCommand || command == attentionCommand || command == reviewCommand {
+		names := []string{}
+		flag.VisitAll(func(f *flag.Flag) {
+			if !isOwnFlag(f.Name) {
+				names = append(names, f.Name)
+			}
+		})
+		return append(names, ownFlags[command]...)
+	}
+
+	names := append([]string{}, commonFlags...)
+	names = append(names, sharedFlags[command]...)
+	return append(names, ownFlags[command]...)
+}
+
+// newCommandFlagSet returns the flag set of a sub-command, its flags share
+// their values with the flags defined on flag.CommandLine
+func newCommandFlagSet(command string) *flag.FlagSet {
+	fs := flag.NewFlagSet(BinName+" "+command, flag.ExitOnError)
+	for _, name := range commandFlagNames(command) {
+		f := flag.CommandLine.Lookup(name)
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
+	fs.Usage = func() {
+		printCommandUsage(fs, command)
+	}
+	return fs
+}
+
+func printCommandUsage(fs *flag.FlagSet, command string) {
+	out := fs.Output()
+	if command == organizeCommand {
+		fmt.Fprintf(out, "Usage: %s [command] [flags]\n\nCommands:\n", BinName)
+		for _, c := range commands {
+			fmt.Fprintf(out, "  %-11s%s\n", c, commandHelp[c])
+		}
+		fmt.Fprintf(out, "\nRun \"%s help <command>\" for the flags of a command.\n\nFlags of %s:\n", BinName, organizeCommand)
+	} else {
+		args := commandArgs[command]
+		if args == "" {
+			args = "[flags]"
+		}
+		fmt.Fprintf(out, "Usage: %s %s %s\n\n%s\n\nFlags:\n", BinName, command, args, commandHelp[command])
+	}
+	fs.PrintDefaults()
+}
+
+// runHelpCommand prints the help text of a sub-command, of organize when none is given
+func runHelpCommand(command string) error {
+	if command == "" {
+		command = organizeCommand
+	}
+	if !stringSliceContains(commands, command) {
+		return fmt.Errorf("Unknown command %q, run \"%s %s\" for a list of commands", command, BinName, helpCommand)
+	}
+	fs := newCommandFlagSet(command)
+	fs.SetOutput(os.Stdout)
+	fs.Usage()
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// SeasonMapping maps a season number used by releases to the moviedb season
+type SeasonMapping struct {
+	Season        int `json:"season"`
+	EpisodeOffset int `json:"episode_offset,omitempty"`
+}
+
+// Override changes how the out path of a single movie or tv show is rendered,
+// and for tv shows how release season and episode numbers are resolved
+type Override struct {
+	Folder        string                `json:"folder,omitempty"`
+	Year          string                `json:"year,omitempty"`
+	SeasonOffset  int                   `json:"season_offset,omitempty"`
+	EpisodeOffset int                   `json:"episode_offset,omitempty"`
+	SeasonMap     map[int]SeasonMapping `json:"season_map,omitempty"`
+	EpisodeGroup  string                `json:"episode_group,omitempty"`
+}
+
+// ProviderOverrides are the overrides of a provider, keyed by its ids
+type ProviderOverrides struct {
+	Movies map[int64]Override `json:"movies"`
+	Tv     map[int64]Override `json:"tv"`
+}
+
+// Config is read from the config file, overrides are keyed by moviedb id,
+// those of other providers are kept by provider name
+type Config struct {
+	Movies       map[int64]Override            `json:"movies"`
+	Tv           map[int64]Override            `json:"tv"`
+	Providers    map[string]*ProviderOverrides `json:"providers,omitempty"`
+	CleanProtect []string                      `json:"clean_protect,omitempty"`
+	Routes       []Route                       `json:"routes,omitempty"`
+	Copy         *CopySettings                 `json:"copy,omitempty"`
+	ApiKeys      map[string]string             `json:"api_keys,omitempty"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Movies: make(map[int64]Override),
+		Tv:     make(map[int64]Override),
+	}
+}
+
+// readConfig reads the config file, a missing file is an empty config
+func readConfig(configPath string) (*Config, error) {
+	config := NewConfig()
+
+	exists, err := fileExists(configPath)
+	if err != nil || !exists {
+		return config, err
+	}
+
+	b, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(b, config)
+	if err != nil {
+		return config, fmt.Errorf("parsing %s: %w", configPath, err)
+	}
+
+	if config.Movies == nil {
+		config.Movies = make(map[int64]Override)
+	}
+	if config.Tv == nil {
+		config.Tv = make(map[int64]Override)
+	}
+
+	return config, nil
+}
+
+// overrides returns the overrides of provider, ids are only valid within
+// their provider
+func (c *Config) overrides(provider string) *ProviderOverrides {
+	if provider == movieDbProvider || provider == "" {
+		return &ProviderOverrides{Movies: c.Movies, Tv: c.Tv}
+	}
+	if c.Providers == nil {
+		c.Providers = make(map[string]*ProviderOverrides)
+	}
+	overrides, ok := c.Providers[provider]
+	if !ok || overrides == nil {
+		overrides = &ProviderOverrides{}
+		c.Providers[provider] = overrides
+	}
+	if overrides.Movies == nil {
+		overrides.Movies = make(map[int64]Override)
+	}
+	if overrides.Tv == nil {
+		overrides.Tv = make(map[int64]Override)
+	}
+	return overrides
+}
+
+func writeConfig(configPath string, config *Config) error {
+	configJson, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(configPath, configJson, 0644)
+}
+
+// mapEpisode translates release season and episode numbers of a tv show
+// to moviedb numbers using its season map
+func (c *Config) mapEpisode(provider string, tvId int64, season, episode int) (int, int) {
+	mapping, ok := c.overrides(provider).Tv[tvId].SeasonMap[season]
+	if !ok {
+		return season, episode
+	}
+	return mapping.Season, episode + mapping.EpisodeOffset
+}
+
+func (c *Config) hasSeasonMapping(provider string, tvId int64, season int) bool {
+	_, ok := c.overrides(provider).Tv[tvId].SeasonMap[season]
+	return ok
+}
+
+func (c *Config) episodeGroup(provider string, tvId int64) string {
+	return c.overrides(provider).Tv[tvId].EpisodeGroup
+}
+
+func (c *Config) setEpisodeGroup(provider string, tvId int64, groupId string) {
+	tv := c.overrides(provider).Tv
+	override := tv[tvId]
+	override.EpisodeGroup = groupId
+	tv[tvId] = override
+}
+
+func (c *Config) setSeasonMapping(provider string, tvId int64, season int, mapping SeasonMapping) {
+	tv := c.overrides(provider).Tv
+	override := tv[tvId]
+	if override.SeasonMap == nil {
+		override.SeasonMap = make(map[int]SeasonMapping)
+	}
+	override.SeasonMap[season] = mapping
+	tv[tvId] = override
+}
+
+// applyOverride applies the override configured with provider for the
+// movie or tv show of media
+func applyOverride(media Media, config *Config, provider string) Media {
+	overrides := config.overrides(provider)
+	switch m := media.(type) {
+	case Movie:
+		override, ok := overrides.Movies[m.Id]
+		if !ok {
+			return media
+		}
+		fmt.Printf(tr("Applying override for movie %d\n"), m.Id)
+		m.PathFolder = override.Folder
+		if override.Year != "" {
+			m.PathYear = override.Year
+		}
+		return m
+	case TvEpisode:
+		override, ok := overrides.Tv[m.TvId]
+		if !ok {
+			return media
+		}
+		fmt.Printf(tr("Applying override for tv show %d\n"), m.TvId)
+		m.PathFolder = override.Folder
+		if override.Year != "" {
+			m.PathYear = override.Year
+		}
+		m.SeasonOffset = override.SeasonOffset
+		m.EpisodeOffset = override.EpisodeOffset
+		return m
+	default:
+		return media
+	}
+}
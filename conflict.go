@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+type conflictPolicy string
+
+const (
+	promptConflict     conflictPolicy = "prompt"
+	skipConflict       conflictPolicy = "skip"
+	overwriteConflict  conflictPolicy = "overwrite"
+	keepBothConflict   conflictPolicy = "keep-both"
+	largerWinsConflict conflictPolicy = "larger-wins"
+	newerWinsConflict  conflictPolicy = "newer-wins"
+)
+
+var conflictPolicies = []conflictPolicy{
+	promptConflict,
+	skipConflict,
+	overwriteConflict,
+	keepBothConflict,
+	largerWinsConflict,
+	newerWinsConflict,
+}
+
+type conflictAction int
+
+const (
+	skipAction conflictAction = iota
+	overwriteAction
+	keepBothAction
+)
+
+func conflictPolicyNames() string {
+	names := make([]string, len(conflictPolicies))
+	for i, p := range conflictPolicies {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+func parseConflictPolicy(s string) (conflictPolicy, error) {
+	for _, p := range conflictPolicies {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("Invalid conflict policy %q, must be one of: %s", s, conflictPolicyNames())
+}
+
+func printConflict(inFile, outFile string, inInfo, outInfo os.FileInfo) {
+	var inMedia, outMedia string
+	if ffprobeAvailable() {
+		inMedia = probeMediaInfoStr(inFile)
+		outMedia = probeMediaInfoStr(outFile)
+	}
+
+	fmt.Println(tr("Out file exists and has different content as in file!"))
+	fmt.Println(tr("In: "), inFile)
+	fmt.Printf(tr("     Size: %s, modified: %s\n"), humanize.Bytes(uint64(inInfo.Size())), inInfo.ModTime())
+	if inMedia != "" {
+		fmt.Printf(tr("     Media: %s\n"), inMedia)
+	}
+	fmt.Println(tr("Out:"), outFile)
+	fmt.Printf(tr("     Size: %s, modified: %s\n"), humanize.Bytes(uint64(outInfo.Size())), outInfo.ModTime())
+	if outMedia != "" {
+		fmt.Printf(tr("     Media: %s\n"), outMedia)
+	}
+}
+
+func probeMediaInfoStr(mediaPath string) string {
+	info, err := probeMediaInfo(mediaPath)
+	if err != nil {
+		return fmt.Sprintf("unknown (%s)", err)
+	}
+	return info.String()
+}
+
+// resolveConflict decides what to do with an in file whose out file already
+// exists with different content. The prompt policy asks the user.
+func resolveConflict(policy conflictPolicy, inInfo, outInfo os.FileInfo, verb string, reader *bufio.Reader) conflictAction {
+	switch policy {
+	case overwriteConflict:
+		return overwriteAction
+	case keepBothConflict:
+		return keepBothAction
+	case largerWinsConflict:
+		if inInfo.Size() > outInfo.Size() {
+			return overwriteAction
+		}
+		return skipAction
+	case newerWinsConflict:
+		if inInfo.ModTime().After(outInfo.ModTime()) {
+			return overwriteAction
+		}
+		return skipAction
+	case promptConflict:
+		if confirm(promptStr(fmt.Sprintf(tr("%s? [yN]"), tr(strings.Title(verb)))), reader) {
+			return overwriteAction
+		}
+		return skipAction
+	default:
+		return skipAction
+	}
+}
+
+func conflictActionName(action conflictAction) string {
+	switch action {
+	case overwriteAction:
+		return "overwrite"
+	case keepBothAction:
+		return "keep both"
+	default:
+		return "skip"
+	}
+}
+
+// keepBothPath returns the first non-existent variant of outFile. When
+// label is given (ie. release source), "name - label.ext" is tried first,
+// then "name (N).ext" starting with N = 2
+func keepBothPath(outFile, label string) (string, error) {
+	ext := filepath.Ext(outFile)
+	name := outFile[0 : len(outFile)-len(ext)]
+
+	if label != "" {
+		candidate := fmt.Sprintf("%s - %s%s", name, label, ext)
+		exists, err := fileExists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", name, n, ext)
+		exists, err := fileExists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+}
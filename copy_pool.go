@@ -0,0 +1,117 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// placement is an in file matched to its out file
+type placement struct {
+	index     int
+	moviePath string
+	outFile   string
+	verb      string
+	movie     Media
+	provider  string
+	overwrite bool
+	imdbId    string
+	details   *MediaDetails
+	crcCheck  string
+	tags      []string
+	note      string
+	match     *Match
+	url       string
+	writeNfo  bool
+	artwork   bool
+
+	// set by placing the file
+	placed    bool
+	checksum  string
+	displaced string
+	copied    int64
+	copyTime  time.Duration
+	err       error
+}
+
+// copyPool places in files in the background, so the next in files can be
+// matched while earlier copies are still running. Finished placements are
+// collected by the run loop, which records them in the manifest.
+type copyPool struct {
+	jobs     chan placement
+	workers  sync.WaitGroup
+	inFlight sync.WaitGroup
+
+	mu      sync.Mutex
+	done    []placement
+	pending map[string]bool
+}
+
+func newCopyPool(workers int, place func(*placement) error) *copyPool {
+	p := &copyPool{
+		jobs:    make(chan placement, workers),
+		pending: make(map[string]bool),
+	}
+	for n := 0; n < workers; n++ {
+		p.workers.Add(1)
+		go func() {
+			defer p.workers.Done()
+			for job := range p.jobs {
+				job.err = place(&job)
+				p.mu.Lock()
+				p.done = append(p.done, job)
+				p.mu.Unlock()
+				p.inFlight.Done()
+			}
+		}()
+	}
+	return p
+}
+
+// Submit queues a placement, blocking while the queue is full
+func (p *copyPool) Submit(job placement) {
+	p.mu.Lock()
+	p.pending[job.outFile] = true
+	p.mu.Unlock()
+	p.inFlight.Add(1)
+	p.jobs <- job
+}
+
+// Pending reports whether a placement to outFile has not been collected yet
+func (p *copyPool) Pending(outFile string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pending[outFile]
+}
+
+// Collect returns the placements finished since the last call
+func (p *copyPool) Collect() []placement {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	done := p.done
+	p.done = nil
+	for _, job := range done {
+		delete(p.pending, job.outFile)
+	}
+	return done
+}
+
+// Wait blocks until all queued placements are finished and collects them
+func (p *copyPool) Wait() []placement {
+	if p == nil {
+		return nil
+	}
+	p.inFlight.Wait()
+	return p.Collect()
+}
+
+// Close stops the workers once the queue is empty
+func (p *copyPool) Close() {
+	close(p.jobs)
+	p.workers.Wait()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	crcOk       = "ok"
+	crcMismatch = "mismatch"
+)
+
+// release groups embed the crc32 of the file in brackets right before the
+// extension, ie. "Show - 01 [1080p][1A2B3C4D].mkv"
+var crcReg = regexp.MustCompile(`[\[(]([0-9A-Fa-f]{8})[\])]\s*$`)
+
+// filenameCrc returns the upper case crc32 embedded in the file name, if any.
+// Bracketed hex tokens elsewhere in the name, ie. release group hashes, are
+// not taken for one.
+func filenameCrc(moviePath string) string {
+	m := crcReg.FindStringSubmatch(fNameSansExtension(moviePath))
+	if m == nil {
+		return ""
+	}
+	return strings.ToUpper(m[1])
+}
+
+func fileCrc(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%08X", h.Sum32()), nil
+}
+
+// verifyCrc checks the file against the crc32 in its name, returning
+// "ok", "mismatch" or an empty string when the name has no crc32
+func verifyCrc(moviePath string) (string, error) {
+	expected := filenameCrc(moviePath)
+	if expected == "" {
+		return "", nil
+	}
+
+	actual, err := fileCrc(moviePath)
+	if err != nil {
+		return "", err
+	}
+
+	if actual != expected {
+		fmt.Printf(tr("Warning: crc32 mismatch, file name says %s but file is %s, the download may be corrupt\n"), expected, actual)
+		return crcMismatch, nil
+	}
+
+	fmt.Printf(tr("Crc32 %s verified\n"), actual)
+	return crcOk, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// conflictItem is an in file whose out file already exists with different
+// content, left for the end of the run
+type conflictItem struct {
+	index     int
+	moviePath string
+	outFile   string
+}
+
+// resolveDeferredConflicts lists the conflicts found during the run grouped
+// by out directory, then places them with a policy chosen for all of them
+// or prompts for each one
+func (o *Organizer) resolveDeferredConflicts(session *Session, movieList []string) {
+	if len(o.conflicts) == 0 {
+		return
+	}
+
+	items := o.conflicts
+	o.conflicts = nil
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return filepath.Dir(items[i].outFile) < filepath.Dir(items[j].outFile)
+	})
+
+	fmt.Printf(tr("\n%d out files already exist with different content:\n"), len(items))
+	dir := ""
+	for _, item := range items {
+		if d := filepath.Dir(item.outFile); d != dir {
+			dir = d
+			fmt.Println(ColorStr(GreenColor, dir))
+		}
+		fmt.Printf("  %s %s %s\n", filepath.Base(item.outFile), arrowStr(), item.moviePath)
+	}
+
+	policy := o.chooseDeferredPolicy()
+	o.conflictPolicy = policy
+	o.resolvingConflicts = true
+	defer func() {
+		o.conflictPolicy = ""
+		o.resolvingConflicts = false
+	}()
+
+	for _, item := range items {
+		session.Start(item.index)
+		err := o.processFile(session, item.index, item.moviePath, movieList)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrQuit) {
+			session.Quit()
+			return
+		}
+		session.Failed(item.moviePath, err)
+	}
+}
+
+func (o *Organizer) chooseDeferredPolicy() conflictPolicy {
+	for {
+		fmt.Print(promptStr(tr("[o] overwrite all, [k] keep both for all, [s] skip all, [e] decide for each")))
+		raw, err := o.reader.ReadString('\n')
+		if err != nil {
+			return skipConflict
+		}
+
+		switch strings.ToLower(strings.TrimSpace(raw)) {
+		case "o":
+			return overwriteConflict
+		case "k":
+			return keepBothConflict
+		case "s":
+			return skipConflict
+		case "e", "":
+			return promptConflict
+		default:
+			fmt.Println(tr("Please select one of the listed options."))
+		}
+	}
+}
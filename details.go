@@ -0,0 +1,66 @@
+package main
+
+// MediaDetails is metadata of the selected movie or tv show kept in the
+// manifest, so exports and audits do not need to query moviedb again
+type MediaDetails struct {
+	OriginalTitle    string   `json:"original_title,omitempty"`
+	OriginalLanguage string   `json:"original_language,omitempty"`
+	Runtime          int      `json:"runtime,omitempty"`
+	Genres           []string `json:"genres,omitempty"`
+}
+
+func genreNames(genres []Genre) []string {
+	names := []string{}
+	for _, genre := range genres {
+		names = append(names, genre.Name)
+	}
+	return names
+}
+
+// fetchDetails gets the movie or tv show details of the selected media
+func fetchDetails(provider MetadataProvider, media Media) (*MediaDetails, error) {
+	switch m := media.(type) {
+	case Movie:
+		movie, err := provider.GetMovie(m.Id)
+		if err != nil {
+			return nil, err
+		}
+		return &MediaDetails{
+			OriginalTitle:    movie.OriginalTitle,
+			OriginalLanguage: movie.OriginalLanguage,
+			Runtime:          movie.Runtime,
+			Genres:           genreNames(movie.Genres),
+		}, nil
+	case TvEpisode:
+		tv, err := provider.GetTv(m.TvId)
+		if err != nil {
+			return nil, err
+		}
+		details := &MediaDetails{
+			OriginalTitle:    tv.OriginalName,
+			OriginalLanguage: tv.OriginalLanguage,
+			Genres:           genreNames(tv.Genres),
+		}
+		if len(tv.EpisodeRunTime) > 0 {
+			details.Runtime = tv.EpisodeRunTime[0]
+		}
+		return details, nil
+	default:
+		return nil, nil
+	}
+}
+
+// fetchImdbId returns the imdb id of the movie or episode
+func fetchImdbId(provider MetadataProvider, media Media) (string, error) {
+	var (
+		externalIds ExternalIds
+		err         error
+	)
+	switch m := media.(type) {
+	case Movie:
+		externalIds, err = provider.GetMovieExternalIds(m.Id)
+	case TvEpisode:
+		externalIds, err = provider.GetEpisodeExternalIds(m)
+	}
+	return externalIds.ImdbId, err
+}
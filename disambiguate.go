@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	disambiguateById       = "id"
+	disambiguateByDirector = "director"
+	disambiguateNone       = "none"
+)
+
+type CrewMember struct {
+	Name string `json:"name"`
+	Job  string `json:"job"`
+}
+
+type CastMember struct {
+	Name      string `json:"name"`
+	Character string `json:"character"`
+	Order     int    `json:"order"`
+}
+
+type Credits struct {
+	Id   int64        `json:"id"`
+	Cast []CastMember `json:"cast"`
+	Crew []CrewMember `json:"crew"`
+}
+
+// directors returns the names of the directors in the crew
+func (c Credits) directors() []string {
+	names := []string{}
+	for _, member := range c.Crew {
+		if member.Job == "Director" && !stringSliceContains(names, member.Name) {
+			names = append(names, member.Name)
+		}
+	}
+	return names
+}
+
+// disambiguationHint fetches a detail telling apart results with the
+// same title and year, the director of movies or the country of tv shows
+func disambiguationHint(provider MetadataProvider, media Media) string {
+	switch m := media.(type) {
+	case Movie:
+		credits, err := provider.GetMovieCredits(m.Id)
+		if err == nil {
+			if directors := credits.directors(); len(directors) > 0 {
+				return fmt.Sprintf(tr("directed by %s"), strings.Join(directors, ", "))
+			}
+		}
+		movie, err := provider.GetMovie(m.Id)
+		if err == nil && len(movie.ProductionCountries) > 0 {
+			return movie.ProductionCountries[0].Name
+		}
+	case Tv:
+		if len(m.OriginCountry) > 0 {
+			return strings.Join(m.OriginCountry, ", ")
+		}
+	}
+	return ""
+}
+
+// pathSuffix returns the suffix appended to the out path of movie,
+// falling back to the id when the director is not known
+func pathSuffix(provider MetadataProvider, movie Movie, by string) string {
+	if by == disambiguateByDirector {
+		credits, err := provider.GetMovieCredits(movie.Id)
+		if err == nil {
+			if directors := credits.directors(); len(directors) > 0 {
+				return fmt.Sprintf("- %s", sanitizeFileName(strings.Join(directors, ", ")))
+			}
+		}
+	}
+	return fmt.Sprintf("[%sid-%d]", idSource(provider.Name()), movie.Id)
+}
+
+// suffixOutFile appends suffix to the file name and the directory
+// of outFile, keeping the extension
+func suffixOutFile(outFile, suffix string) string {
+	ext := filepath.Ext(outFile)
+	dir, file := filepath.Split(strings.TrimSuffix(outFile, ext))
+	dir = filepath.Clean(dir)
+	return filepath.Join(fmt.Sprintf("%s %s", dir, suffix), fmt.Sprintf("%s %s%s", file, suffix, ext))
+}
+
+// disambiguateOutFile appends the id or director of movie to outFile when
+// the manifest shows a different movie already placed with the same out file
+// name, so movies with the same title and year do not collide. Movies sharing
+// only a directory are left alone.
+func (o *Organizer) disambiguateOutFile(provider MetadataProvider, outFile string, movie Movie) string {
+	if *disambiguateFlag == disambiguateNone {
+		return outFile
+	}
+
+	id, ok := o.manifestIndex.OutNameId(outFile)
+	if !ok || id == (mediaId{provider.Name(), movie.Id}) {
+		return outFile
+	}
+
+	suffix := pathSuffix(provider, movie, *disambiguateFlag)
+	fmt.Printf(tr("Out file is used by a different movie with the same title and year (id %d), appending %q\n"), id.id, suffix)
+	return suffixOutFile(outFile, suffix)
+}
+
+// disambiguationHints returns hints for results that share title and year with another result
+func disambiguationHints(provider MetadataProvider, results []Media) map[int]string {
+	counts := make(map[string]int)
+	key := func(media Media) string {
+		return fmt.Sprintf("%s\x00%s", strings.ToLower(media.GetName()), media.GetYear())
+	}
+	for _, result := range results {
+		counts[key(result)] += 1
+	}
+
+	hints := make(map[int]string)
+	for i, result := range results {
+		if counts[key(result)] < 2 {
+			continue
+		}
+		if hint := disambiguationHint(provider, result); hint != "" {
+			hints[i] = hint
+		}
+	}
+	return hints
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const unrarBin = "unrar"
+
+type doctorStatus int
+
+const (
+	doctorOk doctorStatus = iota
+	doctorWarn
+	doctorFail
+)
+
+// doctorFinding is the result of one environment check, with a hint
+// on how to fix it when it is not ok
+type doctorFinding struct {
+	status doctorStatus
+	check  string
+	detail string
+	hint   string
+}
+
+func (f doctorFinding) String() string {
+	var status string
+	switch f.status {
+	case doctorOk:
+		status = ColorStr(GreenColor, tr("ok"))
+	case doctorWarn:
+		status = ColorStr(YellowColor, tr("warn"))
+	default:
+		status = ColorStr(RedColor, tr("fail"))
+	}
+	s := fmt.Sprintf("[%s] %s: %s", status, f.check, f.detail)
+	if f.hint != "" {
+		s += fmt.Sprintf("\n       %s", f.hint)
+	}
+	return s
+}
+
+func checkProvider(name, apiKey string) doctorFinding {
+	check := fmt.Sprintf(tr("%s api key"), name)
+	if apiKey == "" {
+		return doctorFinding{doctorFail, check, tr("not set"), fmt.Sprintf(tr("set -api-key, or api_keys in the config file (%s)"), providerApiKeyUrls[name])}
+	}
+	provider, err := newProvider(name, apiKey)
+	if err != nil {
+		return doctorFinding{doctorFail, check, err.Error(), tr("set -provider to moviedb or tvdb")}
+	}
+	err = provider.Ping()
+	if errors.Is(err, ErrInvalidApiKey) {
+		return doctorFinding{doctorFail, check, tr("invalid"), fmt.Sprintf(tr("check the value of -api-key (%s)"), providerApiKeyUrls[name])}
+	} else if err != nil {
+		return doctorFinding{doctorFail, check, err.Error(), tr("check the network connection")}
+	}
+	return doctorFinding{doctorOk, check, tr("works"), ""}
+}
+
+func checkInDir(dir string) doctorFinding {
+	check := fmt.Sprintf(tr("in dir %s"), dir)
+	_, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return doctorFinding{doctorFail, check, err.Error(), tr("check that the directory exists and is readable by this user")}
+	}
+	return doctorFinding{doctorOk, check, tr("readable"), ""}
+}
+
+func checkOutDir(dir string) doctorFinding {
+	check := fmt.Sprintf(tr("out dir %s"), dir)
+	f, err := ioutil.TempFile(dir, ".doctor")
+	if err != nil {
+		return doctorFinding{doctorFail, check, err.Error(), tr("check that the directory is writable by this user")}
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return doctorFinding{doctorOk, check, tr("writable"), ""}
+}
+
+// checkHardlinks links an in file into outDir, which seeding and -link
+// hardlink need and copies try first, without writing to the in dir
+func checkHardlinks(inFile, outDir string) doctorFinding {
+	check := fmt.Sprintf(tr("hardlinks to %s"), outDir)
+	if inFile == "" {
+		return doctorFinding{doctorWarn, check, tr("not checked, no in files found"), ""}
+	}
+	dst := filepath.Join(outDir, fmt.Sprintf(".doctor-%d", os.Getpid()))
+	err := os.Link(inFile, dst)
+	if err != nil && *linkFlag == hardlinkMode {
+		return doctorFinding{doctorFail, check, err.Error(), fmt.Sprintf(tr("in and out dirs are on different file systems, use -link %s to copy files that can't be linked"), hardlinkOrCopyMode)}
+	} else if err != nil {
+		return doctorFinding{doctorWarn, check, err.Error(), tr("in and out dirs are on different file systems, files are copied and seeding falls back to symlinks")}
+	}
+	os.Remove(dst)
+	return doctorFinding{doctorOk, check, tr("work"), ""}
+}
+
+func checkTool(bin, use string) doctorFinding {
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return doctorFinding{doctorWarn, bin, tr("not found in PATH"), fmt.Sprintf(tr("install it to %s"), use)}
+	}
+	return doctorFinding{doctorOk, bin, path, ""}
+}
+
+// checkDiskSpace compares the free space of outDir to the size of the in files
+func checkDiskSpace(outDir string, inFiles []string) doctorFinding {
+	check := fmt.Sprintf(tr("disk space of %s"), outDir)
+	free, err := freeSpace(outDir)
+	if err != nil {
+		return doctorFinding{doctorWarn, check, err.Error(), ""}
+	}
+
+	var needed uint64
+	for _, inFile := range inFiles {
+		if info, err := os.Stat(inFile); err == nil {
+			needed += uint64(info.Size())
+		}
+	}
+
+	detail := fmt.Sprintf(tr("%s free, in files are %s"), humanize.Bytes(free), humanize.Bytes(needed))
+	if free < needed {
+		return doctorFinding{doctorWarn, check, detail, tr("free space or use -mv on the same file system")}
+	}
+	return doctorFinding{doctorOk, check, detail, ""}
+}
+
+// checkManifest parses the manifest and journal and looks for out files
+// that no longer exist
+func checkManifest(manifestPath string) doctorFinding {
+	check := fmt.Sprintf(tr("manifest %s"), manifestPath)
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		return doctorFinding{doctorFail, check, err.Error(), tr("fix or move the manifest file, a backup may be pulled with the manifest command")}
+	}
+
+	missing := 0
+	for _, entry := range manifest {
+		if entry.OutFile == "" {
+			continue
+		}
+		if exists, err := fileExists(entry.OutFile); err == nil && !exists {
+			missing += 1
+		}
+	}
+
+	detail := fmt.Sprintf(tr("%d entries"), len(manifest))
+	if missing > 0 {
+		return doctorFinding{doctorWarn, check, fmt.Sprintf(tr("%s, %d out files no longer exist"), detail, missing), tr("missing out files are placed again on the next run unless their in files are gone")}
+	}
+	if exists, err := fileExists(manifestJournalPath(manifestPath)); err == nil && exists {
+		return doctorFinding{doctorWarn, check, fmt.Sprintf(tr("%s, journal of an interrupted run left over"), detail), tr("it is merged into the manifest by the next run")}
+	}
+	return doctorFinding{doctorOk, check, detail, ""}
+}
+
+// runDoctorCommand checks the environment of a run, printing a finding for
+// every check, and fails when any check failed
+func runDoctorCommand(inDirs, exts, outDirs []string, manifestPath string, apiKeys map[string]string) error {
+	findings := []doctorFinding{}
+	for i, name := range splitCsv(*providerFlag) {
+		findings = append(findings, checkProvider(name, providerApiKey(name, i == 0, *apiKeyFlag, apiKeys)))
+	}
+
+	for _, dir := range inDirs {
+		findings = append(findings, checkInDir(dir))
+	}
+	inFiles, err := lsMoviesAll(inDirs, exts)
+	if err != nil {
+		inFiles = []string{}
+	}
+	inFile := ""
+	if len(inFiles) > 0 {
+		inFile = inFiles[0]
+	}
+
+	outDirs = sortUniq(outDirs)
+	for _, dir := range outDirs {
+		out := checkOutDir(dir)
+		findings = append(findings, out)
+		if out.status == doctorOk {
+			findings = append(findings, checkHardlinks(inFile, dir), checkDiskSpace(dir, inFiles))
+		}
+	}
+
+	findings = append(findings,
+		checkTool(ffprobeBin, tr("compare conflicting files and use media info in templates")),
+		checkTool(unrarBin, tr("extract rar releases, only extracted video files are placed")),
+		checkManifest(manifestPath),
+	)
+
+	failed := 0
+	for _, finding := range findings {
+		fmt.Println(finding)
+		if finding.status == doctorFail {
+			failed += 1
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	emailOnAlways  = "always"
+	emailOnFailure = "failure"
+)
+
+// sendReport emails the end-of-run report using the smtp flags
+func sendReport(subject, body string) error {
+	if *smtpHostFlag == "" {
+		return fmt.Errorf("smtp-host is required to send email")
+	}
+
+	from := *emailFromFlag
+	if from == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "localhost"
+		}
+		from = fmt.Sprintf("%s@%s", BinName, hostname)
+	}
+
+	to := []string{}
+	for _, addr := range strings.Split(*emailToFlag, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr != "" {
+			to = append(to, addr)
+		}
+	}
+
+	msg := strings.Join([]string{
+		fmt.Sprintf("From: %s", from),
+		fmt.Sprintf("To: %s", strings.Join(to, ", ")),
+		fmt.Sprintf("Subject: %s", subject),
+		fmt.Sprintf("Date: %s", time.Now().Format(time.RFC1123Z)),
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	var auth smtp.Auth
+	if *smtpUserFlag != "" {
+		auth = smtp.PlainAuth("", *smtpUserFlag, *smtpPasswordFlag, *smtpHostFlag)
+	}
+
+	addr := net.JoinHostPort(*smtpHostFlag, fmt.Sprintf("%d", *smtpPortFlag))
+	return smtp.SendMail(addr, auth, from, to, []byte(msg))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const envPrefix = "MVIEDB_"
+
+// flagEnvName returns the environment variable of a flag, ie. MVIEDB_API_KEY for api-key
+func flagEnvName(name string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// flagsFromEnv sets the flags that were not given on the command line from
+// their MVIEDB_* environment variables, so that flags override the environment
+func flagsFromEnv(fs *flag.FlagSet) error {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || given[f.Name] {
+			return
+		}
+		value, ok := os.LookupEnv(flagEnvName(f.Name))
+		if !ok {
+			return
+		}
+		if serr := fs.Set(f.Name, value); serr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %w", value, flagEnvName(f.Name), serr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// explainTokens prints every token of str and whether it is kept for the query
+func explainTokens(str string, stopWords []string) {
+	cleaned := queryReg.ReplaceAllString(str, " ")
+	tokens := strings.Fields(strings.ToLower(cleaned))
+	fmt.Printf(tr("  raw tokens: %s\n"), strings.Join(tokens, " "))
+	ids := idTokens(tokens)
+	for i, token := range tokens {
+		if ids[i] {
+			fmt.Printf(tr("    %-20s dropped (imdb/tmdb id)\n"), token)
+		} else if stringSliceContains(stopWords, token) {
+			fmt.Printf(tr("    %-20s dropped (stop word)\n"), token)
+		} else if !isQueryToken(token, stopWords) {
+			fmt.Printf(tr("    %-20s dropped (single character)\n"), token)
+		} else {
+			fmt.Printf(tr("    %-20s kept\n"), token)
+		}
+	}
+}
+
+// explain prints step by step how the search query for moviePath is built
+func explain(moviePath, inDir string, movieList, stopWords []string) error {
+	ext := filepath.Ext(moviePath)
+	name := moviePath[0 : len(moviePath)-len(ext)]
+	relativeName := strings.TrimPrefix(name, inDir+string(filepath.Separator))
+	fileName := filepath.Base(name)
+
+	fmt.Printf(tr("File: %s\n"), moviePath)
+	fmt.Printf(tr("In dir: %s\n\n"), inDir)
+
+	fmt.Printf(tr("1. Tokens from file name %q\n"), fileName)
+	explainTokens(fileName, stopWords)
+	fileQuery := buildQuery(fileName, stopWords)
+	fmt.Printf(tr("  query: %q\n\n"), fileQuery)
+
+	query := fileQuery
+	testQuery, _, _, _ := extractTvSeasonEpisodeFromQuery(fileQuery)
+	if isObfuscatedName(fileName) {
+		query = obfuscatedQuery(moviePath, inDir, stopWords)
+		fmt.Print(tr("2. File name looks obfuscated, using the directory name or the title of an nfo file\n"))
+		fmt.Printf(tr("  query: %q\n"), query)
+		fmt.Print(tr("  matches need to be confirmed, batch mode leaves the file for review\n\n"))
+	} else if testQuery == "" {
+		fmt.Printf(tr("2. Nothing left after season/episode/year extraction, using path relative to in dir %q\n"), relativeName)
+		explainTokens(relativeName, stopWords)
+		query = buildQuery(relativeName, stopWords)
+		fmt.Printf(tr("  query: %q\n\n"), query)
+	} else {
+		fmt.Print(tr("2. File name query is not empty after extraction, relative path not used\n\n"))
+	}
+	if !isObfuscatedName(fileName) && !informativeQuery(query) {
+		if hintQuery, hintPath := releaseHintQuery(moviePath, inDir, stopWords); hintQuery != "" {
+			query = hintQuery
+			fmt.Printf(tr("2b. No year, air date or season/episode in the query, using the release name in %s\n"), hintPath)
+			fmt.Printf(tr("  query: %q\n\n"), query)
+		}
+	}
+
+	dateQuery, airDate := extractAirDate(query)
+	myQuery, season, episode, year := extractTvSeasonEpisodeFromQuery(dateQuery)
+	absolute := 0
+	if *animeFlag && season == 0 && episode == 0 && airDate == "" {
+		myQuery, absolute = extractAbsoluteEpisode(myQuery)
+	}
+	fmt.Println(tr("3. Air date/season/episode/year extraction"))
+	fmt.Printf(tr("  query: %q\n"), myQuery)
+	if airDate != "" {
+		fmt.Printf(tr("  air date: %s\n"), airDate)
+	}
+	if absolute > 0 {
+		fmt.Printf(tr("  absolute episode: %d\n"), absolute)
+	}
+	fmt.Printf(tr("  season: %d, episode: %d, year: %d\n"), season, episode, year)
+	if ids := findExternalIds(moviePath, inDir); !ids.empty() {
+		kind := tr("movie")
+		if airDate != "" || absolute > 0 || season > 0 || episode > 0 {
+			kind = tr("tv show")
+		}
+		fmt.Printf(tr("  %s found in the file name, nfo or torrent, the %s is looked up by it instead of searched\n"), ids, kind)
+	}
+	if airDate != "" {
+		fmt.Print(tr("  air date found, searching tv shows and the episode aired that day\n\n"))
+	} else if absolute > 0 {
+		fmt.Print(tr("  absolute episode found, searching tv shows and mapping it to season and episode\n\n"))
+	} else if season == 0 && episode == 0 {
+		fmt.Print(tr("  no season/episode found, searching movies\n\n"))
+	} else {
+		fmt.Print(tr("  season/episode found, searching tv shows\n\n"))
+	}
+
+	fmt.Println(tr("4. Common directory tokens"))
+	if *noCommonDirFlag {
+		fmt.Println(tr("  disabled by no-common-dir"))
+		return nil
+	}
+	common, err := commonDirWords(moviePath, movieList, stopWords, *commonDirScopeFlag, *commonDirMinPeersFlag)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(tr("  scope: %s, minimum peers: %d\n"), *commonDirScopeFlag, *commonDirMinPeersFlag)
+	fmt.Printf(tr("  tokens shared with peer files in %s: %q\n"), filepath.Dir(moviePath), strings.Join(common, " "))
+	if len(common) > 0 {
+		fmt.Println(tr("  used as tv show query when switching seasons without a manual query"))
+	} else {
+		fmt.Println(tr("  none, file query is always used"))
+	}
+
+	return nil
+}
+
+func runExplainCommand(args []string, inDirs []string, exts, stopWords []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("Usage: %s %s [flags] <file>", BinName, explainCommand)
+	}
+
+	moviePath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	movieList, err := lsMoviesAll(inDirs, exts)
+	if err != nil {
+		return err
+	}
+
+	return explain(moviePath, inDirFor(inDirs, moviePath), movieList, stopWords)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var imdbIdReg = regexp.MustCompile(`^tt\d{7,8}$`)
+
+var (
+	// tokens naming the imdb id that follows them, ie. the "[imdbid-tt0133093]"
+	// of jellyfin directory names
+	imdbIdTokens = []string{"imdb", "imdbid"}
+	// tokens naming the tmdb id that follows them, ie. "tmdb-603" or the
+	// "<uniqueid type="tmdb">603</uniqueid>" of kodi nfo files
+	tmdbIdTokens = []string{"tmdb", "tmdbid"}
+)
+
+// nfo files larger than this aren't searched for ids
+const maxNfoSize = 1 << 20
+
+// externalIds are the imdb and tmdb ids embedded in a release
+type externalIds struct {
+	imdbId string
+	tmdbId int64
+}
+
+func (e externalIds) empty() bool {
+	return e.imdbId == "" && e.tmdbId == 0
+}
+
+// id returns the imdb id, or else the tmdb id as "tmdb-603"
+func (e externalIds) id() string {
+	if e.imdbId != "" {
+		return e.imdbId
+	}
+	return fmt.Sprintf("tmdb-%d", e.tmdbId)
+}
+
+func (e externalIds) String() string {
+	if e.imdbId != "" {
+		return fmt.Sprintf("imdb id %s", e.imdbId)
+	}
+	return fmt.Sprintf("tmdb id %d", e.tmdbId)
+}
+
+// idTokens reports which of the lower case tokens are an imdb or tmdb id,
+// or name the id following them
+func idTokens(tokens []string) []bool {
+	ids := make([]bool, len(tokens))
+	for i, token := range tokens {
+		if imdbIdReg.MatchString(token) {
+			ids[i] = true
+			if i > 0 && stringSliceContains(imdbIdTokens, tokens[i-1]) {
+				ids[i-1] = true
+			}
+		} else if i > 0 && intReg.MatchString(token) && stringSliceContains(tmdbIdTokens, tokens[i-1]) {
+			ids[i-1] = true
+			ids[i] = true
+		}
+	}
+	return ids
+}
+
+// extractExternalIds returns the first imdb and tmdb ids among the tokens of str
+func extractExternalIds(str string) externalIds {
+	ids := externalIds{}
+	tokens := strings.Fields(strings.ToLower(queryReg.ReplaceAllString(str, " ")))
+	for i, token := range tokens {
+		if ids.imdbId == "" && imdbIdReg.MatchString(token) {
+			ids.imdbId = token
+		} else if ids.tmdbId == 0 && i > 0 && stringSliceContains(tmdbIdTokens, tokens[i-1]) {
+			ids.tmdbId, _ = strconv.ParseInt(token, 10, 64)
+		}
+	}
+	return ids
+}
+
+// nfoFiles returns the nfo file named after moviePath, followed by the other
+// nfo files of its directory unless that is the in dir, ie. the "movie.nfo"
+// of a release directory
+func nfoFiles(moviePath, inDir string) []string {
+	return siblingFiles(moviePath, inDir, ".nfo")
+}
+
+// findExternalIds returns the ids in the path of moviePath relative to its
+// in dir, or else in the nfo and torrent files next to it, ie. the imdb link
+// of a scene nfo or the comment of a torrent
+func findExternalIds(moviePath, inDir string) externalIds {
+	name := strings.TrimSuffix(moviePath, filepath.Ext(moviePath))
+	ids := extractExternalIds(strings.TrimPrefix(name, inDir+string(filepath.Separator)))
+	if !ids.empty() {
+		return ids
+	}
+
+	for _, hint := range releaseHints(moviePath, inDir) {
+		if ids = extractExternalIds(hint.text); !ids.empty() {
+			return ids
+		}
+	}
+	return ids
+}
+
+// movieDbMember returns the provider tmdb ids are valid in, making it the
+// one a chain looks them up with
+func movieDbMember(provider MetadataProvider) (MetadataProvider, bool) {
+	if chain, ok := provider.(*ProviderChain); ok {
+		member, ok := chain.member(movieDbProvider)
+		if ok {
+			chain.use(member)
+		}
+		return chain, ok
+	}
+	return provider, provider.Name() == movieDbProvider
+}
+
+// mediaByExternalIds looks up the movie, or the tv show when tv is set, of
+// the ids embedded in a release. Imdb ids are preferred, they are valid with
+// every provider.
+func (s *Selector) mediaByExternalIds(ids externalIds, tv bool) (Media, error) {
+	if ids.imdbId != "" {
+		response, err := s.provider.FindByImdbId(ids.imdbId)
+		if err != nil {
+			return Movie{}, err
+		}
+		if tv && len(response.TvResults) > 0 {
+			return response.TvResults[0], nil
+		} else if !tv && len(response.MovieResults) > 0 {
+			return response.MovieResults[0], nil
+		}
+		if tv {
+			return Movie{}, fmt.Errorf("no tv show with %s", ids)
+		}
+		return Movie{}, fmt.Errorf("no movie with %s", ids)
+	}
+
+	provider, ok := movieDbMember(s.provider)
+	if !ok {
+		return Movie{}, fmt.Errorf("%s needs the %s provider", ids, movieDbProvider)
+	}
+	if tv {
+		return provider.GetTv(ids.tmdbId)
+	}
+	return provider.GetMovie(ids.tmdbId)
+}
+
+// tvShowQuery returns the tv show part of a query, without air date, season,
+// episode, year or absolute episode, and whether the query is of an episode
+func tvShowQuery(query string) (string, bool) {
+	dateQuery, airDate := extractAirDate(query)
+	showQuery, season, episode, _ := extractTvSeasonEpisodeFromQuery(dateQuery)
+	absolute := 0
+	if *animeFlag && season == 0 && episode == 0 && airDate == "" {
+		showQuery, absolute = extractAbsoluteEpisode(showQuery)
+	}
+	return showQuery, season > 0 || airDate != "" || absolute > 0
+}
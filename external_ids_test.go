@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestExtractExternalIds(t *testing.T) {
+	tests := []struct {
+		str  string
+		want externalIds
+	}{
+		{"The Matrix (1999) [imdbid-tt0133093]", externalIds{imdbId: "tt0133093"}},
+		{"The Matrix (1999) {tmdb-603}", externalIds{tmdbId: 603}},
+		{"The.Matrix.1999.tt0133093.tmdbid.603", externalIds{imdbId: "tt0133093", tmdbId: 603}},
+		{"https://www.imdb.com/title/tt10872600/", externalIds{imdbId: "tt10872600"}},
+		{`<uniqueid type="tmdb">603</uniqueid>`, externalIds{tmdbId: 603}},
+		{"The Matrix 1999 1080p", externalIds{}},
+		{"tt123 tmdb", externalIds{}},
+	}
+	for _, test := range tests {
+		if got := extractExternalIds(test.str); got != test.want {
+			t.Errorf("extractExternalIds(%q) = %+v, want %+v", test.str, got, test.want)
+		}
+	}
+}
+
+func TestIdTokens(t *testing.T) {
+	tokens := []string{"the", "matrix", "imdbid", "tt0133093", "tmdb", "603", "1999"}
+	want := []bool{false, false, true, true, true, true, false}
+	got := idTokens(tokens)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("idTokens(%q) = %v, want %v", tokens, got, want)
+			break
+		}
+	}
+}
+
+func TestExternalIdsString(t *testing.T) {
+	ids := externalIds{imdbId: "tt0133093", tmdbId: 603}
+	if ids.id() != "tt0133093" || ids.String() != "imdb id tt0133093" {
+		t.Errorf("id() = %q, String() = %q, want the imdb id", ids.id(), ids.String())
+	}
+	ids = externalIds{tmdbId: 603}
+	if ids.id() != "tmdb-603" || ids.String() != "tmdb id 603" {
+		t.Errorf("id() = %q, String() = %q, want the tmdb id", ids.id(), ids.String())
+	}
+	if !(externalIds{}).empty() || ids.empty() {
+		t.Errorf("empty() is wrong")
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var healthPingInterval = time.Minute
+
+// WatchStatus is shared between the watch loop and the health endpoint
+type WatchStatus struct {
+	mu            sync.Mutex
+	scanning      bool
+	scanStartedAt time.Time
+	lastScanAt    time.Time
+	nextScanAt    time.Time
+	interval      time.Duration
+	// scanTimeout is how long a scan may run before it is reported as wedged
+	scanTimeout time.Duration
+
+	// pingMu guards the last ping separately, so a slow moviedb does not
+	// hold up the watch loop
+	pingMu     sync.Mutex
+	lastPingAt time.Time
+	lastPing   error
+}
+
+func NewWatchStatus(scanTimeout time.Duration) *WatchStatus {
+	return &WatchStatus{scanTimeout: scanTimeout}
+}
+
+func (s *WatchStatus) ScanStarted() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scanning = true
+	s.scanStartedAt = time.Now()
+}
+
+func (s *WatchStatus) ScanFinished(next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scanning = false
+	s.lastScanAt = time.Now()
+	s.interval = next.Sub(s.lastScanAt)
+	s.nextScanAt = next
+}
+
+type healthCheck struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type healthReport struct {
+	Ok         bool        `json:"ok"`
+	MovieDb    healthCheck `json:"moviedb"`
+	Manifest   healthCheck `json:"manifest"`
+	Watcher    healthCheck `json:"watcher"`
+	Scanning   bool        `json:"scanning"`
+	LastScanAt time.Time   `json:"last_scan_at"`
+	NextScanAt time.Time   `json:"next_scan_at"`
+}
+
+func newHealthCheck(err error) healthCheck {
+	if err != nil {
+		return healthCheck{Ok: false, Error: err.Error()}
+	}
+	return healthCheck{Ok: true}
+}
+
+// checkWritable verifies that a file can be created in the manifest directory
+// and that an existing manifest can be opened for writing
+func checkWritable(manifestPath string) error {
+	exists, err := fileExists(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		f, err := os.OpenFile(manifestPath, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(manifestPath), ".healthz")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func (s *WatchStatus) ping(provider MetadataProvider) error {
+	s.pingMu.Lock()
+	defer s.pingMu.Unlock()
+	if time.Since(s.lastPingAt) > healthPingInterval {
+		s.lastPing = provider.Ping()
+		s.lastPingAt = time.Now()
+	}
+	return s.lastPing
+}
+
+func (s *WatchStatus) watcherError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scanning {
+		if s.scanTimeout > 0 && time.Since(s.scanStartedAt) > s.scanTimeout {
+			return fmt.Errorf("scan running since %s", s.scanStartedAt.Format(time.RFC3339))
+		}
+		return nil
+	}
+	if s.nextScanAt.IsZero() {
+		return nil
+	}
+	// a scan that has not started within one interval of its due time is wedged
+	if time.Since(s.nextScanAt) > s.interval {
+		return fmt.Errorf("scan overdue since %s", s.nextScanAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (s *WatchStatus) report(provider MetadataProvider, manifestPath string) healthReport {
+	report := healthReport{
+		MovieDb:  newHealthCheck(s.ping(provider)),
+		Manifest: newHealthCheck(checkWritable(manifestPath)),
+		Watcher:  newHealthCheck(s.watcherError()),
+	}
+	report.Ok = report.MovieDb.Ok && report.Manifest.Ok && report.Watcher.Ok
+
+	s.mu.Lock()
+	report.Scanning = s.scanning
+	report.LastScanAt = s.lastScanAt
+	report.NextScanAt = s.nextScanAt
+	s.mu.Unlock()
+
+	return report
+}
+
+// serveHealth exposes /healthz, responding 503 when any check fails
+func serveHealth(addr string, status *WatchStatus, provider MetadataProvider, manifestPath string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		report := status.report(provider, manifestPath)
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+
+	go func() {
+		err := http.ListenAndServe(addr, mux)
+		if err != nil {
+			log.Println("Health endpoint error:", err)
+		}
+	}()
+}
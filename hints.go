@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// torrent files larger than this aren't read, the pieces of large releases
+// take a few hundred kilobytes
+const maxTorrentSize = 10 << 20
+
+// sceneNameReg matches scene release names in nfo files, a dotted title
+// followed by the year or season and episode, and ending with the group,
+// ie. "The.Matrix.1999.1080p.BluRay.x264-GROUP"
+var sceneNameReg = regexp.MustCompile(`\b[A-Za-z0-9][A-Za-z0-9'&_.-]*[._](?:(?:19|20)\d{2}|[Ss]\d{1,2}[Ee]\d{1,3})(?:[._][A-Za-z0-9_.-]*)?-[A-Za-z0-9]+\b`)
+
+// releaseHint is what a scene nfo or a torrent file next to an in file tells
+// about its release
+type releaseHint struct {
+	path string
+	// the release name, ie. "The.Matrix.1999.1080p.BluRay.x264-GROUP"
+	name string
+	// text searched for imdb and tmdb ids, ie. an imdb link
+	text string
+}
+
+// siblingFiles returns the file with the extension ext named after moviePath,
+// followed by the other files with that extension of its directory unless
+// that is the in dir
+func siblingFiles(moviePath, inDir, ext string) []string {
+	dir := filepath.Dir(moviePath)
+	own := filepath.Join(dir, fNameSansExtension(moviePath)+ext)
+
+	files := []string{}
+	if exists, _ := fileExists(own); exists {
+		files = append(files, own)
+	}
+	if dir == filepath.Clean(inDir) {
+		return files
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+	for _, f := range infos {
+		path := filepath.Join(dir, f.Name())
+		if !f.IsDir() && strings.ToLower(filepath.Ext(f.Name())) == ext && path != own {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// readHintFile reads path unless it is larger than maxSize
+func readHintFile(path string, maxSize int64) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	} else if info.Size() > maxSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", path, maxSize)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// releaseHints returns the hints of the nfo and torrent files next to
+// moviePath, see siblingFiles
+func releaseHints(moviePath, inDir string) []releaseHint {
+	hints := []releaseHint{}
+	for _, nfo := range siblingFiles(moviePath, inDir, ".nfo") {
+		b, err := readHintFile(nfo, maxNfoSize)
+		if err != nil {
+			continue
+		}
+		text := string(b)
+		hints = append(hints, releaseHint{path: nfo, name: sceneNameReg.FindString(text), text: text})
+	}
+	for _, torrent := range siblingFiles(moviePath, inDir, ".torrent") {
+		b, err := readHintFile(torrent, maxTorrentSize)
+		if err != nil {
+			continue
+		}
+		name, comment, err := torrentInfo(b)
+		if err != nil {
+			continue
+		}
+		hints = append(hints, releaseHint{path: torrent, name: name, text: comment + "\n" + name})
+	}
+	return hints
+}
+
+// informativeQuery reports whether a query has a year, an air date or a
+// season and episode to narrow down the search
+func informativeQuery(query string) bool {
+	dateQuery, airDate := extractAirDate(query)
+	_, season, episode, year := extractTvSeasonEpisodeFromQuery(dateQuery)
+	return airDate != "" || season > 0 || episode > 0 || year > 0
+}
+
+// releaseHintQuery returns the query of the first release name of an nfo or
+// torrent file next to moviePath that is informative, with the file it is from
+func releaseHintQuery(moviePath, inDir string, stopWords []string) (string, string) {
+	for _, hint := range releaseHints(moviePath, inDir) {
+		if hint.name == "" {
+			continue
+		}
+		if query := buildQuery(hint.name, stopWords); informativeQuery(query) {
+			return query, hint.path
+		}
+	}
+	return "", ""
+}
+
+// torrentInfo returns the name and the comment of a torrent file. The name of
+// a single file torrent is the file name, which is returned without extension.
+func torrentInfo(b []byte) (string, string, error) {
+	v, _, err := bdecode(b)
+	if err != nil {
+		return "", "", err
+	}
+	torrent, ok := v.(map[string]interface{})
+	if !ok {
+		return "", "", fmt.Errorf("torrent is not a dictionary")
+	}
+	info, _ := torrent["info"].(map[string]interface{})
+	name, _ := info["name"].(string)
+	if _, ok := info["length"]; ok {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	comment, _ := torrent["comment"].(string)
+	return name, comment, nil
+}
+
+// bdecode decodes the bencoded value at the start of b, returning it with the
+// rest of b. Dictionaries decode to map[string]interface{}, lists to
+// []interface{}, strings to string and integers to int64.
+func bdecode(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, b, fmt.Errorf("unexpected end of bencoded data")
+	}
+
+	switch {
+	case b[0] == 'i':
+		end := bytes.IndexByte(b, 'e')
+		if end < 0 {
+			return nil, b, fmt.Errorf("unterminated bencoded integer")
+		}
+		n, err := strconv.ParseInt(string(b[1:end]), 10, 64)
+		return n, b[end+1:], err
+	case b[0] == 'l':
+		list := []interface{}{}
+		b = b[1:]
+		for len(b) > 0 && b[0] != 'e' {
+			v, rest, err := bdecode(b)
+			if err != nil {
+				return nil, rest, err
+			}
+			list = append(list, v)
+			b = rest
+		}
+		if len(b) == 0 {
+			return nil, b, fmt.Errorf("unterminated bencoded list")
+		}
+		return list, b[1:], nil
+	case b[0] == 'd':
+		dict := map[string]interface{}{}
+		b = b[1:]
+		for len(b) > 0 && b[0] != 'e' {
+			k, rest, err := bdecode(b)
+			if err != nil {
+				return nil, rest, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, rest, fmt.Errorf("bencoded dictionary key is not a string")
+			}
+			v, rest, err := bdecode(rest)
+			if err != nil {
+				return nil, rest, err
+			}
+			dict[key] = v
+			b = rest
+		}
+		if len(b) == 0 {
+			return nil, b, fmt.Errorf("unterminated bencoded dictionary")
+		}
+		return dict, b[1:], nil
+	case b[0] >= '0' && b[0] <= '9':
+		colon := bytes.IndexByte(b, ':')
+		if colon < 0 {
+			return nil, b, fmt.Errorf("invalid bencoded string")
+		}
+		n, err := strconv.Atoi(string(b[:colon]))
+		if err != nil || n < 0 || n > len(b)-colon-1 {
+			return nil, b, fmt.Errorf("invalid bencoded string length")
+		}
+		return string(b[colon+1 : colon+1+n]), b[colon+1+n:], nil
+	default:
+		return nil, b, fmt.Errorf("invalid bencoded value %q", b[0])
+	}
+}
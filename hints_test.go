@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBdecode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want interface{}
+		rest string
+	}{
+		{"i42e", int64(42), ""},
+		{"i-3eabc", int64(-3), "abc"},
+		{"4:spam", "spam", ""},
+		{"0:x", "", "x"},
+		{"l4:spami1ee", []interface{}{"spam", int64(1)}, ""},
+		{"d3:cow3:moo4:spaml1:a1:bee", map[string]interface{}{"cow": "moo", "spam": []interface{}{"a", "b"}}, ""},
+	}
+	for _, test := range tests {
+		got, rest, err := bdecode([]byte(test.in))
+		if err != nil {
+			t.Errorf("bdecode(%q) error: %v", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) || string(rest) != test.rest {
+			t.Errorf("bdecode(%q) = %#v, %q, want %#v, %q", test.in, got, rest, test.want, test.rest)
+		}
+	}
+}
+
+func TestBdecodeInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"i42",
+		"ixe",
+		"5:spam",
+		"9223372036854775807:x",
+		"-1:x",
+		"4spam",
+		"l4:spam",
+		"d3:cow3:moo",
+		"di1e3:mooe",
+		"x",
+	}
+	for _, in := range tests {
+		if got, _, err := bdecode([]byte(in)); err == nil {
+			t.Errorf("bdecode(%q) = %#v, want error", in, got)
+		}
+	}
+}
+
+func TestTorrentInfo(t *testing.T) {
+	tests := []struct {
+		in      string
+		name    string
+		comment string
+	}{
+		{
+			"d7:comment30:https://www.imdb.com/tt01337584:infod6:lengthi1e4:name36:The.Matrix.1999.1080p.BluRay-GRP.mkvee",
+			"The.Matrix.1999.1080p.BluRay-GRP",
+			"https://www.imdb.com/tt0133758",
+		},
+		{
+			"d4:infod5:filesle4:name25:Show.S01.1080p.WEB-DL-GRPee",
+			"Show.S01.1080p.WEB-DL-GRP",
+			"",
+		},
+	}
+	for _, test := range tests {
+		name, comment, err := torrentInfo([]byte(test.in))
+		if err != nil {
+			t.Errorf("torrentInfo(%q) error: %v", test.in, err)
+			continue
+		}
+		if name != test.name || comment != test.comment {
+			t.Errorf("torrentInfo(%q) = %q, %q, want %q, %q", test.in, name, comment, test.name, test.comment)
+		}
+	}
+
+	if _, _, err := torrentInfo([]byte("l4:spame")); err == nil {
+		t.Errorf("torrentInfo of a list, want error")
+	}
+}
+
+func TestSceneNameReg(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Release: The.Matrix.1999.1080p.BluRay.x264-GROUP\n", "The.Matrix.1999.1080p.BluRay.x264-GROUP"},
+		{"  Some.Show.S01E02.720p.HDTV.x264-GRP  ", "Some.Show.S01E02.720p.HDTV.x264-GRP"},
+		{"Amelie.2001-GRP", "Amelie.2001-GRP"},
+		{"Ocean's.Eleven.2001.DVDRip-GRP", "Ocean's.Eleven.2001.DVDRip-GRP"},
+		{"Released by GROUP in 2001", ""},
+		{"The.Matrix.1080p.BluRay-GRP", ""},
+	}
+	for _, test := range tests {
+		if got := sceneNameReg.FindString(test.in); got != test.want {
+			t.Errorf("sceneNameReg.FindString(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
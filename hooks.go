@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// hookEnv describes the match of an in file to hook commands
+func hookEnv(moviePath, outFile, verb string, media Media) []string {
+	return append(os.Environ(),
+		"MVIEDB_IN_FILE="+moviePath,
+		"MVIEDB_OUT_FILE="+outFile,
+		"MVIEDB_ID="+strconv.FormatInt(media.GetId(), 10),
+		"MVIEDB_TYPE="+media.GetType(),
+		"MVIEDB_NAME="+media.GetName(),
+		"MVIEDB_YEAR="+media.GetYear(),
+		"MVIEDB_SOURCE="+parseSource(moviePath),
+		"MVIEDB_MODE="+verb,
+	)
+}
+
+// runHook runs command with sh, passing the match in environment variables
+func runHook(name, command, moviePath, outFile, verb string, media Media) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = hookEnv(moviePath, outFile, verb, media)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("%s %q: %w", name, command, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// httpFixture is a recorded api response
+type httpFixture struct {
+	Url        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Status     string `json:"status"`
+	Body       string `json:"body"`
+}
+
+// fixtureTransport records api responses to dir, or replays them from dir
+// without touching the network
+type fixtureTransport struct {
+	dir    string
+	replay bool
+	next   http.RoundTripper
+}
+
+// redactUrl removes the api key so fixtures can be shared
+func redactUrl(u *url.URL) string {
+	redacted := *u
+	q := redacted.Query()
+	if q.Get("api_key") != "" {
+		q.Set("api_key", "REDACTED")
+	}
+	redacted.RawQuery = q.Encode()
+	return redacted.String()
+}
+
+func (t *fixtureTransport) fixturePath(u *url.URL) string {
+	sum := sha256.Sum256([]byte(redactUrl(u)))
+	return filepath.Join(t.dir, fmt.Sprintf("%s.json", hex.EncodeToString(sum[:])))
+}
+
+func (f httpFixture) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        f.Status,
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewBufferString(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}
+}
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fixturePath := t.fixturePath(req.URL)
+
+	if t.replay {
+		b, err := ioutil.ReadFile(fixturePath)
+		if err != nil {
+			return nil, fmt.Errorf("No recorded response for %s: %s", redactUrl(req.URL), err)
+		}
+		fixture := httpFixture{}
+		err = json.Unmarshal(b, &fixture)
+		if err != nil {
+			return nil, err
+		}
+		return fixture.response(req), nil
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := httpFixture{
+		Url:        redactUrl(req.URL),
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Body:       string(body),
+	}
+	b, err := json.MarshalIndent(fixture, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(t.dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ioutil.WriteFile(fixturePath, b, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return fixture.response(req), nil
+}
+
+// RecordHttp saves every api response in dir
+func (c *MovieDb) RecordHttp(dir string) {
+	c.Client.Transport = &fixtureTransport{dir: dir, replay: false, next: http.DefaultTransport}
+}
+
+// ReplayHttp answers every api request from responses previously recorded in dir
+func (c *MovieDb) ReplayHttp(dir string) {
+	c.Client.Transport = &fixtureTransport{dir: dir, replay: true}
+}
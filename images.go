@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ImagesConfiguration is the images section of the moviedb /configuration,
+// needed to build full urls of posters, backdrops and episode stills
+type ImagesConfiguration struct {
+	BaseUrl       string   `json:"base_url"`
+	SecureBaseUrl string   `json:"secure_base_url"`
+	BackdropSizes []string `json:"backdrop_sizes"`
+	LogoSizes     []string `json:"logo_sizes"`
+	PosterSizes   []string `json:"poster_sizes"`
+	ProfileSizes  []string `json:"profile_sizes"`
+	StillSizes    []string `json:"still_sizes"`
+}
+
+type Configuration struct {
+	Images     ImagesConfiguration `json:"images"`
+	ChangeKeys []string            `json:"change_keys"`
+}
+
+// GetConfiguration fetches the api configuration once per client
+func (c *MovieDb) GetConfiguration() (Configuration, error) {
+	c.cacheMutex.Lock()
+	configuration := c.configuration
+	c.cacheMutex.Unlock()
+	if configuration != nil {
+		return *configuration, nil
+	}
+
+	url, err := configurationUrl(c.ApiKey)
+	if err != nil {
+		return Configuration{}, err
+	}
+
+	body, err := c.get(url)
+	if err != nil {
+		return Configuration{}, err
+	}
+
+	configuration = &Configuration{}
+	err = json.Unmarshal(body, configuration)
+	if err != nil {
+		return Configuration{}, err
+	}
+
+	c.cacheMutex.Lock()
+	c.configuration = configuration
+	c.cacheMutex.Unlock()
+	return *configuration, nil
+}
+
+// imageUrl builds the full url of an image path in the given size, using the
+// largest available size ("original") when size is not one of sizes
+func (c *MovieDb) imageUrl(path, size string, sizes func(ImagesConfiguration) []string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("No image")
+	}
+
+	configuration, err := c.GetConfiguration()
+	if err != nil {
+		return "", err
+	}
+
+	available := sizes(configuration.Images)
+	if !stringSliceContains(available, size) {
+		if len(available) == 0 {
+			size = "original"
+		} else {
+			size = available[len(available)-1]
+		}
+	}
+
+	return fmt.Sprintf("%s%s%s", configuration.Images.SecureBaseUrl, size, path), nil
+}
+
+func (c *MovieDb) PosterUrl(path, size string) (string, error) {
+	return c.imageUrl(path, size, func(i ImagesConfiguration) []string { return i.PosterSizes })
+}
+
+func (c *MovieDb) BackdropUrl(path, size string) (string, error) {
+	return c.imageUrl(path, size, func(i ImagesConfiguration) []string { return i.BackdropSizes })
+}
+
+func (c *MovieDb) StillUrl(path, size string) (string, error) {
+	return c.imageUrl(path, size, func(i ImagesConfiguration) []string { return i.StillSizes })
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+)
+
+// listFlag is a flag that may be given multiple times, each value a CSV
+type listFlag []string
+
+func newListFlag(name, usage string) *listFlag {
+	l := &listFlag{}
+	flag.Var(l, name, usage)
+	return l
+}
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, splitCsv(value)...)
+	return nil
+}
+
+// absInDirs returns the absolute in dirs, "." when none were given
+func absInDirs(dirs []string) ([]string, error) {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	inDirs := []string{}
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return inDirs, err
+		}
+		if !stringSliceContains(inDirs, abs) {
+			inDirs = append(inDirs, abs)
+		}
+	}
+	return inDirs, nil
+}
+
+// inDirFor returns the in dir moviePath was found in, the deepest
+// when in dirs are nested, so paths are made relative to their own root
+func inDirFor(inDirs []string, moviePath string) string {
+	root := ""
+	for _, dir := range inDirs {
+		if strings.HasPrefix(moviePath, dir+string(filepath.Separator)) && len(dir) > len(root) {
+			root = dir
+		}
+	}
+	if root == "" && len(inDirs) > 0 {
+		return inDirs[0]
+	}
+	return root
+}
+
+// lsMoviesAll lists the movies of all in dirs, without duplicates
+func lsMoviesAll(inDirs []string, exts []string) ([]string, error) {
+	movies := []string{}
+	for _, dir := range inDirs {
+		dirMovies, err := lsMovies(dir, exts)
+		if err != nil {
+			return movies, err
+		}
+		movies = append(movies, dirMovies...)
+	}
+	return sortUniq(movies), nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+)
+
+// metadata languages are ISO 639-1 codes with an optional ISO 3166-1
+// country, ie. "de" or "de-DE"
+var languageReg = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// withLanguage adds the language parameter to an api url
+func withLanguage(rawUrl, language string) (string, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("language", language)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// originalTitled returns media named by its title, or the name of its show,
+// in the original language
+func originalTitled(media Media) Media {
+	switch m := media.(type) {
+	case Movie:
+		if m.OriginalTitle != "" {
+			m.Title = m.OriginalTitle
+		}
+		return m
+	case TvEpisode:
+		if m.TvOriginalName != "" {
+			m.TvName = m.TvOriginalName
+		}
+		return m
+	default:
+		return media
+	}
+}
@@ -26,6 +26,25 @@ var (
 	whiteFn  = color.New(color.FgWhite).SprintFunc()
 )
 
+// plainOutput avoids glyphs and in-place updates for screen readers
+var plainOutput = false
+
+// promptStr terminates an interactive prompt
+func promptStr(msg string) string {
+	if plainOutput {
+		return fmt.Sprintf("%s: ", msg)
+	}
+	return fmt.Sprintf("%s ➜ ", msg)
+}
+
+// arrowStr separates the in file from the out file
+func arrowStr() string {
+	if plainOutput {
+		return tr("to")
+	}
+	return "➜"
+}
+
 type LinePrinter struct {
 	fragments     []string
 	currentLength int
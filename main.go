@@ -4,20 +4,24 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
+	"os/user"
 	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strings"
 	"time"
 
-	humanize "github.com/dustin/go-humanize"
+	"github.com/fatih/color"
 )
 
 // build flags
@@ -40,22 +44,117 @@ cmrg,ipt,hc,flawl3ss,srt,bluray,web,bd,rip,x265,d3fil3r,tvnrg,hevc,d3g,ac,dd5,2h
 
 // cli flags
 var (
-	versionFlag      = flag.Bool("v", false, "Print version information and exit")
-	printTokensFlag  = flag.Bool("p", false, "Print all unique tokens used for generated search from in-directory")
-	apiKeyFlag       = flag.String("api-key", "", "MovieDB api key (required)")
-	inFlag           = flag.String("in", ".", "Input/source directory")
-	outFlag          = flag.String("out", ".", "Output/destination directory")
-	movieOutFlag     = flag.String("movie-out", "", "Output/destination directory for movies, uses 'out' if not provided")
-	tvOutFlag        = flag.String("tv-out", "", "Output/destination directory for tv episodes, uses 'out' if not provided")
-	manifestFlag     = flag.String("manifest", fmt.Sprintf("./%s-manifest.json", BinName), "Path to manifest file")
-	setStopWordsFlag = flag.String("set-stop-words", strings.Join(defaultStopWords, ","), "CSV of words to exclude from moviedb search")
-	addStopWordsFlag = flag.String("add-stop-words", "", "CSV of words to exclude from moviedb search (added to default set-stop-words list)")
-	movieExtsFlag    = flag.String("movie-exts", ".mp4,.avi,.mov,.flv,.wmv,.mkv,.m4v,.mpg,.webm", "CSV of valid movie extensions")
-	noColorFlag      = flag.Bool("no-color", false, "Enable if you hate fun")
-	dryRunFlag       = flag.Bool("dry-run", false, "Do not copy files from in dir to out dir")
-	mvFlag           = flag.Bool("mv", false, "Move files from in dir to out dir (instead of copy)")
-	confirmFlag      = flag.Bool("confirm", false, "Ask for confirmation before moving or copying files")
-	cleanFlag        = flag.Bool("clean", false, "List files in out dir that are candidates for removal")
+	apiKeyFlag                = flag.String("api-key", "", "Api key of the metadata provider (required, unless set in the config file)")
+	inFlag                    = newListFlag("in", "Input/source directory, repeat or use CSV for multiple directories (default \".\")")
+	outFlag                   = flag.String("out", ".", "Output/destination directory")
+	movieOutFlag              = flag.String("movie-out", "", "Output/destination directory for movies, uses 'out' if not provided")
+	tvOutFlag                 = flag.String("tv-out", "", "Output/destination directory for tv episodes, uses 'out' if not provided")
+	manifestFlag              = flag.String("manifest", fmt.Sprintf("./%s-manifest.json", BinName), "Path to manifest file")
+	setStopWordsFlag          = flag.String("set-stop-words", strings.Join(defaultStopWords, ","), "CSV of words to exclude from moviedb search")
+	addStopWordsFlag          = flag.String("add-stop-words", "", "CSV of words to exclude from moviedb search (added to default set-stop-words list)")
+	movieExtsFlag             = flag.String("movie-exts", ".mp4,.avi,.mov,.flv,.wmv,.mkv,.m4v,.mpg,.webm", "CSV of valid movie extensions")
+	noColorFlag               = flag.Bool("no-color", false, "Enable if you hate fun")
+	dryRunFlag                = flag.Bool("dry-run", false, "Do not copy files from in dir to out dir")
+	mvFlag                    = flag.Bool("mv", false, "Move files from in dir to out dir (instead of copy)")
+	confirmFlag               = flag.Bool("confirm", false, "Ask for confirmation before moving or copying files")
+	upgradeFlag               = flag.Bool("upgrade", false, "On conflict, replace out file only if in file is higher quality, otherwise skip (requires ffprobe)")
+	qualityLadderFlag         = flag.String("quality-ladder", strings.Join(defaultQualityLadder, ","), "CSV of video codecs used by upgrade, most preferred first")
+	onConflictFlag            = flag.String("on-conflict", string(promptConflict), fmt.Sprintf("Policy when out file exists with different content (%s)", conflictPolicyNames()))
+	yearSourceFlag            = flag.String("year-source", tmdbYearSource, "Where the year in out paths comes from when moviedb and file name disagree (tmdb, filename)")
+	langFlag                  = flag.String("lang", "", "Language of interactive messages (en, es, de, fr), defaults to LANG environment variable")
+	plainOutputFlag           = flag.Bool("plain", false, "Plain line-oriented output without colors or glyphs, for screen readers")
+	smtpHostFlag              = flag.String("smtp-host", "", "SMTP server used to email the end-of-run report")
+	smtpPortFlag              = flag.Int("smtp-port", 587, "SMTP server port")
+	smtpUserFlag              = flag.String("smtp-user", "", "SMTP username, enables authentication")
+	smtpPasswordFlag          = flag.String("smtp-password", "", "SMTP password")
+	emailFromFlag             = flag.String("email-from", "", "Sender address of the email report (default mviedb@hostname)")
+	emailToFlag               = flag.String("email-to", "", "CSV of addresses to email the end-of-run report to")
+	emailOnFlag               = flag.String("email-on", emailOnAlways, "When to email the report (always, failure)")
+	intervalFlag              = flag.Duration("interval", 15*time.Minute, "Time between in dir scans in watch mode")
+	scheduleFlag              = flag.String("schedule", "", "Cron-style schedule (minute hour day month weekday) for in dir scans in watch mode, overrides interval")
+	healthAddrFlag            = flag.String("health-addr", "", "Address to serve /healthz on in watch mode, ie. \":8080\"")
+	scanTimeoutFlag           = flag.Duration("scan-timeout", 6*time.Hour, "Time after which a running scan fails the /healthz watcher check, 0 to disable")
+	forceFlag                 = flag.Bool("force", false, "Push manifest even if the remote manifest changed since the last sync")
+	recordHttpFlag            = flag.String("record-http", "", "Record all moviedb api responses to this directory")
+	replayHttpFlag            = flag.String("replay-http", "", "Replay moviedb api responses previously recorded to this directory, without network access")
+	keepGoingFlag             = flag.Bool("keep-going", false, "Continue with the next in file after a failure, reporting all failures at the end")
+	retryAttemptsFlag         = flag.Int("retry-attempts", 3, "Number of times in files that failed with transient errors are retried at the end of the run")
+	retryBackoffFlag          = flag.Duration("retry-backoff", 30*time.Second, "Wait before the first retry, doubled for each following attempt")
+	opTimeoutFlag             = flag.Duration("op-timeout", 0, "Maximum time for a single stat, compare or copy of an in file, timed out files are retried (default 0, no timeout)")
+	trashSourceFlag           = flag.Bool("trash-source", false, "With mv, move in files to the desktop trash (or quarantine-dir) instead of removing them")
+	quarantineDirFlag         = flag.String("quarantine-dir", "", "With trash-source, move in files here instead of the desktop trash")
+	quarantineRetentionFlag   = flag.Duration("quarantine-retention", 30*24*time.Hour, "Time after which files in quarantine-dir are permanently removed")
+	mirrorFlag                = flag.Bool("mirror", false, "Keep the in dir structure and file names in the out dir, while still recording matches in the manifest")
+	tagMetadataFlag           = flag.Bool("tag-metadata", false, "Write title, year and moviedb id into the container metadata of out files (requires mkvpropedit for mkv, and ffmpeg for containers other than mp4, m4v and mov, which are tagged in place)")
+	noCommonDirFlag           = flag.Bool("no-common-dir", false, "Do not use tokens common to all files of a directory as tv show query")
+	commonDirScopeFlag        = flag.String("common-dir-scope", treeCommonDirScope, "Peer files for common directory tokens: tree (directory and sub-directories) or dir (directory only)")
+	commonDirMinPeersFlag     = flag.Int("common-dir-min-peers", 1, "Minimum number of peer files required to use common directory tokens")
+	previewFlag               = flag.Bool("preview", false, "Show the out file before placing it and allow editing its file name")
+	configFlag                = flag.String("config", fmt.Sprintf("./%s-config.json", BinName), "Path to config file with per movie and tv show overrides")
+	diffFlag                  = flag.String("diff", "", "With dry-run, show only planned operations that differ from this previous dry-run manifest")
+	tokenStatsFlag            = flag.Bool("token-stats", false, "Print how many and which in files each token came from, and stop word candidates")
+	tokenJsonFlag             = flag.Bool("token-json", false, "Print token stats as json")
+	stopWordThresholdFlag     = flag.Float64("stop-word-threshold", 30, "With token-stats, percentage of in files a token must appear in to be a stop word candidate")
+	cleanProtectFlag          = flag.String("clean-protect", "", "CSV of directories or glob patterns, relative to the out dir, that clean never removes")
+	recycleDirFlag            = flag.String("recycle-dir", "", "Move out files that are overwritten or upgraded here instead of replacing them")
+	recycleRetentionFlag      = flag.Duration("recycle-retention", 30*24*time.Hour, "Time after which files in recycle-dir are permanently removed")
+	preHookFlag               = flag.String("pre-hook", "", "Shell command run before each in file is placed, the file is not placed if it fails, see hooks")
+	postHookFlag              = flag.String("post-hook", "", "Shell command run after each in file is placed, see hooks")
+	subtitlesFlag             = flag.Bool("subtitles", false, "Also place subtitles next to in files with the same file name, ie. \"Movie.en.srt\"")
+	subtitleUtf8Flag          = flag.Bool("subtitle-utf8", false, "With subtitles, convert text subtitles in other encodings (windows-1250, windows-1252, gbk) to UTF-8")
+	stateFlag                 = flag.String("state", fmt.Sprintf("./%s-state.json", BinName), "Path to state file remembering the tv show chosen for each in file directory")
+	preferLanguageFlag        = flag.String("prefer-language", "", "CSV of original languages (ie. en,de) whose search results are listed first")
+	onlyPreferredLanguageFlag = flag.Bool("only-preferred-language", false, "With prefer-language, hide search results in other original languages, unless no result matches")
+	noSeasonSummaryFlag       = flag.Bool("no-season-summary", false, "Prompt for each file of a tv season directory instead of confirming them all at once")
+	stableForFlag             = flag.Duration("stable-for", 2*time.Second, "Defer in files modified more recently than this whose size is still changing, or that are open for writing, 0 to disable")
+	minAgeFlag                = flag.Duration("min-age", 0, "Defer in files modified more recently than this to the next run, ie. \"10m\"")
+	seedingFlag               = flag.Bool("seeding", false, "Leave in files untouched for seeding torrents, placing out files as hard links or symlinks, can not be combined with mv")
+	chownFlag                 = flag.String("chown", "", "Owner of placed out files and created directories as user:group, ie. \"plex:plex\", requires root or CAP_CHOWN")
+	chmodFlag                 = flag.String("chmod", "", "Modes of placed out files and created directories as file/dir octal modes, ie. \"664/775\"")
+	batchFlag                 = flag.Bool("batch", false, "Select matches without prompting, leaving files without a confident match for review")
+	batchThresholdFlag        = flag.Float64("batch-threshold", 0.8, "Minimum similarity score (0-1) of the best result to select it in batch mode")
+	niceIoFlag                = flag.Bool("nice-io", false, "Place files with idle io priority so that other programs are not slowed down (linux only)")
+	niceCpuFlag               = flag.Int("nice-cpu", 0, "With nice-io, also place files at this cpu niceness (1-19)")
+	targetFlag                = flag.String("target", "", "Directory the bench command measures copy throughput to, ie. an out dir")
+	benchSizeFlag             = flag.Int64("bench-size", 256, "Size in MB of the file copied by the bench command")
+	providerFlag              = flag.String("provider", movieDbProvider, "Metadata provider: moviedb (themoviedb.org) or tvdb (thetvdb.com), or a CSV of them to search the next when a search finds nothing or a provider is unreachable")
+	movieTemplateFlag         = flag.String("movie-template", "", "Go template of movie out paths relative to the out dir, ie. \"{{.Title}} ({{.Year}})/{{.Title}} ({{.Year}}) [{{.Resolution}}]\", see templates")
+	tvTemplateFlag            = flag.String("tv-template", "", "Go template of tv episode out paths relative to the out dir, see templates")
+	noDeferConflictsFlag      = flag.Bool("no-defer-conflicts", false, "Prompt for conflicts as they are found instead of at the end of the run")
+	namingPresetFlag          = flag.String("naming-preset", "", "Name out files as recommended by a media server: plex, jellyfin or kodi, movie-template and tv-template take precedence")
+	articlesFlag              = flag.String("articles", keepArticles, "Leading articles of the title in the top out directory: keep, move (ie. \"Matrix, The (1999)\") or strip, file names keep them")
+	articleListFlag           = flag.String("article-list", "the,a,an", "CSV of lower case leading articles handled by articles")
+	disambiguateFlag          = flag.String("disambiguate", disambiguateById, "Append to out paths of different movies with the same title and year: id, director or none")
+	sidecarsFlag              = flag.Bool("sidecars", false, "Also place subtitles, nfo and artwork files next to in files with the same file name, ie. \"Movie.nfo\" or \"Movie-poster.jpg\", implies subtitles")
+	cleanTopFlag              = flag.Int("clean-top", 0, "Only handle the N directories with the most reclaimable space, 0 for all")
+	verifyFlag                = flag.String("verify", "", "Hash in files while copying and verify the out file, removing it on mismatch, and record the checksum in the manifest: sha256")
+	copyWorkersFlag           = flag.Int("copy-workers", 0, "Number of copies run in the background while the next in files are matched, 0 copies each file before matching the next, defaults to the parallelism saved by bench")
+	apiUsageFlag              = flag.String("api-usage", fmt.Sprintf("./%s-api-usage.json", BinName), "Path to file counting api requests per day")
+	apiDailyLimitFlag         = flag.Int("api-daily-limit", 0, "Warn when the api requests of the day approach this limit, 0 for no limit")
+	seasonFetchRateFlag       = flag.Float64("season-fetch-rate", 4, "Maximum tv seasons fetched per second in the background, 0 for no limit")
+	noMovieSummaryFlag        = flag.Bool("no-movie-summary", false, "Prompt for each file of a movie directory naming the same movie instead of choosing the main feature and extras at once")
+	attentionFlag             = flag.String("attention", fmt.Sprintf("./%s-attention.json", BinName), "Path to file queueing in files that were skipped, had no results, conflicts or need review, see the attention command")
+	animeFlag                 = flag.Bool("anime", false, "Match the last number of tv file names without season and episode as absolute episode number, ie. \"One Piece - 1045\"")
+	tagsFlag                  = flag.String("tags", "", "CSV of tags recorded with the manifest entries of placed files, with manifest edit the tags added, with manifest list the tags listed")
+	noteFlag                  = flag.String("note", "", "Note recorded with the manifest entries of placed files, with manifest edit the note set")
+	askTagsFlag               = flag.Bool("ask-tags", false, "Ask for the tags and note of each placed file, tags and note default to those given")
+	untagFlag                 = flag.String("untag", "", "With manifest edit, CSV of tags removed")
+	belowScoreFlag            = flag.Float64("below-score", 0, "With manifest list, only list entries auto-matched with a score below this, to re-verify them")
+	maxConfidenceFlag         = flag.Float64("max-confidence", 0, "With review, the score below which auto matches are reviewed (default batch-threshold)")
+	strmFlag                  = flag.Bool("strm", false, "Organize .strm files of remote media along with movie files, recording their urls in the manifest")
+	strmUrlFlag               = flag.String("strm-url", "", "Write .strm files pointing at this base url joined with the path of in files relative to their in dir, instead of placing the in files")
+	apiRateFlag               = flag.Float64("api-rate", 20, "Maximum api requests per second to the metadata provider, 0 for no limit")
+	apiRetriesFlag            = flag.Int("api-retries", 3, "Number of times api requests that are rate limited (429) or fail with a 5xx status are sent again, with exponential backoff")
+	languageFlag              = flag.String("language", "", "Language of titles, overviews and episode names from moviedb, ie. de-DE, defaults to english")
+	preferOriginalTitleFlag   = flag.Bool("prefer-original-title", false, "Name out files by the title in the original language instead of the localized title")
+	writeNfoFlag              = flag.Bool("write-nfo", false, "Write a kodi nfo file with the plot, genres, ratings, ids and cast of the movie or episode next to out files, \"movie.nfo\" in movie directories, keeping nfo files that are already there")
+	downloadArtworkFlag       = flag.Bool("download-artwork", false, "Download the poster and fanart of movies into their out directory as \"poster.jpg\" and \"fanart.jpg\", and the stills of episodes next to their out file, ie. \"Show S01E01-thumb.jpg\", keeping artwork that is already there")
+	cleanWorkersFlag          = flag.Int("clean-workers", 8, "Number of directories clean reads at the same time, more speed up scanning out dirs on network shares")
+	linkFlag                  = flag.String("link", "", "Leave in files in place, placing out files as symlinks, hardlinks, or hardlinks falling back to copies across file systems, one of: symlink, hardlink, hardlink-or-copy")
+	fromFlag                  = flag.String("from", "", "Directory the in and out files of manifest entries are rebased from, ie. the old mount point of a share")
+	toFlag                    = flag.String("to", "", "Directory the in and out files of manifest entries are rebased to")
+	manifestIgnoreCaseFlag    = flag.Bool("manifest-ignore-case", runtime.GOOS == "windows" || runtime.GOOS == "darwin", "Match in and out files with the manifest ignoring case, ie. for shares remounted with a different case, the default on windows and macos")
+	noReflinkFlag             = flag.Bool("no-reflink", false, "Always copy the contents of files, instead of sharing their blocks on file systems with copy on write, like btrfs and xfs")
 )
 
 var (
@@ -64,25 +163,49 @@ var (
 )
 
 type ManifestEntry struct {
-	InFile    string    `json:"in_file"`
-	OutFile   string    `json:"out_file"`
-	MovieDbId int64     `json:"movie_db_id"`
-	Type      string    `json:"type"`
-	CreatedAt time.Time `json:"created_at"`
+	InFile     string        `json:"in_file"`
+	OutFile    string        `json:"out_file"`
+	MovieDbId  int64         `json:"movie_db_id"`
+	Provider   string        `json:"provider,omitempty"`
+	ImdbId     string        `json:"imdb_id,omitempty"`
+	ExtraIds   []int64       `json:"extra_movie_db_ids,omitempty"`
+	Type       string        `json:"type"`
+	Source     string        `json:"source,omitempty"`
+	Url        string        `json:"url,omitempty"`
+	Crc32Check string        `json:"crc32_check,omitempty"`
+	Sha256     string        `json:"sha256,omitempty"`
+	Displaced  string        `json:"displaced,omitempty"`
+	Created    *bool         `json:"created,omitempty"`
+	Tags       []string      `json:"tags,omitempty"`
+	Note       string        `json:"note,omitempty"`
+	Match      *Match        `json:"match,omitempty"`
+	Details    *MediaDetails `json:"details,omitempty"`
+	User       string        `json:"user,omitempty"`
+	Host       string        `json:"host,omitempty"`
+	Version    string        `json:"version,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
 }
 
-func stringSliceContains(s []string, a string) bool {
-	for _, b := range s {
-		if a == b {
-			return true
-		}
+// currentUsername returns the name of the user running the process, if known
+func currentUsername() string {
+	u, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
 	}
-	return false
+	return u.Username
 }
 
-func stringSliceContainsPrefix(pp []string, s string) bool {
-	for _, p := range pp {
-		if strings.HasPrefix(s, p) {
+func currentHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return hostname
+}
+
+func stringSliceContains(s []string, a string) bool {
+	for _, b := range s {
+		if a == b {
 			return true
 		}
 	}
@@ -105,6 +228,18 @@ func splitSortUniq(wordsStr string) []string {
 	return sortUniq(fields)
 }
 
+// splitCsv splits a comma separated list, dropping empty values
+func splitCsv(csv string) []string {
+	values := []string{}
+	for _, value := range strings.Split(csv, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func sortUniq(words []string) []string {
 	ret := []string{}
 	sort.Strings(words)
@@ -169,24 +304,30 @@ func readManifest(manifestPath string) ([]ManifestEntry, error) {
 		return manifest, err
 	}
 
-	if !exists {
-		// new manifest
-		return manifest, nil
-	}
+	if exists {
+		f, err := os.Open(manifestPath)
+		if err != nil {
+			return manifest, err
+		}
+		defer f.Close()
 
-	f, err := os.Open(manifestPath)
-	if err != nil {
-		return manifest, err
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			return manifest, err
+		}
+
+		err = json.Unmarshal(b, &manifest)
+		if err != nil {
+			return manifest, err
+		}
 	}
-	defer f.Close()
 
-	b, err := ioutil.ReadAll(f)
+	journal, err := readManifestJournal(manifestPath)
 	if err != nil {
 		return manifest, err
 	}
 
-	err = json.Unmarshal(b, &manifest)
-	return manifest, err
+	return append(manifest, journal...), nil
 }
 
 func writeManifest(manifestPath string, manifest []ManifestEntry) error {
@@ -195,12 +336,49 @@ func writeManifest(manifestPath string, manifest []ManifestEntry) error {
 		return err
 	}
 
-	return ioutil.WriteFile(manifestPath, manifestJson, 0644)
+	err = ioutil.WriteFile(manifestPath, manifestJson, 0644)
+	if err != nil {
+		return err
+	}
+
+	return removeManifestJournal(manifestPath)
 }
 
-func buildOutFile(originalPath, outDir string, media Media) (string, error) {
+func buildOutFile(originalPath, outDir string, media Media, provider string) (string, error) {
 	ext := strings.ToLower(filepath.Ext(originalPath))
-	return fmt.Sprintf("%s/%s%s", outDir, media.GetPath(), ext), nil
+
+	t := movieTemplate
+	if media.GetType() == "tv_episode" {
+		t = tvTemplate
+	}
+
+	// templates get both titles, newPathFields prefers the original one
+	named := media
+	if *preferOriginalTitleFlag {
+		named = originalTitled(media)
+	}
+
+	path := named.GetPath()
+	if t != nil {
+		var err error
+		path, err = renderPath(t, originalPath, media, provider)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// out paths are rendered with forward slashes on all platforms
+	path = applySortTitle(path, named, *articlesFlag, splitCsv(*articleListFlag))
+	return filepath.Join(outDir, filepath.FromSlash(path)) + ext, nil
+}
+
+// buildMirrorOutFile keeps the path of the in file relative to the in dir
+func buildMirrorOutFile(originalPath, inDir, outDir string) (string, error) {
+	rel, err := filepath.Rel(inDir, originalPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(outDir, rel), nil
 }
 
 // https://stackoverflow.com/questions/21060945/simple-way-to-copy-a-file-in-golang
@@ -237,7 +415,19 @@ func CopyFile(src, dst string) (err error) {
 	return
 }
 
-func copyFileContents(src, dst string) (err error) {
+// copyFileContents reflinks src to dst when the file system supports it,
+// copying the contents otherwise. The kernel strategy lets io.Copy hand the
+// copy to copy_file_range.
+func copyFileContents(src, dst string) error {
+	if !*noReflinkFlag && reflinkFile(src, dst) == nil {
+		return nil
+	}
+	return copyFileWith(src, dst, copySettings, true)
+}
+
+// copyFileWith copies src to dst with the given strategy and buffer size,
+// printing its progress on terminals when progress is set
+func copyFileWith(src, dst string, settings CopySettings, progress bool) (err error) {
 	in, err := os.Open(src)
 	if err != nil {
 		return
@@ -253,7 +443,23 @@ func copyFileContents(src, dst string) (err error) {
 			err = cerr
 		}
 	}()
-	if _, err = io.Copy(out, in); err != nil {
+	if progress {
+		if info, err := in.Stat(); err == nil {
+			stop := startCopyProgress(out, info.Size())
+			defer stop()
+		}
+	}
+	if settings.Strategy == userspaceCopy {
+		bufferSize := settings.BufferSize
+		if bufferSize <= 0 {
+			bufferSize = defaultCopyBufferSize
+		}
+		// hide the files from io.Copy so it can't hand the copy to the kernel
+		_, err = io.CopyBuffer(struct{ io.Writer }{out}, struct{ io.Reader }{in}, make([]byte, bufferSize))
+	} else {
+		_, err = io.Copy(out, in)
+	}
+	if err != nil {
 		return
 	}
 	err = out.Sync()
@@ -316,7 +522,7 @@ func DeepCompare(file1, file2 string) (bool, error) {
 }
 
 func movieInfo(i, n int, moviePath, inDir string) string {
-	name := strings.TrimPrefix(moviePath, fmt.Sprintf("%s/", inDir))
+	name := strings.TrimPrefix(moviePath, inDir+string(filepath.Separator))
 	return fmt.Sprintf("\n%d/%d %s\n", i+1, n, ColorStr(BlueColor, name))
 }
 
@@ -333,33 +539,7 @@ func confirm(msg string, reader *bufio.Reader) bool {
 	}
 
 	lower := strings.ToLower(response)
-	return lower[:1] == "y"
-}
-
-// lowest directories under outDir that do not contain an out file from manifest
-func getCleanDirs(outDir string, manifest []ManifestEntry) ([]string, error) {
-	dirs := []string{}
-	outFiles := []string{}
-	for _, m := range manifest {
-		if m.OutFile != "" && strings.HasPrefix(m.OutFile, outDir) {
-			outFiles = append(outFiles, m.OutFile)
-		}
-	}
-
-	err := filepath.Walk(outDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			fmt.Printf("prevent panic by handling failure accessing a path %q: %v\n", path, err)
-			return err
-		}
-		if info.IsDir() {
-			if !stringSliceContainsPrefix(dirs, path) && !stringSliceHasPrefix(outFiles, path) {
-				dirs = append(dirs, path)
-			}
-		}
-		return nil
-	})
-
-	return dirs, err
+	return strings.HasPrefix(lower, "y") || strings.HasPrefix(lower, tr("y"))
 }
 
 func getOutDir(outFlag, fallbackOutFlag string) (string, error) {
@@ -407,7 +587,16 @@ func sortedIntersect(a []string, b []string) []string {
 	return set
 }
 
-func commonDirWords(moviePath string, movieList []string, stopWords []string) ([]string, error) {
+const (
+	treeCommonDirScope = "tree"
+	dirCommonDirScope  = "dir"
+)
+
+// commonDirWords returns the query tokens moviePath shares with all its peers,
+// the other in files in the same directory ("dir" scope) or also in its
+// sub-directories ("tree" scope). No tokens are returned with fewer than
+// minPeers peers.
+func commonDirWords(moviePath string, movieList []string, stopWords []string, scope string, minPeers int) ([]string, error) {
 	name := fNameSansExtension(moviePath)
 	peerPaths := []string{name}
 	dir, err := filepath.Abs(filepath.Dir(moviePath))
@@ -416,13 +605,24 @@ func commonDirWords(moviePath string, movieList []string, stopWords []string) ([
 	}
 
 	for _, p := range movieList {
-		if strings.HasPrefix(p, dir) {
+		if p == moviePath {
+			continue
+		}
+		if scope == dirCommonDirScope {
+			if filepath.Dir(p) == dir {
+				peerPaths = append(peerPaths, fNameSansExtension(p))
+			}
+		} else if strings.HasPrefix(p, dir+string(filepath.Separator)) {
 			peerPaths = append(peerPaths, fNameSansExtension(p))
 		}
 	}
 
+	if len(peerPaths)-1 < minPeers {
+		return []string{}, nil
+	}
+
 	originalQueryTokens := buildQueryTokens(peerPaths[0], stopWords)
-	common := sortUniq(originalQueryTokens)
+	common := sortUniq(append([]string{}, originalQueryTokens...))
 
 	for i := 1; i < len(peerPaths); i++ {
 		b := sortUniq(buildQueryTokens(peerPaths[i], stopWords))
@@ -444,13 +644,167 @@ func commonDirWords(moviePath string, movieList []string, stopWords []string) ([
 }
 
 func main() {
-	flag.Parse()
+	command, args := parseCommand(os.Args[1:])
+	action, args := parseAction(args)
+	if command == helpCommand {
+		err := runHelpCommand(action)
+		if err != nil {
+			log.Fatalln("Help error:", err)
+		}
+		os.Exit(0)
+	}
+
+	fs := newCommandFlagSet(command)
+	fs.Parse(args)
+	err := flagsFromEnv(fs)
+	if err != nil {
+		log.Fatalln("Environment error:", err)
+	}
 
-	if *versionFlag {
+	// the leading argument of commands without actions is a positional argument,
+	// ie. the count in "undo 2"
+	if action != "" && command != manifestCommand && command != attentionCommand {
+		if command != undoCommand && command != explainCommand {
+			log.Fatalf("Unknown command %q, run \"%s %s\" for a list of commands\n", action, BinName, helpCommand)
+		}
+		args = append([]string{action}, fs.Args()...)
+	} else {
+		args = fs.Args()
+	}
+
+	if command == versionCommand {
 		fmt.Println(versionStr())
 		os.Exit(0)
 	}
 
+	if *noColorFlag || *plainOutputFlag {
+		color.NoColor = true
+	}
+
+	if *plainOutputFlag {
+		plainOutput = true
+	}
+
+	if *langFlag != "" {
+		setLanguage(*langFlag)
+	} else {
+		setLanguage(detectLanguage())
+	}
+
+	if command == benchCommand {
+		err := runBenchCommand(*targetFlag, *benchSizeFlag, *configFlag)
+		if err != nil {
+			log.Fatalln("Bench error:", err)
+		}
+		os.Exit(0)
+	}
+
+	if command == manifestCommand {
+		edit := manifestEdit{tags: splitCsv(*tagsFlag), untags: splitCsv(*untagFlag), note: *noteFlag}
+		fs.Visit(func(f *flag.Flag) {
+			edit.setNote = edit.setNote || f.Name == "note"
+		})
+		err := runManifestCommand(action, args, *manifestFlag, edit)
+		if err != nil {
+			log.Fatalln("Manifest error:", err)
+		}
+		os.Exit(0)
+	}
+
+	if command == verifyCommand {
+		err := verifyManifest(*manifestFlag)
+		if err != nil {
+			log.Fatalln("Verify error:", err)
+		}
+		os.Exit(0)
+	}
+
+	if command == attentionCommand && action != "" {
+		err := runAttentionCommand(action, *attentionFlag)
+		if err != nil {
+			log.Fatalln("Attention error:", err)
+		}
+		os.Exit(0)
+	}
+
+	if command == undoCommand {
+		err := runUndoCommand(args, *manifestFlag, bufio.NewReader(os.Stdin))
+		if err != nil {
+			log.Fatalln("Undo error:", err)
+		}
+		os.Exit(0)
+	}
+
+	onConflict, err := parseConflictPolicy(*onConflictFlag)
+	if err != nil {
+		log.Fatalln("On conflict error:", err)
+	}
+
+	qualityLadder := parseQualityLadder(*qualityLadderFlag)
+
+	if *disambiguateFlag != disambiguateById && *disambiguateFlag != disambiguateByDirector && *disambiguateFlag != disambiguateNone {
+		log.Fatalf("Invalid disambiguate %q, must be one of: %s, %s, %s\n", *disambiguateFlag, disambiguateById, disambiguateByDirector, disambiguateNone)
+	}
+	if *articlesFlag != keepArticles && *articlesFlag != moveArticles && *articlesFlag != stripArticles {
+		log.Fatalf("Invalid articles %q, must be one of: %s, %s, %s\n", *articlesFlag, keepArticles, moveArticles, stripArticles)
+	}
+
+	movieTemplateStr, tvTemplateStr, err := presetTemplates(*namingPresetFlag, *movieTemplateFlag, *tvTemplateFlag)
+	if err != nil {
+		log.Fatalln("Template error:", err)
+	}
+
+	err = parsePathTemplates(movieTemplateStr, tvTemplateStr)
+	if err != nil {
+		log.Fatalln("Template error:", err)
+	}
+
+	if *yearSourceFlag != tmdbYearSource && *yearSourceFlag != filenameYearSource {
+		log.Fatalf("Invalid year-source %q, must be one of: %s, %s\n", *yearSourceFlag, tmdbYearSource, filenameYearSource)
+	}
+
+	if *diffFlag != "" && !*dryRunFlag {
+		log.Fatalln("diff requires dry-run")
+	}
+
+	if *verifyFlag != "" && *verifyFlag != sha256Verify {
+		log.Fatalf("Invalid verify %q, must be: %s\n", *verifyFlag, sha256Verify)
+	}
+
+	if *batchFlag && (*confirmFlag || *previewFlag) {
+		log.Fatalln("batch can not be combined with confirm or preview")
+	}
+
+	if *niceIoFlag && !niceIoSupported {
+		log.Fatalln("nice-io is only supported on linux")
+	}
+
+	if *seedingFlag && *mvFlag {
+		log.Fatalln("seeding can not be combined with mv, in files must be left in place")
+	}
+
+	if *linkFlag != "" && !stringSliceContains(linkModes, *linkFlag) {
+		log.Fatalf("Invalid link %q, must be one of: %s\n", *linkFlag, strings.Join(linkModes, ", "))
+	}
+	if *linkFlag != "" && *mvFlag {
+		log.Fatalln("link can not be combined with mv, in files must be left in place")
+	}
+
+	if *strmUrlFlag != "" && *mvFlag {
+		log.Fatalln("strm-url can not be combined with mv, in files must be left in place")
+	}
+	if u, err := url.Parse(*strmUrlFlag); *strmUrlFlag != "" && (err != nil || u.Scheme == "" || u.Host == "") {
+		log.Fatalf("Invalid strm-url %q, must be an absolute url\n", *strmUrlFlag)
+	}
+
+	if *languageFlag != "" && !languageReg.MatchString(*languageFlag) {
+		log.Fatalf("Invalid language %q, must be a language code with an optional country, ie. de or de-DE\n", *languageFlag)
+	}
+
+	if *emailOnFlag != emailOnAlways && *emailOnFlag != emailOnFailure {
+		log.Fatalf("Invalid email-on %q, must be one of: %s, %s\n", *emailOnFlag, emailOnAlways, emailOnFailure)
+	}
+
 	movieOutDir, err := getOutDir(*movieOutFlag, *outFlag)
 	if err != nil {
 		log.Fatalln("Movie out error:", err)
@@ -462,7 +816,7 @@ func main() {
 	}
 
 	var manifestPath string
-	if *dryRunFlag && !*cleanFlag {
+	if *dryRunFlag && command != cleanCommand {
 		manifestStr := *manifestFlag
 		manifestExt := filepath.Ext(manifestStr)
 		manifestSuffix := fmt.Sprintf("-dry-run%s", manifestExt)
@@ -481,205 +835,215 @@ func main() {
 		log.Fatalln("Manifest error:", err)
 	}
 
-	if *cleanFlag {
+	config, err := readConfig(*configFlag)
+	if err != nil {
+		log.Fatalln("Config error:", err)
+	}
+	if config.Copy != nil {
+		copySettings = *config.Copy
+	}
+	// background copies default to the parallelism saved by bench
+	copyWorkersGiven := false
+	fs.Visit(func(f *flag.Flag) {
+		copyWorkersGiven = copyWorkersGiven || f.Name == "copy-workers"
+	})
+	if !copyWorkersGiven && copySettings.Parallelism > 1 {
+		*copyWorkersFlag = copySettings.Parallelism
+	}
+
+	if command == cleanCommand {
 		if movieOutDir != tvOutDir {
 			log.Fatalln("Cannot clean differnt movie-out and tv-out at the same time")
 		}
-		dirs, err := getCleanDirs(movieOutDir, manifest)
-		if err != nil {
-			log.Fatalln("Error getting directories for cleanup")
+		protect := append(splitCsv(*cleanProtectFlag), config.CleanProtect...)
+		if *cleanWorkersFlag < 1 {
+			log.Fatalf("Invalid clean-workers %d, must be at least 1\n", *cleanWorkersFlag)
 		}
-		for _, dir := range dirs {
-			fmt.Println(dir)
-			if !*dryRunFlag {
-				os.RemoveAll(dir)
-			}
+		err = runClean(movieOutDir, manifest, protect, *cleanTopFlag, *cleanWorkersFlag, bufio.NewReader(os.Stdin))
+		if err != nil {
+			log.Fatalln("Clean error:", err)
 		}
 		os.Exit(0)
 	}
 
-	inDir, err := filepath.Abs(*inFlag)
+	inDirs, err := absInDirs(*inFlag)
 	if err != nil {
 		log.Fatalln("Error getting absolute path to in dir:", err)
 	}
 
 	exts := strings.Split(*movieExtsFlag, ",")
-	movieList, err := lsMovies(inDir, exts)
-	if err != nil {
-		log.Fatalln("List movies error:", err)
+	if *strmFlag && !stringSliceContains(exts, strmExt) {
+		exts = append(exts, strmExt)
 	}
 
-	numMovies := len(movieList)
-
 	stopWords := strings.Split(*setStopWordsFlag, ",")
 	stopWords = append(stopWords, strings.Split(*addStopWordsFlag, ",")...)
 	stopWords = sortUniq(stopWords)
 
-	if *printTokensFlag {
-		tokens := []string{}
+	if command == explainCommand {
+		err := runExplainCommand(args, inDirs, exts, stopWords)
+		if err != nil {
+			log.Fatalln("Explain error:", err)
+		}
+		os.Exit(0)
+	}
+
+	if command == doctorCommand {
+		err := runDoctorCommand(inDirs, exts, []string{movieOutDir, tvOutDir}, manifestPath, config.ApiKeys)
+		if err != nil {
+			log.Fatalln("Doctor error:", err)
+		}
+		os.Exit(0)
+	}
+
+	if command == tokensCommand {
+		movieList, err := lsMoviesAll(inDirs, exts)
+		if err != nil {
+			log.Fatalln("List movies error:", err)
+		}
+
+		manifestIndex := NewManifestIndex(manifest)
+		unseen := []string{}
 		for _, moviePath := range movieList {
-			seen := false
-			for _, e := range manifest {
-				if e.InFile == moviePath || e.OutFile == moviePath {
-					seen = true
-				}
-			}
-			if !seen {
-				query := splitSortUniq(GetQuery(moviePath, inDir, stopWords))
-				myQuery, _, _, _ := extractTvSeasonEpisodeFromQuery(strings.Join(query, " "))
-				tokens = append(tokens, strings.Fields(myQuery)...)
+			if !manifestIndex.Seen(moviePath) {
+				unseen = append(unseen, moviePath)
 			}
 		}
-		for _, token := range sortUniq(tokens) {
-			fmt.Println(token)
+		err = printTokens(unseen, inDirs, stopWords)
+		if err != nil {
+			log.Fatalln("Print tokens error:", err)
 		}
 		os.Exit(0)
 	}
 
-	if *apiKeyFlag == "" {
-		log.Fatalln("api-key is required")
+	if *recordHttpFlag != "" && *replayHttpFlag != "" {
+		log.Fatalln("Cannot use record-http and replay-http at the same time")
 	}
 
-	movieDb := NewMovieDb(*apiKeyFlag)
-
-	reader := bufio.NewReader(os.Stdin)
+	if *downloadArtworkFlag && *replayHttpFlag != "" {
+		log.Fatalln("Cannot use download-artwork and replay-http at the same time, artwork is not recorded")
+	}
 
-	selector := NewSelector(movieDb, inDir, reader, stopWords)
+	provider, err := newProviders(*providerFlag, *apiKeyFlag, config.ApiKeys, *replayHttpFlag != "")
+	if err != nil {
+		log.Fatalln("Provider error:", err)
+	}
 
-	var verb string
-	if *mvFlag {
-		verb = "move"
-	} else {
-		verb = "copy"
+	seasonFetchLimiter = newRateLimiter(*seasonFetchRateFlag)
+	if *replayHttpFlag == "" {
+		apiLimiter = newRateLimiter(*apiRateFlag)
 	}
 
-	for i, moviePath := range movieList {
-		exists := false
-		info := movieInfo(i, numMovies, moviePath, inDir)
-		for _, e := range manifest {
-			if e.InFile == moviePath || e.OutFile == moviePath {
-				fmt.Println(info)
-				fmt.Printf("Skipping because we've seen this in-file before\n\n")
-				exists = true
-				break
-			}
+	if *replayHttpFlag == "" {
+		apiUsage, err = readApiUsage(*apiUsageFlag, *apiDailyLimitFlag)
+		if err != nil {
+			log.Fatalln("Api usage error:", err)
 		}
+	}
 
-		if exists {
-			continue
-		}
+	if *recordHttpFlag != "" {
+		provider.RecordHttp(*recordHttpFlag)
+	} else if *replayHttpFlag != "" {
+		provider.ReplayHttp(*replayHttpFlag)
+	}
 
-		common, err := commonDirWords(moviePath, movieList, stopWords)
-		if err != nil {
-			log.Println("Error getting common directory query tokens:", err)
-			break
+	if *replayHttpFlag == "" {
+		err = provider.Ping()
+		var providerErr *ProviderError
+		if errors.Is(err, ErrInvalidApiKey) && errors.As(err, &providerErr) {
+			log.Fatalf("Invalid %s api key, check -api-key or api_keys in the config file (%s)\n", providerErr.Provider, providerApiKeyUrls[providerErr.Provider])
+		} else if errors.Is(err, ErrInvalidApiKey) {
+			log.Fatalf("Invalid api key, check the value of -api-key (%s)\n", providerApiKeyUrls[provider.Name()])
+		} else if err != nil {
+			log.Printf("Warning: unable to reach %s: %s\n", provider.Name(), err)
 		}
+	}
 
-		movie, err := selector.Handle(i, numMovies, moviePath, common, info)
-		if err != nil {
-			if err.Error() == "skipped" {
-				continue
-			} else if err.Error() == "quit" {
-				break
-			} else {
-				log.Println("Error searching movies:", err)
-				break
-			}
-		}
+	err = config.compileRoutes()
+	if err != nil {
+		log.Fatalln("Config error:", err)
+	}
 
-		var outFile string
-		if movie.GetType() == "tv_episode" {
-			outFile, err = buildOutFile(moviePath, tvOutDir, movie)
-		} else {
-			outFile, err = buildOutFile(moviePath, movieOutDir, movie)
-		}
+	reader := bufio.NewReader(os.Stdin)
 
-		if err != nil {
-			log.Println("Unable to build out file:", err)
-			break
-		}
+	state, err := readState(*stateFlag)
+	if err != nil {
+		log.Fatalln("State error:", err)
+	}
 
-		doCopy := true
-		if outFile == moviePath {
-			fmt.Println("In file and out file are the same path")
-		} else if _, err := os.Stat(outFile); err == nil {
-			// outFile exists
-			isSameFile, err := SameFile(moviePath, outFile)
-			if err != nil {
-				log.Println("Error comparing files:", err)
-				break
-			}
+	selector := NewSelector(provider, inDirs, reader, stopWords, config, *configFlag, state, *stateFlag)
 
-			if isSameFile {
-				fmt.Println("Out file exists and is same content as in file, updating manifest")
-				doCopy = false
-			} else {
-				inInfo, err := os.Stat(moviePath)
-				if err != nil {
-					log.Println("Error getting info for in file:", err)
-				}
-
-				outInfo, err := os.Stat(outFile)
-				if err != nil {
-					log.Println("Error getting info for out file:", err)
-				}
-
-				fmt.Println("Out file exists and has different content as in file!")
-				fmt.Println("In: ", moviePath)
-				fmt.Printf("     Size: %s, modified: %s\n", humanize.Bytes(uint64(inInfo.Size())), inInfo.ModTime())
-				fmt.Println("Out:", outFile)
-				fmt.Printf("     Size: %s, modified: %s\n", humanize.Bytes(uint64(outInfo.Size())), outInfo.ModTime())
-
-				if !confirm(fmt.Sprintf("%s? [yN] ➜ ", strings.Title(verb)), reader) {
-					continue
-				}
+	var verb string
+	if *strmUrlFlag != "" {
+		verb = strmVerb
+	} else if *seedingFlag || *linkFlag != "" {
+		verb = "link"
+	} else if *mvFlag {
+		verb = "move"
+	} else {
+		verb = "copy"
+	}
+
+	if !*seedingFlag && *linkFlag == "" {
+		for _, inDir := range inDirs {
+			if ok, path := looksLikeTorrentDir(inDir); ok {
+				log.Printf("Warning: %s looks like an active torrent download directory (found %s), consider -seeding to leave in files untouched\n", inDir, path)
 			}
 		}
+	}
 
-		fmt.Printf("%s %s %s %s\n", strings.Title(verb), ColorStr(RedColor, moviePath), ColorStr(WhiteColor, "➜"), ColorStr(GreenColor, outFile))
+	ownership, err := parseOwnership(*chownFlag, *chmodFlag)
+	if err != nil {
+		log.Fatalln("Ownership error:", err)
+	}
 
-		if !*dryRunFlag && doCopy {
-			if *confirmFlag {
-				if !confirm(fmt.Sprintf("%s? [yN] ➜ ", strings.Title(verb)), reader) {
-					continue
-				}
-			}
-			myOutDir := filepath.Dir(outFile)
-			err = os.MkdirAll(myOutDir, 0755)
-			if err != nil {
-				log.Println("Error creating out directory:", err)
-				break
-			}
+	attention, err := readAttention(*attentionFlag)
+	if err != nil {
+		log.Fatalln("Attention error:", err)
+	}
+	if command == attentionCommand {
+		printAttention(attention)
+	}
 
-			err = CopyFile(moviePath, outFile)
-			if err != nil {
-				log.Println("Error copying file:", err)
-				break
-			}
+	organizer := &Organizer{
+		inDirs:        inDirs,
+		movieOutDir:   movieOutDir,
+		tvOutDir:      tvOutDir,
+		manifestPath:  manifestPath,
+		exts:          exts,
+		stopWords:     stopWords,
+		selector:      selector,
+		reader:        reader,
+		verb:          verb,
+		onConflict:    onConflict,
+		qualityLadder: qualityLadder,
+		config:        config,
+		ownership:     ownership,
+		attention:     attention,
+		attentionPath: *attentionFlag,
+		attentionOnly: command == attentionCommand,
+	}
 
-			if *mvFlag {
-				err = os.Remove(moviePath)
-				if err != nil {
-					log.Println("Error moving file:", err)
-					break
-				}
-			}
+	if command == reviewCommand {
+		err = organizer.Review(*manifestFlag, *maxConfidenceFlag)
+		if err != nil {
+			log.Fatalln("Review error:", err)
 		}
+		os.Exit(0)
+	}
 
-		manifest = append(manifest, ManifestEntry{
-			InFile:    moviePath,
-			OutFile:   outFile,
-			MovieDbId: movie.GetId(),
-			Type:      movie.GetType(),
-			CreatedAt: time.Now(),
-		})
-
-		err = writeManifest(manifestPath, manifest)
+	if command == watchCommand {
+		status := NewWatchStatus(*scanTimeoutFlag)
+		if *healthAddrFlag != "" {
+			serveHealth(*healthAddrFlag, status, provider, manifestPath)
+		}
+		err = watch(organizer, *intervalFlag, *scheduleFlag, status)
 		if err != nil {
-			log.Println("Error updating manifest: ", err)
-			break
+			log.Fatalln("Watch error:", err)
 		}
+	} else {
+		finishRun(organizer.Run())
 	}
 
-	fmt.Printf("\nGoodbye!\n")
+	fmt.Printf(tr("\nGoodbye!\n"))
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ManifestIndex looks up manifest entries by in file and out file
+// without scanning the whole manifest for every in file. Paths are
+// normalized by manifestKey.
+type ManifestIndex struct {
+	byInFile  map[string]int
+	byOutFile map[string]int
+	byOutName map[string]mediaId
+}
+
+// mediaId is an id with the provider it is valid in
+type mediaId struct {
+	provider string
+	id       int64
+}
+
+// mediaId returns the id of the movie or episode of the entry, entries
+// written before providers were recorded have moviedb ids
+func (e ManifestEntry) mediaId() mediaId {
+	provider := e.Provider
+	if provider == "" {
+		provider = movieDbProvider
+	}
+	return mediaId{provider, e.MovieDbId}
+}
+
+func NewManifestIndex(manifest []ManifestEntry) *ManifestIndex {
+	index := &ManifestIndex{
+		byInFile:  make(map[string]int, len(manifest)),
+		byOutFile: make(map[string]int, len(manifest)),
+		byOutName: make(map[string]mediaId),
+	}
+	for i, e := range manifest {
+		index.Add(i, e)
+	}
+	return index
+}
+
+// Add indexes the entry at position i of the manifest
+func (idx *ManifestIndex) Add(i int, e ManifestEntry) {
+	if e.InFile != "" {
+		idx.byInFile[manifestKey(e.InFile)] = i
+	}
+	if e.OutFile != "" {
+		idx.byOutFile[manifestKey(e.OutFile)] = i
+		if e.Type == "movie" {
+			idx.byOutName[outNameKey(e.OutFile)] = e.mediaId()
+		}
+	}
+}
+
+// Seen reports whether path is the in file or out file of any entry
+func (idx *ManifestIndex) Seen(path string) bool {
+	key := manifestKey(path)
+	if _, ok := idx.byInFile[key]; ok {
+		return true
+	}
+	_, ok := idx.byOutFile[key]
+	return ok
+}
+
+// OutNameId returns the id of the movie placed with the name of outFile,
+// whatever its extension
+func (idx *ManifestIndex) OutNameId(outFile string) (mediaId, bool) {
+	id, ok := idx.byOutName[outNameKey(outFile)]
+	return id, ok
+}
+
+func outNameKey(outFile string) string {
+	return manifestKey(strings.TrimSuffix(outFile, filepath.Ext(outFile)))
+}
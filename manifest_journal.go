@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+)
+
+// Entries placed during a run are appended to a journal next to the manifest
+// instead of rewriting the whole manifest for every in file. The journal is
+// merged by readManifest, so entries survive an interrupted run, and it is
+// removed whenever the full manifest is written.
+func manifestJournalPath(manifestPath string) string {
+	return manifestPath + ".journal"
+}
+
+func appendManifestJournal(manifestPath string, entry ManifestEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(manifestJournalPath(manifestPath), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+func readManifestJournal(manifestPath string) ([]ManifestEntry, error) {
+	entries := []ManifestEntry{}
+
+	f, err := os.Open(manifestJournalPath(manifestPath))
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return entries, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lineErr error
+	for scanner.Scan() {
+		if lineErr != nil {
+			// only the last line may be incomplete, after a crash mid-write
+			return entries, lineErr
+		}
+		entry := ManifestEntry{}
+		lineErr = json.Unmarshal(scanner.Bytes(), &entry)
+		if lineErr == nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+func removeManifestJournal(manifestPath string) error {
+	err := os.Remove(manifestJournalPath(manifestPath))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// compactManifest merges a left over journal into the manifest file
+func compactManifest(manifestPath string) error {
+	exists, err := fileExists(manifestJournalPath(manifestPath))
+	if err != nil || !exists {
+		return err
+	}
+
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	return writeManifest(manifestPath, manifest)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const rebaseAction = "rebase"
+
+// manifestKey normalizes a path for matching manifest entries, so a share
+// remounted with a trailing slash or, with -manifest-ignore-case, with a
+// different case still matches
+func manifestKey(path string) string {
+	path = filepath.Clean(path)
+	if *manifestIgnoreCaseFlag {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// sameManifestPath reports whether two paths match as manifest paths
+func sameManifestPath(a, b string) bool {
+	return manifestKey(a) == manifestKey(b)
+}
+
+// rebasePath returns path moved from the directory from to the directory to,
+// and whether path is below from at all
+func rebasePath(path, from, to string) (string, bool) {
+	if path == "" {
+		return path, false
+	}
+	key, fromKey := manifestKey(path), manifestKey(from)
+	if key == fromKey {
+		return filepath.Clean(to), true
+	}
+	prefix := strings.TrimSuffix(fromKey, string(filepath.Separator)) + string(filepath.Separator)
+	if !strings.HasPrefix(key, prefix) {
+		return path, false
+	}
+	// the cleaned path keeps its case, only the prefix is replaced
+	return filepath.Join(to, filepath.Clean(path)[len(prefix):]), true
+}
+
+// rebaseManifest moves the in, out and displaced files of manifest entries
+// from the directory from to the directory to, ie. after a share was
+// remounted from /mnt/media to /media, asking for each entry
+func rebaseManifest(manifestPath, from, to string, dryRun bool) error {
+	if from == "" || to == "" {
+		return fmt.Errorf("Usage: %s %s %s -from <old dir> -to <new dir>", BinName, manifestCommand, rebaseAction)
+	}
+	if !filepath.IsAbs(from) || !filepath.IsAbs(to) {
+		return fmt.Errorf("from and to must be absolute paths")
+	}
+
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	approval := newRenameApproval(bufio.NewReader(os.Stdin))
+	rebased := 0
+	for i, m := range manifest {
+		inFile, inOk := rebasePath(m.InFile, from, to)
+		outFile, outOk := rebasePath(m.OutFile, from, to)
+		displaced, displacedOk := rebasePath(m.Displaced, from, to)
+		if !inOk && !outOk && !displacedOk {
+			continue
+		}
+		if inOk {
+			fmt.Println(renameDiff(m.InFile, inFile))
+		}
+		if outOk {
+			fmt.Println(renameDiff(m.OutFile, outFile))
+		}
+		group := renameGroup(from, m.InFile)
+		if outOk {
+			group = renameGroup(from, m.OutFile)
+		}
+		ok, err := approval.approve(group)
+		if errors.Is(err, ErrQuit) {
+			break
+		} else if err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		m.InFile, m.OutFile, m.Displaced = inFile, outFile, displaced
+		manifest[i] = m
+		rebased += 1
+	}
+
+	fmt.Printf(tr("Rebased %d of %d manifest entries\n"), rebased, len(manifest))
+	if dryRun || rebased == 0 {
+		return nil
+	}
+	return writeManifest(manifestPath, manifest)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	pushAction = "push"
+	pullAction = "pull"
+)
+
+// ErrRemoteNotFound is returned when a remote manifest does not exist
+var ErrRemoteNotFound = errors.New("remote file not found")
+
+// syncStatePath stores the hash of the remote manifest as of the last sync
+func syncStatePath(manifestPath string) string {
+	return fmt.Sprintf("%s.sync", manifestPath)
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func readSyncState(manifestPath string) (string, error) {
+	b, err := ioutil.ReadFile(syncStatePath(manifestPath))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(b)), err
+}
+
+func writeSyncState(manifestPath, hash string) error {
+	return ioutil.WriteFile(syncStatePath(manifestPath), []byte(hash+"\n"), 0644)
+}
+
+func isS3Remote(remote string) bool {
+	return strings.HasPrefix(remote, "s3://")
+}
+
+// isSshRemote matches scp style remotes, ie. "user@host:path" or "host:path"
+func isSshRemote(remote string) bool {
+	i := strings.Index(remote, ":")
+	return i > 0 && !strings.Contains(remote[:i], "/") && !isS3Remote(remote)
+}
+
+func runTransfer(src, dst string) error {
+	var cmd *exec.Cmd
+	if isS3Remote(src) || isS3Remote(dst) {
+		cmd = exec.Command("aws", "s3", "cp", "--quiet", src, dst)
+	} else if isSshRemote(src) || isSshRemote(dst) {
+		cmd = exec.Command("scp", "-q", src, dst)
+	} else {
+		return copyFileContents(src, dst)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	err := cmd.Run()
+	if err != nil && remoteNotFound(stderr.String()) {
+		return fmt.Errorf("%s: %w", src, ErrRemoteNotFound)
+	}
+	return err
+}
+
+// remoteNotFound reports whether the error output of scp or the aws cli says
+// that the file does not exist, as opposed to ie. a failed login
+func remoteNotFound(stderr string) bool {
+	for _, msg := range []string{"No such file or directory", "(404)"} {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchRemote downloads the remote manifest, returning nil if it does not exist yet
+func fetchRemote(remote string) ([]byte, error) {
+	if !isS3Remote(remote) && !isSshRemote(remote) {
+		b, err := ioutil.ReadFile(remote)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return b, err
+	}
+
+	dir, err := ioutil.TempDir("", BinName)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "manifest.json")
+	err = runTransfer(remote, tmp)
+	if errors.Is(err, ErrRemoteNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadFile(tmp)
+}
+
+func manifestEntryKey(e ManifestEntry) string {
+	return fmt.Sprintf("%s\x00%s\x00%d", e.InFile, e.OutFile, e.CreatedAt.UnixNano())
+}
+
+// mergeManifests returns all entries of local followed by entries of
+// remote that are not in local
+func mergeManifests(local, remote []ManifestEntry) ([]ManifestEntry, int) {
+	seen := make(map[string]bool, len(local))
+	for _, e := range local {
+		seen[manifestEntryKey(e)] = true
+	}
+
+	merged := append([]ManifestEntry{}, local...)
+	added := 0
+	for _, e := range remote {
+		if !seen[manifestEntryKey(e)] {
+			merged = append(merged, e)
+			added += 1
+		}
+	}
+	return merged, added
+}
+
+// pullManifest merges the remote manifest into the local one
+func pullManifest(manifestPath, remote string) error {
+	remoteBytes, err := fetchRemote(remote)
+	if err != nil {
+		return err
+	}
+	if remoteBytes == nil {
+		return fmt.Errorf("Remote manifest %s does not exist", remote)
+	}
+
+	remoteManifest := []ManifestEntry{}
+	err = json.Unmarshal(remoteBytes, &remoteManifest)
+	if err != nil {
+		return fmt.Errorf("Invalid remote manifest: %s", err)
+	}
+
+	local, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	merged, added := mergeManifests(local, remoteManifest)
+	err = writeManifest(manifestPath, merged)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(tr("Pulled %d new entries from %s\n"), added, remote)
+	return writeSyncState(manifestPath, hashBytes(remoteBytes))
+}
+
+// pushManifest uploads the local manifest, refusing when the remote has
+// changed since the last sync unless force is given
+func pushManifest(manifestPath, remote string, force bool) error {
+	remoteBytes, err := fetchRemote(remote)
+	if err != nil {
+		return err
+	}
+
+	lastHash, err := readSyncState(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	if remoteBytes != nil && hashBytes(remoteBytes) != lastHash && !force {
+		return fmt.Errorf("Remote manifest %s has changed since last sync, pull first (or use -force)", remote)
+	}
+
+	err = compactManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	localBytes, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	err = runTransfer(manifestPath, remote)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(tr("Pushed %s to %s\n"), manifestPath, remote)
+	return writeSyncState(manifestPath, hashBytes(localBytes))
+}
+
+func runManifestCommand(action string, args []string, manifestPath string, edit manifestEdit) error {
+	switch action {
+	case verifyAction:
+		return verifyManifest(manifestPath)
+	case listAction:
+		return listManifest(manifestPath, edit.tags, *belowScoreFlag)
+	case editAction:
+		return editManifest(manifestPath, args, edit)
+	case rebaseAction:
+		return rebaseManifest(manifestPath, *fromFlag, *toFlag, *dryRunFlag)
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("Usage: %s %s %s|%s [flags] <remote>, or %s %s %s|%s|%s|%s [flags]", BinName, manifestCommand, pushAction, pullAction, BinName, manifestCommand, verifyAction, listAction, editAction, rebaseAction)
+	}
+	remote := args[0]
+
+	switch action {
+	case pushAction:
+		return pushManifest(manifestPath, remote, *forceFlag)
+	case pullAction:
+		return pullManifest(manifestPath, remote)
+	default:
+		return fmt.Errorf("Unknown manifest action %q, must be one of: %s, %s, %s, %s, %s, %s", action, pushAction, pullAction, verifyAction, listAction, editAction, rebaseAction)
+	}
+}
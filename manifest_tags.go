@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const editAction = "edit"
+
+// clean-protect patterns starting with tagProtectPrefix protect the
+// directories of the out files of manifest entries with that tag
+const tagProtectPrefix = "tag:"
+
+// manifestEdit changes the tags and note of manifest entries
+type manifestEdit struct {
+	tags    []string
+	untags  []string
+	note    string
+	setNote bool
+}
+
+// hasAnyTag reports whether the entry has one of tags
+func (m ManifestEntry) hasAnyTag(tags []string) bool {
+	for _, tag := range tags {
+		if stringSliceContains(m.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// addTags appends the tags not already in tags
+func addTags(tags, add []string) []string {
+	for _, tag := range add {
+		if !stringSliceContains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// removeTags returns tags without the ones in remove
+func removeTags(tags, remove []string) []string {
+	kept := []string{}
+	for _, tag := range tags {
+		if !stringSliceContains(remove, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// askTags asks for the tags and the note of an in file, the tags and note
+// given on the command line are the defaults
+func askTags(tags []string, note string, reader *bufio.Reader) ([]string, string) {
+	prompt := tr("Tags, CSV (empty for none)")
+	if len(tags) > 0 {
+		prompt = fmt.Sprintf(tr("Tags, CSV (default: %s)"), strings.Join(tags, ","))
+	}
+	fmt.Print(promptStr(prompt))
+	raw, err := reader.ReadString('\n')
+	if err != nil {
+		return tags, note
+	}
+	if answer := splitCsv(raw); len(answer) > 0 {
+		tags = answer
+	}
+
+	prompt = tr("Note (empty for none)")
+	if note != "" {
+		prompt = fmt.Sprintf(tr("Note (default: %s)"), note)
+	}
+	fmt.Print(promptStr(prompt))
+	raw, err = reader.ReadString('\n')
+	if err != nil {
+		return tags, note
+	}
+	if answer := strings.TrimSpace(raw); answer != "" {
+		note = answer
+	}
+	return tags, note
+}
+
+// editManifest changes the tags and note of the manifest entries of an
+// in or out file
+func editManifest(manifestPath string, args []string, edit manifestEdit) error {
+	if len(args) != 1 {
+		return fmt.Errorf("Usage: %s %s %s [flags] <in or out file>", BinName, manifestCommand, editAction)
+	}
+	if len(edit.tags) == 0 && len(edit.untags) == 0 && !edit.setNote {
+		return fmt.Errorf("nothing to edit, use -tags, -untag or -note")
+	}
+
+	file, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	edited := 0
+	for i, m := range manifest {
+		if !sameManifestPath(m.InFile, file) && !sameManifestPath(m.OutFile, file) {
+			continue
+		}
+		m.Tags = removeTags(addTags(m.Tags, edit.tags), edit.untags)
+		if edit.setNote {
+			m.Note = edit.note
+		}
+		manifest[i] = m
+		edited += 1
+	}
+	if edited == 0 {
+		return fmt.Errorf("no manifest entry for %s", file)
+	}
+
+	fmt.Printf(tr("Edited %d manifest entries\n"), edited)
+	return writeManifest(manifestPath, manifest)
+}
+
+// listManifest prints the in and out files of manifest entries with any of
+// tags, all entries when tags is empty. With belowScore, only entries
+// auto-matched with a lower score are listed.
+func listManifest(manifestPath string, tags []string, belowScore float64) error {
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	listed := 0
+	for _, m := range manifest {
+		if len(tags) > 0 && !m.hasAnyTag(tags) {
+			continue
+		}
+		if belowScore > 0 && !m.lowConfidence(belowScore) {
+			continue
+		}
+		line := fmt.Sprintf("%s %s %s", ColorStr(RedColor, m.InFile), ColorStr(WhiteColor, arrowStr()), ColorStr(GreenColor, m.OutFile))
+		if len(m.Tags) > 0 {
+			line += " " + ColorStr(YellowColor, fmt.Sprintf("[%s]", strings.Join(m.Tags, ",")))
+		}
+		if m.Note != "" {
+			line += fmt.Sprintf(" (%s)", m.Note)
+		}
+		if m.Match != nil {
+			line += " " + ColorStr(WhiteColor, fmt.Sprintf("<%s>", m.Match))
+		}
+		fmt.Println(line)
+		listed += 1
+	}
+	fmt.Printf(tr("%d manifest entries\n"), listed)
+	return nil
+}
+
+// taggedDirs returns the directories of the out files of manifest entries
+// with tag
+func taggedDirs(manifest []ManifestEntry, tag string) []string {
+	dirs := []string{}
+	for _, m := range manifest {
+		if m.OutFile != "" && stringSliceContains(m.Tags, tag) {
+			dirs = append(dirs, filepath.Dir(m.OutFile))
+		}
+	}
+	return dirs
+}
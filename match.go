@@ -0,0 +1,55 @@
+package main
+
+import "fmt"
+
+// how the movie or episode of an in file was matched
+const (
+	autoMatch        = "auto"
+	interactiveMatch = "interactive"
+	idMatch          = "id"
+	summaryMatch     = "summary"
+)
+
+// Match records how the movie or episode of a manifest entry was matched,
+// so auto matches can be audited and the ones with a low score re-verified
+type Match struct {
+	Method string `json:"method"`
+	// score of the best search result, auto matches only
+	Score float64 `json:"score,omitempty"`
+	// the result selected and the number of results, interactive matches only
+	Selection int `json:"selection,omitempty"`
+	Results   int `json:"results,omitempty"`
+	// imdb or tmdb id embedded in the release that the movie or show was
+	// looked up by
+	ExternalId string `json:"external_id,omitempty"`
+	// the season and episode were mapped by the season map of the config file
+	SeasonMap bool `json:"season_map,omitempty"`
+}
+
+func (m *Match) String() string {
+	var s string
+	switch m.Method {
+	case autoMatch:
+		s = autoMatch
+		if m.Score > 0 {
+			s = fmt.Sprintf("%s, score %.2f", s, m.Score)
+		}
+	case interactiveMatch:
+		s = fmt.Sprintf("selection %d of %d", m.Selection, m.Results)
+	default:
+		s = m.Method
+	}
+	if m.ExternalId != "" {
+		s = fmt.Sprintf("%s, %s", s, m.ExternalId)
+	}
+	if m.SeasonMap {
+		s += ", season map"
+	}
+	return s
+}
+
+// lowConfidence reports whether the entry was matched automatically with a
+// score below minScore
+func (m ManifestEntry) lowConfidence(minScore float64) bool {
+	return m.Match != nil && m.Match.Method == autoMatch && m.Match.Score < minScore
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+var ffprobeBin = "ffprobe"
+
+type MediaInfo struct {
+	Width      int
+	Height     int
+	VideoCodec string
+	AudioCodec string
+	BitRate    int64
+	Duration   time.Duration
+}
+
+type ffprobeStream struct {
+	CodecType string `json:"codec_type"`
+	CodecName string `json:"codec_name"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+}
+
+type ffprobeFormat struct {
+	Duration string `json:"duration"`
+	BitRate  string `json:"bit_rate"`
+}
+
+type ffprobeResponse struct {
+	Streams []ffprobeStream `json:"streams"`
+	Format  ffprobeFormat   `json:"format"`
+}
+
+func ffprobeAvailable() bool {
+	_, err := exec.LookPath(ffprobeBin)
+	return err == nil
+}
+
+// probeMediaInfo uses ffprobe to read stream and format details of a media file
+func probeMediaInfo(mediaPath string) (MediaInfo, error) {
+	info := MediaInfo{}
+
+	cmd := exec.Command(ffprobeBin, "-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", mediaPath)
+	out, err := cmd.Output()
+	if err != nil {
+		return info, err
+	}
+
+	response := ffprobeResponse{}
+	err = json.Unmarshal(out, &response)
+	if err != nil {
+		return info, err
+	}
+
+	for _, stream := range response.Streams {
+		if stream.CodecType == "video" && info.VideoCodec == "" {
+			info.VideoCodec = stream.CodecName
+			info.Width = stream.Width
+			info.Height = stream.Height
+		} else if stream.CodecType == "audio" && info.AudioCodec == "" {
+			info.AudioCodec = stream.CodecName
+		}
+	}
+
+	if response.Format.BitRate != "" {
+		info.BitRate, _ = strconv.ParseInt(response.Format.BitRate, 10, 64)
+	}
+
+	if response.Format.Duration != "" {
+		seconds, err := strconv.ParseFloat(response.Format.Duration, 64)
+		if err == nil {
+			info.Duration = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	return info, nil
+}
+
+func (m MediaInfo) Resolution() string {
+	if m.Width == 0 || m.Height == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", m.Width, m.Height)
+}
+
+func (m MediaInfo) String() string {
+	parts := []string{}
+	if res := m.Resolution(); res != "" {
+		parts = append(parts, res)
+	}
+
+	codecs := []string{}
+	if m.VideoCodec != "" {
+		codecs = append(codecs, m.VideoCodec)
+	}
+	if m.AudioCodec != "" {
+		codecs = append(codecs, m.AudioCodec)
+	}
+	if len(codecs) > 0 {
+		parts = append(parts, strings.Join(codecs, "/"))
+	}
+
+	if m.BitRate > 0 {
+		parts = append(parts, fmt.Sprintf("%sps", strings.Replace(humanize.SI(float64(m.BitRate), "b"), " ", "", -1)))
+	}
+
+	if m.Duration > 0 {
+		parts = append(parts, m.Duration.Round(time.Second).String())
+	}
+
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,686 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// Messages are looked up by their english text (format strings included),
+// so untranslated messages fall back to english automatically.
+var messageCatalogs = map[string]map[string]string{
+	"es": {
+		"y":                           "s",
+		"Move":                        "Mover",
+		"Copy":                        "Copiar",
+		"Link":                        "Enlazar",
+		"Stream":                      "Transmitir",
+		"to":                          "a",
+		"%s? [yN]":                    "¿%s? [sN]",
+		"Movie":                       "Película",
+		"Tv show":                     "Serie",
+		"Episode":                     "Episodio",
+		"%s query (page %d/%d): %s\n": "Búsqueda de %s (página %d/%d): %s\n",
+		"%s query: %s\n":              "Búsqueda de %s: %s\n",
+		"year: %d":                    "año: %d",
+		"season: %d":                  "temporada: %d",
+		"episode: %d":                 "episodio: %d",
+		"No results!":                 "¡Sin resultados!",
+		"(default: %s)":               "(predeterminado: %s)",
+		"1 select":                    "1 seleccionar",
+		"1-%d select":                 "1-%d seleccionar",
+		"default (empty string) select choice %d": "predeterminado (vacío) selecciona la opción %d",
+		"q quit":                                "q salir",
+		"s skip":                                "s omitir",
+		"h this help":                           "h esta ayuda",
+		"p next page of results (if available)": "p siguiente página de resultados (si existe)",
+		"g choose episode group (dvd, absolute order) of tv show":           "g elegir el grupo de episodios (dvd, orden absoluto) de la serie",
+		"N-M select episodes N to M of a multi-episode file":                "N-M seleccionar los episodios N a M de un archivo con varios episodios",
+		"any other text is new query":                                       "cualquier otro texto es una nueva búsqueda",
+		"Invalid selection:":                                                "Selección no válida:",
+		"Invalid tv season selection:":                                      "Selección de temporada no válida:",
+		"Unable to extract season number from query string.":                "No se pudo extraer el número de temporada de la búsqueda.",
+		"Please select one of the listed options.":                          "Por favor, elija una de las opciones de la lista.",
+		"Error selecting tv show based on previous query:":                  "Error al seleccionar la serie según la búsqueda anterior:",
+		"Error searching movies:":                                           "Error al buscar películas:",
+		"Error searching tv shows:":                                         "Error al buscar series:",
+		"Skipping because we've seen this in-file before":                   "Omitido porque este archivo ya fue procesado",
+		"In file and out file are the same path":                            "El archivo de entrada y el de salida son la misma ruta",
+		"Out file exists and is same content as in file, updating manifest": "El archivo de salida existe con el mismo contenido, actualizando el manifiesto",
+		"Out file exists and has different content as in file!":             "¡El archivo de salida existe con contenido diferente!",
+		"In: ":                          "Entrada: ",
+		"Out:":                          "Salida:",
+		"     Size: %s, modified: %s\n": "     Tamaño: %s, modificado: %s\n",
+		"     Media: %s\n":              "     Medios: %s\n",
+		"Conflict policy %s: %s\n":      "Política de conflicto %s: %s\n",
+		"Upgrade: %s, replacing\n":      "Mejora: %s, reemplazando\n",
+		"Upgrade: %s, skipping\n":       "Mejora: %s, omitiendo\n",
+		"\nGoodbye!\n":                  "\n¡Adiós!\n",
+		"\n%d out files already exist with different content:\n":                                       "\n%d archivos de salida ya existen con contenido diferente:\n",
+		"\nRetrying %d failed files in %s (attempt %d/%d)\n":                                           "\nReintentando %d archivos fallidos en %s (intento %d/%d)\n",
+		"\nStop word candidates (in at least %g%% of %d files):\n%s\n":                                 "\nCandidatas a palabras vacías (en al menos el %g%% de %d archivos):\n%s\n",
+		"    %-20s dropped (imdb/tmdb id)\n":                                                           "    %-20s descartado (id de imdb/tmdb)\n",
+		"    %-20s dropped (single character)\n":                                                       "    %-20s descartado (un solo carácter)\n",
+		"    %-20s dropped (stop word)\n":                                                              "    %-20s descartado (palabra vacía)\n",
+		"    %-20s kept\n":                                                                             "    %-20s conservado\n",
+		"  %s found in the file name, nfo or torrent, the %s is looked up by it instead of searched\n": "  %s encontrado en el nombre de archivo, nfo o torrent, %s se consulta por él en lugar de buscarse\n",
+		"  absolute episode found, searching tv shows and mapping it to season and episode\n\n":        "  episodio absoluto encontrado, buscando series y asignándolo a temporada y episodio\n\n",
+		"  absolute episode: %d\n":                                                                     "  episodio absoluto: %d\n",
+		"  air date found, searching tv shows and the episode aired that day\n\n":                      "  fecha de emisión encontrada, buscando series y el episodio emitido ese día\n\n",
+		"  air date: %s\n":            "  fecha de emisión: %s\n",
+		"  disabled by no-common-dir": "  desactivado por no-common-dir",
+		"  matches need to be confirmed, batch mode leaves the file for review\n\n": "  las coincidencias deben confirmarse, el modo batch deja el archivo para revisión\n\n",
+		"  no season/episode found, searching movies\n\n":                           "  no se encontró temporada/episodio, buscando películas\n\n",
+		"  none, file query is always used":                                         "  ninguno, siempre se usa la búsqueda del archivo",
+		"  query: %q\n":                                                             "  búsqueda: %q\n",
+		"  query: %q\n\n":                                                           "  búsqueda: %q\n\n",
+		"  raw tokens: %s\n":                                                        "  tokens sin procesar: %s\n",
+		"  scope: %s, minimum peers: %d\n":                                          "  ámbito: %s, mínimo de archivos vecinos: %d\n",
+		"  season/episode found, searching tv shows\n\n":                            "  temporada/episodio encontrado, buscando series\n\n",
+		"  season: %d, episode: %d, year: %d\n":                                     "  temporada: %d, episodio: %d, año: %d\n",
+		"  tokens shared with peer files in %s: %q\n":                               "  tokens compartidos con los archivos vecinos en %s: %q\n",
+		"  used as tv show query when switching seasons without a manual query":     "  se usa como búsqueda de serie al cambiar de temporada sin búsqueda manual",
+		" of %d":                                      " de %d",
+		"%2d %s (%d episodes, %d groups)%s\n":         "%2d %s (%d episodios, %d grupos)%s\n",
+		"%d entries":                                  "%d entradas",
+		"%d files need attention\n":                   "%d archivos requieren atención\n",
+		"%d manifest entries\n":                       "%d entradas del manifiesto\n",
+		"%s (%s, %d files, newest %s)":                "%s (%s, %d archivos, más reciente %s)",
+		"%s api key":                                  "clave de api de %s",
+		"%s free, in files are %s":                    "%s libres, los archivos de entrada ocupan %s",
+		"%s, %d out files no longer exist":            "%s, %d archivos de salida ya no existen",
+		"%s, journal of an interrupted run left over": "%s, quedó el diario de una ejecución interrumpida",
+		"%s, retrying in %s (attempt %d/%d)\n":        "%s, reintentando en %s (intento %d/%d)\n",
+		"%s: checksum is %s, expected %s\n":           "%s: la suma de comprobación es %s, se esperaba %s\n",
+		", remaining: %s":                             ", restante: %s",
+		", throughput: %s/s":                          ", velocidad: %s/s",
+		"1. Tokens from file name %q\n":               "1. Tokens del nombre de archivo %q\n",
+		"2. File name looks obfuscated, using the directory name or the title of an nfo file\n":    "2. El nombre de archivo parece ofuscado, usando el nombre del directorio o el título de un archivo nfo\n",
+		"2. File name query is not empty after extraction, relative path not used\n\n":             "2. La búsqueda del nombre de archivo no está vacía tras la extracción, no se usa la ruta relativa\n\n",
+		"2. Nothing left after season/episode/year extraction, using path relative to in dir %q\n": "2. No queda nada tras extraer temporada/episodio/año, usando la ruta relativa al directorio de entrada %q\n",
+		"2b. No year, air date or season/episode in the query, using the release name in %s\n":     "2b. Sin año, fecha de emisión ni temporada/episodio en la búsqueda, usando el nombre de la release en %s\n",
+		"3. Air date/season/episode/year extraction":                                               "3. Extracción de fecha de emisión/temporada/episodio/año",
+		"4. Common directory tokens":          "4. Tokens comunes del directorio",
+		"Aired order (default)":               "Orden de emisión (predeterminado)",
+		"Api requests: %d this run, %d today": "Solicitudes a la api: %d en esta ejecución, %d hoy",
+		"Apply? [yNsaq] (s: all remaining of this show or movie, a: all remaining, q: quit)": "¿Aplicar? [sNaq] (a: todos los restantes, q: salir)",
+		"Applying override for movie %d\n":                                                   "Aplicando la corrección para la película %d\n",
+		"Applying override for tv show %d\n":                                                 "Aplicando la corrección para la serie %d\n",
+		"Auto-selected %s (%s), score %.2f\n":                                                "Seleccionado automáticamente %s (%s), puntuación %.2f\n",
+		"Auto-selected %s S%02dE%02d %s\n":                                                   "Seleccionado automáticamente %s S%02dE%02d %s\n",
+		"Confirmed %d and corrected %d of %d auto matches\n":                                 "Confirmadas %d y corregidas %d de %d coincidencias automáticas\n",
+		"Converted subtitle %s from %s to utf-8\n":                                           "Subtítulo %s convertido de %s a utf-8\n",
+		"Copying to %s:\n":                                                                   "Copiando a %s:\n",
+		"Crc32 %s verified\n":                                                                "Crc32 %s verificado\n",
+		"Current file name: %s\n":                                                            "Nombre de archivo actual: %s\n",
+		"Deferred to the next run: %d\n":                                                     "Aplazados a la próxima ejecución: %d\n",
+		"Deferring because the in-file is still being written":                               "Aplazado porque el archivo de entrada aún se está escribiendo",
+		"Deferring because the in-file was modified too recently":                            "Aplazado porque el archivo de entrada se modificó hace muy poco",
+		"Edited %d manifest entries\n":                                                       "Editadas %d entradas del manifiesto\n",
+		"Episode %d is not in season %d of %s on moviedb.\n":                                 "El episodio %d no está en la temporada %d de %s en moviedb.\n",
+		"Episode offset (empty for 0)":                                                       "Desplazamiento de episodios (vacío para 0)",
+		"Error getting episode groups:":                                                      "Error al obtener los grupos de episodios:",
+		"Error looking up %s: %s\n":                                                          "Error al consultar %s: %s\n",
+		"Extras, CSV of numbers, the other files are ignored (default: none)":                "Extras, números separados por comas, los demás archivos se ignoran (predeterminado: ninguno)",
+		"Failures:":                 "Fallos:",
+		"Fastest: %s at %s/s\n":     "Más rápido: %s a %s/s\n",
+		"File: %s\n":                "Archivo: %s\n",
+		"Files of %s (%s) in %s:\n": "Archivos de %s (%s) en %s:\n",
+		"Files: %d/%d, copied: %s":  "Archivos: %d/%d, copiado: %s",
+		"Found %d directories without out files in %s\n":               "Se encontraron %d directorios sin archivos de salida en %s\n",
+		"Found %s (%s) by %s\n":                                        "Encontrado %s (%s) por %s\n",
+		"Ignoring %s, it is neither the main feature nor an extra\n\n": "Ignorando %s, no es la película principal ni un extra\n\n",
+		"In dir: %s\n\n":                        "Directorio de entrada: %s\n\n",
+		"Keeping artwork %s\n":                  "Conservando la imagen %s\n",
+		"Keeping nfo file %s\n":                 "Conservando el archivo nfo %s\n",
+		"Leaving %s, it was not placed by %s\n": "Dejando %s, no fue colocado por %s\n",
+		"Main feature [1-%d], or s to match each file separately (default: %d)": "Película principal [1-%d], o s para buscar cada archivo por separado (predeterminado: %d)",
+		"Manifest is empty, nothing to undo":                                    "El manifiesto está vacío, no hay nada que deshacer",
+		"Map release season %d to moviedb season (empty to skip)":               "Asignar la temporada %d de la release a la temporada de moviedb (vacío para omitir)",
+		"Matched %s (%s)":                                            "Coincidencia %s (%s)",
+		"Needs review:":                                              "Requieren revisión:",
+		"New file name (without extension)":                          "Nuevo nombre de archivo (sin extensión)",
+		"Next scan at %s\n":                                          "Próximo escaneo a las %s\n",
+		"No auto matches with a score below %.2f\n":                  "No hay coincidencias automáticas con una puntuación menor que %.2f\n",
+		"Not tagging out file metadata, it is a .strm file":          "No se etiquetan los metadatos del archivo de salida, es un archivo .strm",
+		"Not tagging out file metadata, it is linked to the in file": "No se etiquetan los metadatos del archivo de salida, está enlazado al archivo de entrada",
+		"Note (default: %s)":                                         "Nota (predeterminado: %s)",
+		"Note (empty for none)":                                      "Nota (vacío para ninguna)",
+		"Out file %s no longer exists\n":                             "El archivo de salida %s ya no existe\n",
+		"Out file exists with different content, deciding at the end of the run":                     "El archivo de salida existe con contenido diferente, se decidirá al final de la ejecución",
+		"Out file is used by a different movie with the same title and year (id %d), appending %q\n": "El archivo de salida lo usa otra película con el mismo título y año (id %d), añadiendo %q\n",
+		"Out file: %s\n":                                          "Archivo de salida: %s\n",
+		"Placed: %d, skipped: %d, failed: %d\n":                   "Colocados: %d, omitidos: %d, fallidos: %d\n",
+		"Plan diff: %d changed, %d added, %d no longer planned\n": "Diferencias del plan: %d cambiados, %d añadidos, %d ya no planificados\n",
+		"Processed %d/%d files, copied %s in %s":                  "Procesados %d/%d archivos, copiados %s en %s",
+		"Pulled %d new entries from %s\n":                         "Obtenidas %d entradas nuevas de %s\n",
+		"Pushed %s to %s\n":                                       "Enviado %s a %s\n",
+		"Rebased %d of %d manifest entries\n":                     "Reubicadas %d de %d entradas del manifiesto\n",
+		"Reclaimable: %s in %d directories\n":                     "Recuperable: %s en %d directorios\n",
+		"Recycled %s %s %s\n":                                     "Reciclado %s %s %s\n",
+		"Remaining files of %s in %s:\n":                          "Archivos restantes de %s en %s:\n",
+		"Remove? [yNaq] (a: all remaining, q: quit)":              "¿Eliminar? [sNaq] (a: todos los restantes, q: salir)",
+		"Removed: %s in %d directories\n":                         "Eliminado: %s en %d directorios\n",
+		"Removing %s\n":                                           "Eliminando %s\n",
+		"Same as before: %s (%s)\n":                               "Igual que antes: %s (%s)\n",
+		"Saved copy defaults to %s\n":                             "Valores de copia predeterminados guardados en %s\n",
+		"Scanned %d directories":                                  "Escaneados %d directorios",
+		"Searching %s\n":                                          "Buscando en %s\n",
+		"Summed the size of %d directories":                       "Sumado el tamaño de %d directorios",
+		"Tags, CSV (default: %s)":                                 "Etiquetas, separadas por comas (predeterminado: %s)",
+		"Tags, CSV (empty for none)":                              "Etiquetas, separadas por comas (vacío para ninguna)",
+		"The file name looks obfuscated, the query was built from its directory or nfo file. Use %s (%s)? [yN]": "El nombre de archivo parece ofuscado, la búsqueda se formó a partir de su directorio o archivo nfo. ¿Usar %s (%s)? [sN]",
+		"Transient failure, will retry at the end of the run:":                                                  "Fallo transitorio, se reintentará al final de la ejecución:",
+		"Undid %d placements\n":                                                                    "Deshechas %d colocaciones\n",
+		"Undo %d placements? [yN]":                                                                 "¿Deshacer %d colocaciones? [sN]",
+		"Upgrade: ffprobe not found, skipping":                                                     "Mejora: no se encontró ffprobe, omitiendo",
+		"Upgrade: unable to probe in file, skipping:":                                              "Mejora: no se pudo analizar el archivo de entrada, omitiendo:",
+		"Upgrade: unable to probe out file, skipping:":                                             "Mejora: no se pudo analizar el archivo de salida, omitiendo:",
+		"Use these %d episodes? [yN]":                                                              "¿Usar estos %d episodios? [sN]",
+		"Using tokens common to files in this directory: %s\n":                                     "Usando los tokens comunes a los archivos de este directorio: %s\n",
+		"Verified %d out files, %d failed\n":                                                       "Verificados %d archivos de salida, %d fallidos\n",
+		"Warning: %d api requests today, approaching the daily limit of %d\n":                      "Aviso: %d solicitudes a la api hoy, cerca del límite diario de %d\n",
+		"Warning: %d api requests today, over the daily limit of %d\n":                             "Aviso: %d solicitudes a la api hoy, por encima del límite diario de %d\n",
+		"Warning: crc32 mismatch, file name says %s but file is %s, the download may be corrupt\n": "Aviso: crc32 no coincide, el nombre de archivo indica %s pero el archivo es %s, la descarga puede estar dañada\n",
+		"Warning: moviedb year %s does not match file name year %s, using %s\n":                    "Aviso: el año %s de moviedb no coincide con el año %s del nombre de archivo, usando %s\n",
+		"Writing %s of test data to %s\n":                                                          "Escribiendo %s de datos de prueba en %s\n",
+		"Writing nfo file %s\n":                                                                    "Escribiendo el archivo nfo %s\n",
+		"[e] edit file name, enter to accept":                                                      "[e] editar el nombre de archivo, enter para aceptar",
+		"[o] overwrite all, [k] keep both for all, [s] skip all, [e] decide for each":              "[o] sobrescribir todos, [k] conservar ambos en todos, [s] omitir todos, [e] decidir para cada uno",
+		"absolute episode: %d":                                                                     "episodio absoluto: %d",
+		"air date: %s":                                                                             "fecha de emisión: %s",
+		"check that the directory exists and is readable by this user":                             "compruebe que el directorio existe y que este usuario puede leerlo",
+		"check that the directory is writable by this user":                                        "compruebe que este usuario puede escribir en el directorio",
+		"check the network connection":                                                             "compruebe la conexión de red",
+		"check the value of -api-key (%s)":                                                         "compruebe el valor de -api-key (%s)",
+		"compare conflicting files and use media info in templates":                                "comparar archivos en conflicto y usar la información de medios en plantillas",
+		"directed by %s":   "dirigida por %s",
+		"disk space of %s": "espacio en disco de %s",
+		"extract rar releases, only extracted video files are placed": "extraer releases rar, solo se colocan los archivos de vídeo extraídos",
+		"fail": "fallo",
+		"fix or move the manifest file, a backup may be pulled with the manifest command": "corrija o mueva el archivo del manifiesto, se puede obtener una copia de seguridad con el comando manifest",
+		"free space or use -mv on the same file system":                                   "libere espacio o use -mv en el mismo sistema de archivos",
+		"hardlinks to %s": "enlaces duros a %s",
+		"in and out dirs are on different file systems, files are copied and seeding falls back to symlinks": "los directorios de entrada y salida están en sistemas de archivos distintos, los archivos se copian y el seeding usa enlaces simbólicos",
+		"in and out dirs are on different file systems, use -link %s to copy files that can't be linked":     "los directorios de entrada y salida están en sistemas de archivos distintos, use -link %s para copiar los archivos que no se pueden enlazar",
+		"in dir %s":        "directorio de entrada %s",
+		"install it to %s": "instálelo para %s",
+		"invalid":          "no válida",
+		"it is merged into the manifest by the next run": "la próxima ejecución lo incorpora al manifiesto",
+		"manifest %s": "manifiesto %s",
+		"missing out files are placed again on the next run unless their in files are gone": "los archivos de salida que faltan se colocan de nuevo en la próxima ejecución salvo que ya no existan sus archivos de entrada",
+		"movie":                          "la película",
+		"not checked, no in files found": "no comprobado, no se encontraron archivos de entrada",
+		"not found in PATH":              "no se encontró en el PATH",
+		"not set":                        "no establecida",
+		"ok":                             "ok",
+		"out dir %s":                     "directorio de salida %s",
+		"readable":                       "legible",
+		"set -api-key, or api_keys in the config file (%s)": "establezca -api-key, o api_keys en el archivo de configuración (%s)",
+		"set -provider to moviedb or tvdb":                  "establezca -provider en moviedb o tvdb",
+		"tv show":                                           "la serie",
+		"warn":                                              "aviso",
+		"work":                                              "funcionan",
+		"works":                                             "funciona",
+		"writable":                                          "escribible",
+	},
+	"de": {
+		"y":                           "j",
+		"Move":                        "Verschieben",
+		"Copy":                        "Kopieren",
+		"Link":                        "Verknüpfen",
+		"Stream":                      "Streamen",
+		"to":                          "nach",
+		"%s? [yN]":                    "%s? [jN]",
+		"Movie":                       "Film",
+		"Tv show":                     "Serie",
+		"Episode":                     "Episode",
+		"%s query (page %d/%d): %s\n": "%s-Suche (Seite %d/%d): %s\n",
+		"%s query: %s\n":              "%s-Suche: %s\n",
+		"year: %d":                    "Jahr: %d",
+		"season: %d":                  "Staffel: %d",
+		"episode: %d":                 "Episode: %d",
+		"No results!":                 "Keine Ergebnisse!",
+		"(default: %s)":               "(Standard: %s)",
+		"1 select":                    "1 auswählen",
+		"1-%d select":                 "1-%d auswählen",
+		"default (empty string) select choice %d": "Standard (leere Eingabe) wählt Option %d",
+		"q quit":                                "q beenden",
+		"s skip":                                "s überspringen",
+		"h this help":                           "h diese Hilfe",
+		"p next page of results (if available)": "p nächste Ergebnisseite (falls vorhanden)",
+		"g choose episode group (dvd, absolute order) of tv show":           "g Episodengruppe (dvd, absolute Reihenfolge) der Serie wählen",
+		"N-M select episodes N to M of a multi-episode file":                "N-M Episoden N bis M einer Datei mit mehreren Episoden auswählen",
+		"any other text is new query":                                       "jeder andere Text ist eine neue Suche",
+		"Invalid selection:":                                                "Ungültige Auswahl:",
+		"Invalid tv season selection:":                                      "Ungültige Staffelauswahl:",
+		"Unable to extract season number from query string.":                "Staffelnummer konnte nicht aus der Suche ermittelt werden.",
+		"Please select one of the listed options.":                          "Bitte eine der aufgelisteten Optionen wählen.",
+		"Error selecting tv show based on previous query:":                  "Fehler beim Auswählen der Serie anhand der vorherigen Suche:",
+		"Error searching movies:":                                           "Fehler bei der Filmsuche:",
+		"Error searching tv shows:":                                         "Fehler bei der Seriensuche:",
+		"Skipping because we've seen this in-file before":                   "Übersprungen, da diese Datei bereits verarbeitet wurde",
+		"In file and out file are the same path":                            "Eingabe- und Ausgabedatei haben denselben Pfad",
+		"Out file exists and is same content as in file, updating manifest": "Ausgabedatei existiert mit gleichem Inhalt, Manifest wird aktualisiert",
+		"Out file exists and has different content as in file!":             "Ausgabedatei existiert mit anderem Inhalt!",
+		"In: ":                          "Ein: ",
+		"Out:":                          "Aus:",
+		"     Size: %s, modified: %s\n": "     Größe: %s, geändert: %s\n",
+		"     Media: %s\n":              "     Medien: %s\n",
+		"Conflict policy %s: %s\n":      "Konfliktregel %s: %s\n",
+		"Upgrade: %s, replacing\n":      "Upgrade: %s, wird ersetzt\n",
+		"Upgrade: %s, skipping\n":       "Upgrade: %s, wird übersprungen\n",
+		"\nGoodbye!\n":                  "\nAuf Wiedersehen!\n",
+		"\n%d out files already exist with different content:\n":                                       "\n%d Ausgabedateien existieren bereits mit anderem Inhalt:\n",
+		"\nRetrying %d failed files in %s (attempt %d/%d)\n":                                           "\nErneuter Versuch für %d fehlgeschlagene Dateien in %s (Versuch %d/%d)\n",
+		"\nStop word candidates (in at least %g%% of %d files):\n%s\n":                                 "\nStoppwort-Kandidaten (in mindestens %g%% von %d Dateien):\n%s\n",
+		"    %-20s dropped (imdb/tmdb id)\n":                                                           "    %-20s verworfen (imdb/tmdb-Id)\n",
+		"    %-20s dropped (single character)\n":                                                       "    %-20s verworfen (einzelnes Zeichen)\n",
+		"    %-20s dropped (stop word)\n":                                                              "    %-20s verworfen (Stoppwort)\n",
+		"    %-20s kept\n":                                                                             "    %-20s behalten\n",
+		"  %s found in the file name, nfo or torrent, the %s is looked up by it instead of searched\n": "  %s im Dateinamen, in der nfo oder im torrent gefunden, %s wird darüber nachgeschlagen statt gesucht\n",
+		"  absolute episode found, searching tv shows and mapping it to season and episode\n\n":        "  absolute Episode gefunden, Serien werden gesucht und sie wird Staffel und Episode zugeordnet\n\n",
+		"  absolute episode: %d\n":                                                                     "  absolute Episode: %d\n",
+		"  air date found, searching tv shows and the episode aired that day\n\n":                      "  Ausstrahlungsdatum gefunden, Serien und die an dem Tag ausgestrahlte Episode werden gesucht\n\n",
+		"  air date: %s\n":            "  Ausstrahlungsdatum: %s\n",
+		"  disabled by no-common-dir": "  durch no-common-dir deaktiviert",
+		"  matches need to be confirmed, batch mode leaves the file for review\n\n": "  Treffer müssen bestätigt werden, im Batch-Modus bleibt die Datei zur Prüfung liegen\n\n",
+		"  no season/episode found, searching movies\n\n":                           "  keine Staffel/Episode gefunden, Filme werden gesucht\n\n",
+		"  none, file query is always used":                                         "  keine, die Suche der Datei wird immer verwendet",
+		"  query: %q\n":                                                             "  Suche: %q\n",
+		"  query: %q\n\n":                                                           "  Suche: %q\n\n",
+		"  raw tokens: %s\n":                                                        "  rohe Tokens: %s\n",
+		"  scope: %s, minimum peers: %d\n":                                          "  Bereich: %s, Mindestanzahl Nachbardateien: %d\n",
+		"  season/episode found, searching tv shows\n\n":                            "  Staffel/Episode gefunden, Serien werden gesucht\n\n",
+		"  season: %d, episode: %d, year: %d\n":                                     "  Staffel: %d, Episode: %d, Jahr: %d\n",
+		"  tokens shared with peer files in %s: %q\n":                               "  gemeinsame Tokens mit Nachbardateien in %s: %q\n",
+		"  used as tv show query when switching seasons without a manual query":     "  wird beim Staffelwechsel ohne manuelle Suche als Seriensuche verwendet",
+		" of %d":                                      " von %d",
+		"%2d %s (%d episodes, %d groups)%s\n":         "%2d %s (%d Episoden, %d Gruppen)%s\n",
+		"%d entries":                                  "%d Einträge",
+		"%d files need attention\n":                   "%d Dateien benötigen Aufmerksamkeit\n",
+		"%d manifest entries\n":                       "%d Manifest-Einträge\n",
+		"%s (%s, %d files, newest %s)":                "%s (%s, %d Dateien, neueste %s)",
+		"%s api key":                                  "%s-API-Schlüssel",
+		"%s free, in files are %s":                    "%s frei, Eingabedateien sind %s groß",
+		"%s, %d out files no longer exist":            "%s, %d Ausgabedateien existieren nicht mehr",
+		"%s, journal of an interrupted run left over": "%s, Journal eines abgebrochenen Laufs übrig",
+		"%s, retrying in %s (attempt %d/%d)\n":        "%s, neuer Versuch in %s (Versuch %d/%d)\n",
+		"%s: checksum is %s, expected %s\n":           "%s: Prüfsumme ist %s, erwartet %s\n",
+		", remaining: %s":                             ", verbleibend: %s",
+		", throughput: %s/s":                          ", Durchsatz: %s/s",
+		"1. Tokens from file name %q\n":               "1. Tokens aus dem Dateinamen %q\n",
+		"2. File name looks obfuscated, using the directory name or the title of an nfo file\n":    "2. Dateiname sieht verschleiert aus, Verzeichnisname oder Titel einer nfo-Datei wird verwendet\n",
+		"2. File name query is not empty after extraction, relative path not used\n\n":             "2. Suche aus dem Dateinamen ist nach der Extraktion nicht leer, relativer Pfad wird nicht verwendet\n\n",
+		"2. Nothing left after season/episode/year extraction, using path relative to in dir %q\n": "2. Nach der Extraktion von Staffel/Episode/Jahr bleibt nichts übrig, Pfad relativ zum Eingabeverzeichnis %q wird verwendet\n",
+		"2b. No year, air date or season/episode in the query, using the release name in %s\n":     "2b. Kein Jahr, Ausstrahlungsdatum oder Staffel/Episode in der Suche, Release-Name in %s wird verwendet\n",
+		"3. Air date/season/episode/year extraction":                                               "3. Extraktion von Ausstrahlungsdatum/Staffel/Episode/Jahr",
+		"4. Common directory tokens":          "4. Gemeinsame Verzeichnis-Tokens",
+		"Aired order (default)":               "Ausstrahlungsreihenfolge (Standard)",
+		"Api requests: %d this run, %d today": "API-Anfragen: %d in diesem Lauf, %d heute",
+		"Apply? [yNsaq] (s: all remaining of this show or movie, a: all remaining, q: quit)": "Anwenden? [jNsaq] (s: alle übrigen dieser Serie oder dieses Films, a: alle übrigen, q: beenden)",
+		"Applying override for movie %d\n":                                                   "Überschreibung für Film %d wird angewendet\n",
+		"Applying override for tv show %d\n":                                                 "Überschreibung für Serie %d wird angewendet\n",
+		"Auto-selected %s (%s), score %.2f\n":                                                "Automatisch ausgewählt: %s (%s), Wertung %.2f\n",
+		"Auto-selected %s S%02dE%02d %s\n":                                                   "Automatisch ausgewählt: %s S%02dE%02d %s\n",
+		"Confirmed %d and corrected %d of %d auto matches\n":                                 "%d bestätigt und %d von %d automatischen Treffern korrigiert\n",
+		"Converted subtitle %s from %s to utf-8\n":                                           "Untertitel %s von %s nach utf-8 konvertiert\n",
+		"Copying to %s:\n":                                                                   "Kopieren nach %s:\n",
+		"Crc32 %s verified\n":                                                                "Crc32 %s geprüft\n",
+		"Current file name: %s\n":                                                            "Aktueller Dateiname: %s\n",
+		"Deferred to the next run: %d\n":                                                     "Auf den nächsten Lauf verschoben: %d\n",
+		"Deferring because the in-file is still being written":                               "Verschoben, da die Eingabedatei noch geschrieben wird",
+		"Deferring because the in-file was modified too recently":                            "Verschoben, da die Eingabedatei zu kürzlich geändert wurde",
+		"Edited %d manifest entries\n":                                                       "%d Manifest-Einträge bearbeitet\n",
+		"Episode %d is not in season %d of %s on moviedb.\n":                                 "Episode %d ist nicht in Staffel %d von %s auf moviedb.\n",
+		"Episode offset (empty for 0)":                                                       "Episodenversatz (leer für 0)",
+		"Error getting episode groups:":                                                      "Fehler beim Abrufen der Episodengruppen:",
+		"Error looking up %s: %s\n":                                                          "Fehler beim Nachschlagen von %s: %s\n",
+		"Extras, CSV of numbers, the other files are ignored (default: none)":                "Extras, kommagetrennte Nummern, die übrigen Dateien werden ignoriert (Standard: keine)",
+		"Failures:":                 "Fehler:",
+		"Fastest: %s at %s/s\n":     "Am schnellsten: %s mit %s/s\n",
+		"File: %s\n":                "Datei: %s\n",
+		"Files of %s (%s) in %s:\n": "Dateien von %s (%s) in %s:\n",
+		"Files: %d/%d, copied: %s":  "Dateien: %d/%d, kopiert: %s",
+		"Found %d directories without out files in %s\n":               "%d Verzeichnisse ohne Ausgabedateien in %s gefunden\n",
+		"Found %s (%s) by %s\n":                                        "%s (%s) über %s gefunden\n",
+		"Ignoring %s, it is neither the main feature nor an extra\n\n": "%s wird ignoriert, weder Hauptfilm noch Extra\n\n",
+		"In dir: %s\n\n":                        "Eingabeverzeichnis: %s\n\n",
+		"Keeping artwork %s\n":                  "Bild %s wird behalten\n",
+		"Keeping nfo file %s\n":                 "nfo-Datei %s wird behalten\n",
+		"Leaving %s, it was not placed by %s\n": "%s bleibt, es wurde nicht von %s abgelegt\n",
+		"Main feature [1-%d], or s to match each file separately (default: %d)": "Hauptfilm [1-%d], oder s, um jede Datei einzeln zuzuordnen (Standard: %d)",
+		"Manifest is empty, nothing to undo":                                    "Das Manifest ist leer, nichts rückgängig zu machen",
+		"Map release season %d to moviedb season (empty to skip)":               "Release-Staffel %d einer moviedb-Staffel zuordnen (leer zum Überspringen)",
+		"Matched %s (%s)":                                            "Zugeordnet: %s (%s)",
+		"Needs review:":                                              "Zu prüfen:",
+		"New file name (without extension)":                          "Neuer Dateiname (ohne Endung)",
+		"Next scan at %s\n":                                          "Nächster Scan um %s\n",
+		"No auto matches with a score below %.2f\n":                  "Keine automatischen Treffer mit einer Wertung unter %.2f\n",
+		"Not tagging out file metadata, it is a .strm file":          "Metadaten der Ausgabedatei werden nicht getaggt, sie ist eine .strm-Datei",
+		"Not tagging out file metadata, it is linked to the in file": "Metadaten der Ausgabedatei werden nicht getaggt, sie ist mit der Eingabedatei verknüpft",
+		"Note (default: %s)":                                         "Notiz (Standard: %s)",
+		"Note (empty for none)":                                      "Notiz (leer für keine)",
+		"Out file %s no longer exists\n":                             "Ausgabedatei %s existiert nicht mehr\n",
+		"Out file exists with different content, deciding at the end of the run":                     "Ausgabedatei existiert mit anderem Inhalt, Entscheidung am Ende des Laufs",
+		"Out file is used by a different movie with the same title and year (id %d), appending %q\n": "Ausgabedatei wird von einem anderen Film mit gleichem Titel und Jahr verwendet (Id %d), %q wird angehängt\n",
+		"Out file: %s\n":                                          "Ausgabedatei: %s\n",
+		"Placed: %d, skipped: %d, failed: %d\n":                   "Abgelegt: %d, übersprungen: %d, fehlgeschlagen: %d\n",
+		"Plan diff: %d changed, %d added, %d no longer planned\n": "Plan-Diff: %d geändert, %d hinzugefügt, %d nicht mehr geplant\n",
+		"Processed %d/%d files, copied %s in %s":                  "%d/%d Dateien verarbeitet, %s in %s kopiert",
+		"Pulled %d new entries from %s\n":                         "%d neue Einträge von %s geholt\n",
+		"Pushed %s to %s\n":                                       "%s nach %s übertragen\n",
+		"Rebased %d of %d manifest entries\n":                     "%d von %d Manifest-Einträgen umgestellt\n",
+		"Reclaimable: %s in %d directories\n":                     "Freizugeben: %s in %d Verzeichnissen\n",
+		"Recycled %s %s %s\n":                                     "Recycelt %s %s %s\n",
+		"Remaining files of %s in %s:\n":                          "Übrige Dateien von %s in %s:\n",
+		"Remove? [yNaq] (a: all remaining, q: quit)":              "Entfernen? [jNaq] (a: alle übrigen, q: beenden)",
+		"Removed: %s in %d directories\n":                         "Entfernt: %s in %d Verzeichnissen\n",
+		"Removing %s\n":                                           "%s wird entfernt\n",
+		"Same as before: %s (%s)\n":                               "Wie zuvor: %s (%s)\n",
+		"Saved copy defaults to %s\n":                             "Standardwerte zum Kopieren in %s gespeichert\n",
+		"Scanned %d directories":                                  "%d Verzeichnisse durchsucht",
+		"Searching %s\n":                                          "Suche in %s\n",
+		"Summed the size of %d directories":                       "Größe von %d Verzeichnissen summiert",
+		"Tags, CSV (default: %s)":                                 "Tags, kommagetrennt (Standard: %s)",
+		"Tags, CSV (empty for none)":                              "Tags, kommagetrennt (leer für keine)",
+		"The file name looks obfuscated, the query was built from its directory or nfo file. Use %s (%s)? [yN]": "Der Dateiname sieht verschleiert aus, die Suche wurde aus seinem Verzeichnis oder seiner nfo-Datei gebildet. %s (%s) verwenden? [jN]",
+		"Transient failure, will retry at the end of the run:":                                                  "Vorübergehender Fehler, neuer Versuch am Ende des Laufs:",
+		"Undid %d placements\n":                                                                    "%d Ablagen rückgängig gemacht\n",
+		"Undo %d placements? [yN]":                                                                 "%d Ablagen rückgängig machen? [jN]",
+		"Upgrade: ffprobe not found, skipping":                                                     "Upgrade: ffprobe nicht gefunden, wird übersprungen",
+		"Upgrade: unable to probe in file, skipping:":                                              "Upgrade: Eingabedatei konnte nicht analysiert werden, wird übersprungen:",
+		"Upgrade: unable to probe out file, skipping:":                                             "Upgrade: Ausgabedatei konnte nicht analysiert werden, wird übersprungen:",
+		"Use these %d episodes? [yN]":                                                              "Diese %d Episoden verwenden? [jN]",
+		"Using tokens common to files in this directory: %s\n":                                     "Gemeinsame Tokens der Dateien in diesem Verzeichnis werden verwendet: %s\n",
+		"Verified %d out files, %d failed\n":                                                       "%d Ausgabedateien geprüft, %d fehlgeschlagen\n",
+		"Warning: %d api requests today, approaching the daily limit of %d\n":                      "Warnung: %d API-Anfragen heute, das Tageslimit von %d ist fast erreicht\n",
+		"Warning: %d api requests today, over the daily limit of %d\n":                             "Warnung: %d API-Anfragen heute, über dem Tageslimit von %d\n",
+		"Warning: crc32 mismatch, file name says %s but file is %s, the download may be corrupt\n": "Warnung: crc32 stimmt nicht überein, der Dateiname nennt %s, die Datei hat %s, der Download ist eventuell beschädigt\n",
+		"Warning: moviedb year %s does not match file name year %s, using %s\n":                    "Warnung: moviedb-Jahr %s passt nicht zum Jahr %s im Dateinamen, %s wird verwendet\n",
+		"Writing %s of test data to %s\n":                                                          "%s Testdaten werden nach %s geschrieben\n",
+		"Writing nfo file %s\n":                                                                    "nfo-Datei %s wird geschrieben\n",
+		"[e] edit file name, enter to accept":                                                      "[e] Dateinamen bearbeiten, Enter zum Übernehmen",
+		"[o] overwrite all, [k] keep both for all, [s] skip all, [e] decide for each":              "[o] alle überschreiben, [k] bei allen beide behalten, [s] alle überspringen, [e] einzeln entscheiden",
+		"absolute episode: %d":                                                                     "absolute Episode: %d",
+		"air date: %s":                                                                             "Ausstrahlungsdatum: %s",
+		"check that the directory exists and is readable by this user":                             "prüfen, ob das Verzeichnis existiert und für diesen Benutzer lesbar ist",
+		"check that the directory is writable by this user":                                        "prüfen, ob das Verzeichnis für diesen Benutzer beschreibbar ist",
+		"check the network connection":                                                             "Netzwerkverbindung prüfen",
+		"check the value of -api-key (%s)":                                                         "Wert von -api-key prüfen (%s)",
+		"compare conflicting files and use media info in templates":                                "Dateien in Konflikt vergleichen und Medieninfos in Vorlagen verwenden",
+		"directed by %s":   "Regie: %s",
+		"disk space of %s": "Speicherplatz von %s",
+		"extract rar releases, only extracted video files are placed": "rar-Releases entpacken, nur entpackte Videodateien werden abgelegt",
+		"fail": "Fehler",
+		"fix or move the manifest file, a backup may be pulled with the manifest command": "Manifest-Datei reparieren oder verschieben, eine Sicherung lässt sich mit dem Befehl manifest holen",
+		"free space or use -mv on the same file system":                                   "Speicher freigeben oder -mv auf demselben Dateisystem verwenden",
+		"hardlinks to %s": "Hardlinks nach %s",
+		"in and out dirs are on different file systems, files are copied and seeding falls back to symlinks": "Ein- und Ausgabeverzeichnis liegen auf verschiedenen Dateisystemen, Dateien werden kopiert und Seeding weicht auf Symlinks aus",
+		"in and out dirs are on different file systems, use -link %s to copy files that can't be linked":     "Ein- und Ausgabeverzeichnis liegen auf verschiedenen Dateisystemen, -link %s kopiert Dateien, die nicht verknüpft werden können",
+		"in dir %s":        "Eingabeverzeichnis %s",
+		"install it to %s": "installieren, um %s",
+		"invalid":          "ungültig",
+		"it is merged into the manifest by the next run": "der nächste Lauf führt es ins Manifest zusammen",
+		"manifest %s": "Manifest %s",
+		"missing out files are placed again on the next run unless their in files are gone": "fehlende Ausgabedateien werden beim nächsten Lauf erneut abgelegt, sofern ihre Eingabedateien noch existieren",
+		"movie":                          "der Film",
+		"not checked, no in files found": "nicht geprüft, keine Eingabedateien gefunden",
+		"not found in PATH":              "nicht im PATH gefunden",
+		"not set":                        "nicht gesetzt",
+		"ok":                             "ok",
+		"out dir %s":                     "Ausgabeverzeichnis %s",
+		"readable":                       "lesbar",
+		"set -api-key, or api_keys in the config file (%s)": "-api-key setzen, oder api_keys in der Konfigurationsdatei (%s)",
+		"set -provider to moviedb or tvdb":                  "-provider auf moviedb oder tvdb setzen",
+		"tv show":                                           "die Serie",
+		"warn":                                              "Warnung",
+		"work":                                              "funktionieren",
+		"works":                                             "funktioniert",
+		"writable":                                          "beschreibbar",
+	},
+	"fr": {
+		"y":                           "o",
+		"Move":                        "Déplacer",
+		"Copy":                        "Copier",
+		"Link":                        "Lier",
+		"Stream":                      "Diffuser",
+		"to":                          "vers",
+		"%s? [yN]":                    "%s ? [oN]",
+		"Movie":                       "Film",
+		"Tv show":                     "Série",
+		"Episode":                     "Épisode",
+		"%s query (page %d/%d): %s\n": "Recherche %s (page %d/%d) : %s\n",
+		"%s query: %s\n":              "Recherche %s : %s\n",
+		"year: %d":                    "année : %d",
+		"season: %d":                  "saison : %d",
+		"episode: %d":                 "épisode : %d",
+		"No results!":                 "Aucun résultat !",
+		"(default: %s)":               "(par défaut : %s)",
+		"1 select":                    "1 sélectionner",
+		"1-%d select":                 "1-%d sélectionner",
+		"default (empty string) select choice %d": "par défaut (saisie vide) sélectionne le choix %d",
+		"q quit":                                "q quitter",
+		"s skip":                                "s passer",
+		"h this help":                           "h cette aide",
+		"p next page of results (if available)": "p page de résultats suivante (si disponible)",
+		"g choose episode group (dvd, absolute order) of tv show":           "g choisir le groupe d'épisodes (dvd, ordre absolu) de la série",
+		"N-M select episodes N to M of a multi-episode file":                "N-M sélectionner les épisodes N à M d'un fichier à plusieurs épisodes",
+		"any other text is new query":                                       "tout autre texte est une nouvelle recherche",
+		"Invalid selection:":                                                "Sélection invalide :",
+		"Invalid tv season selection:":                                      "Sélection de saison invalide :",
+		"Unable to extract season number from query string.":                "Impossible d'extraire le numéro de saison de la recherche.",
+		"Please select one of the listed options.":                          "Veuillez choisir une des options proposées.",
+		"Error selecting tv show based on previous query:":                  "Erreur de sélection de la série d'après la recherche précédente :",
+		"Error searching movies:":                                           "Erreur lors de la recherche de films :",
+		"Error searching tv shows:":                                         "Erreur lors de la recherche de séries :",
+		"Skipping because we've seen this in-file before":                   "Ignoré car ce fichier a déjà été traité",
+		"In file and out file are the same path":                            "Les fichiers d'entrée et de sortie ont le même chemin",
+		"Out file exists and is same content as in file, updating manifest": "Le fichier de sortie existe avec le même contenu, mise à jour du manifeste",
+		"Out file exists and has different content as in file!":             "Le fichier de sortie existe avec un contenu différent !",
+		"In: ":                          "Entrée : ",
+		"Out:":                          "Sortie :",
+		"     Size: %s, modified: %s\n": "     Taille : %s, modifié : %s\n",
+		"     Media: %s\n":              "     Média : %s\n",
+		"Conflict policy %s: %s\n":      "Règle de conflit %s : %s\n",
+		"Upgrade: %s, replacing\n":      "Amélioration : %s, remplacement\n",
+		"Upgrade: %s, skipping\n":       "Amélioration : %s, ignoré\n",
+		"\nGoodbye!\n":                  "\nAu revoir !\n",
+		"\n%d out files already exist with different content:\n":                                       "\n%d fichiers de sortie existent déjà avec un contenu différent :\n",
+		"\nRetrying %d failed files in %s (attempt %d/%d)\n":                                           "\nNouvel essai de %d fichiers en échec dans %s (tentative %d/%d)\n",
+		"\nStop word candidates (in at least %g%% of %d files):\n%s\n":                                 "\nCandidats mots vides (dans au moins %g%% de %d fichiers) :\n%s\n",
+		"    %-20s dropped (imdb/tmdb id)\n":                                                           "    %-20s écarté (id imdb/tmdb)\n",
+		"    %-20s dropped (single character)\n":                                                       "    %-20s écarté (caractère unique)\n",
+		"    %-20s dropped (stop word)\n":                                                              "    %-20s écarté (mot vide)\n",
+		"    %-20s kept\n":                                                                             "    %-20s conservé\n",
+		"  %s found in the file name, nfo or torrent, the %s is looked up by it instead of searched\n": "  %s trouvé dans le nom de fichier, le nfo ou le torrent, consulté par cet id au lieu d'une recherche (%s)\n",
+		"  absolute episode found, searching tv shows and mapping it to season and episode\n\n":        "  épisode absolu trouvé, recherche de séries et conversion en saison et épisode\n\n",
+		"  absolute episode: %d\n":                                                                     "  épisode absolu : %d\n",
+		"  air date found, searching tv shows and the episode aired that day\n\n":                      "  date de diffusion trouvée, recherche de séries et de l'épisode diffusé ce jour-là\n\n",
+		"  air date: %s\n":            "  date de diffusion : %s\n",
+		"  disabled by no-common-dir": "  désactivé par no-common-dir",
+		"  matches need to be confirmed, batch mode leaves the file for review\n\n": "  les correspondances doivent être confirmées, le mode batch laisse le fichier à vérifier\n\n",
+		"  no season/episode found, searching movies\n\n":                           "  aucune saison/épisode trouvé, recherche de films\n\n",
+		"  none, file query is always used":                                         "  aucun, la recherche du fichier est toujours utilisée",
+		"  query: %q\n":                                                             "  recherche : %q\n",
+		"  query: %q\n\n":                                                           "  recherche : %q\n\n",
+		"  raw tokens: %s\n":                                                        "  tokens bruts : %s\n",
+		"  scope: %s, minimum peers: %d\n":                                          "  portée : %s, minimum de fichiers voisins : %d\n",
+		"  season/episode found, searching tv shows\n\n":                            "  saison/épisode trouvé, recherche de séries\n\n",
+		"  season: %d, episode: %d, year: %d\n":                                     "  saison : %d, épisode : %d, année : %d\n",
+		"  tokens shared with peer files in %s: %q\n":                               "  tokens partagés avec les fichiers voisins dans %s : %q\n",
+		"  used as tv show query when switching seasons without a manual query":     "  utilisé comme recherche de série lors d'un changement de saison sans recherche manuelle",
+		" of %d":                                      " sur %d",
+		"%2d %s (%d episodes, %d groups)%s\n":         "%2d %s (%d épisodes, %d groupes)%s\n",
+		"%d entries":                                  "%d entrées",
+		"%d files need attention\n":                   "%d fichiers nécessitent votre attention\n",
+		"%d manifest entries\n":                       "%d entrées du manifeste\n",
+		"%s (%s, %d files, newest %s)":                "%s (%s, %d fichiers, plus récent %s)",
+		"%s api key":                                  "clé d'api %s",
+		"%s free, in files are %s":                    "%s libres, les fichiers d'entrée font %s",
+		"%s, %d out files no longer exist":            "%s, %d fichiers de sortie n'existent plus",
+		"%s, journal of an interrupted run left over": "%s, journal d'une exécution interrompue restant",
+		"%s, retrying in %s (attempt %d/%d)\n":        "%s, nouvel essai dans %s (tentative %d/%d)\n",
+		"%s: checksum is %s, expected %s\n":           "%s : la somme de contrôle est %s, %s attendu\n",
+		", remaining: %s":                             ", restant : %s",
+		", throughput: %s/s":                          ", débit : %s/s",
+		"1. Tokens from file name %q\n":               "1. Tokens du nom de fichier %q\n",
+		"2. File name looks obfuscated, using the directory name or the title of an nfo file\n":    "2. Le nom de fichier semble obscurci, utilisation du nom du répertoire ou du titre d'un fichier nfo\n",
+		"2. File name query is not empty after extraction, relative path not used\n\n":             "2. La recherche du nom de fichier n'est pas vide après l'extraction, chemin relatif non utilisé\n\n",
+		"2. Nothing left after season/episode/year extraction, using path relative to in dir %q\n": "2. Rien ne reste après l'extraction de saison/épisode/année, utilisation du chemin relatif au répertoire d'entrée %q\n",
+		"2b. No year, air date or season/episode in the query, using the release name in %s\n":     "2b. Pas d'année, de date de diffusion ni de saison/épisode dans la recherche, utilisation du nom de release dans %s\n",
+		"3. Air date/season/episode/year extraction":                                               "3. Extraction de date de diffusion/saison/épisode/année",
+		"4. Common directory tokens":          "4. Tokens communs du répertoire",
+		"Aired order (default)":               "Ordre de diffusion (par défaut)",
+		"Api requests: %d this run, %d today": "Requêtes api : %d pendant cette exécution, %d aujourd'hui",
+		"Apply? [yNsaq] (s: all remaining of this show or movie, a: all remaining, q: quit)": "Appliquer ? [oNsaq] (s : tous les restants de cette série ou de ce film, a : tous les restants, q : quitter)",
+		"Applying override for movie %d\n":                                                   "Application de la correction pour le film %d\n",
+		"Applying override for tv show %d\n":                                                 "Application de la correction pour la série %d\n",
+		"Auto-selected %s (%s), score %.2f\n":                                                "Sélection automatique de %s (%s), score %.2f\n",
+		"Auto-selected %s S%02dE%02d %s\n":                                                   "Sélection automatique de %s S%02dE%02d %s\n",
+		"Confirmed %d and corrected %d of %d auto matches\n":                                 "%d confirmées et %d corrigées sur %d correspondances automatiques\n",
+		"Converted subtitle %s from %s to utf-8\n":                                           "Sous-titre %s converti de %s en utf-8\n",
+		"Copying to %s:\n":                                                                   "Copie vers %s :\n",
+		"Crc32 %s verified\n":                                                                "Crc32 %s vérifié\n",
+		"Current file name: %s\n":                                                            "Nom de fichier actuel : %s\n",
+		"Deferred to the next run: %d\n":                                                     "Reportés à la prochaine exécution : %d\n",
+		"Deferring because the in-file is still being written":                               "Reporté car le fichier d'entrée est encore en cours d'écriture",
+		"Deferring because the in-file was modified too recently":                            "Reporté car le fichier d'entrée a été modifié trop récemment",
+		"Edited %d manifest entries\n":                                                       "%d entrées du manifeste modifiées\n",
+		"Episode %d is not in season %d of %s on moviedb.\n":                                 "L'épisode %d n'est pas dans la saison %d de %s sur moviedb.\n",
+		"Episode offset (empty for 0)":                                                       "Décalage d'épisodes (vide pour 0)",
+		"Error getting episode groups:":                                                      "Erreur lors de la récupération des groupes d'épisodes :",
+		"Error looking up %s: %s\n":                                                          "Erreur lors de la consultation de %s : %s\n",
+		"Extras, CSV of numbers, the other files are ignored (default: none)":                "Bonus, numéros séparés par des virgules, les autres fichiers sont ignorés (par défaut : aucun)",
+		"Failures:":                 "Échecs :",
+		"Fastest: %s at %s/s\n":     "Le plus rapide : %s à %s/s\n",
+		"File: %s\n":                "Fichier : %s\n",
+		"Files of %s (%s) in %s:\n": "Fichiers de %s (%s) dans %s :\n",
+		"Files: %d/%d, copied: %s":  "Fichiers : %d/%d, copié : %s",
+		"Found %d directories without out files in %s\n":               "%d répertoires sans fichiers de sortie trouvés dans %s\n",
+		"Found %s (%s) by %s\n":                                        "%s (%s) trouvé par %s\n",
+		"Ignoring %s, it is neither the main feature nor an extra\n\n": "%s ignoré, ce n'est ni le film principal ni un bonus\n\n",
+		"In dir: %s\n\n":                        "Répertoire d'entrée : %s\n\n",
+		"Keeping artwork %s\n":                  "Image %s conservée\n",
+		"Keeping nfo file %s\n":                 "Fichier nfo %s conservé\n",
+		"Leaving %s, it was not placed by %s\n": "%s laissé, il n'a pas été placé par %s\n",
+		"Main feature [1-%d], or s to match each file separately (default: %d)": "Film principal [1-%d], ou s pour associer chaque fichier séparément (par défaut : %d)",
+		"Manifest is empty, nothing to undo":                                    "Le manifeste est vide, rien à annuler",
+		"Map release season %d to moviedb season (empty to skip)":               "Associer la saison %d de la release à la saison moviedb (vide pour passer)",
+		"Matched %s (%s)":                                            "Correspondance %s (%s)",
+		"Needs review:":                                              "À vérifier :",
+		"New file name (without extension)":                          "Nouveau nom de fichier (sans extension)",
+		"Next scan at %s\n":                                          "Prochaine analyse à %s\n",
+		"No auto matches with a score below %.2f\n":                  "Aucune correspondance automatique avec un score inférieur à %.2f\n",
+		"Not tagging out file metadata, it is a .strm file":          "Les métadonnées du fichier de sortie ne sont pas écrites, c'est un fichier .strm",
+		"Not tagging out file metadata, it is linked to the in file": "Les métadonnées du fichier de sortie ne sont pas écrites, il est lié au fichier d'entrée",
+		"Note (default: %s)":                                         "Note (par défaut : %s)",
+		"Note (empty for none)":                                      "Note (vide pour aucune)",
+		"Out file %s no longer exists\n":                             "Le fichier de sortie %s n'existe plus\n",
+		"Out file exists with different content, deciding at the end of the run":                     "Le fichier de sortie existe avec un contenu différent, décision à la fin de l'exécution",
+		"Out file is used by a different movie with the same title and year (id %d), appending %q\n": "Le fichier de sortie est utilisé par un autre film de même titre et année (id %d), ajout de %q\n",
+		"Out file: %s\n":                                          "Fichier de sortie : %s\n",
+		"Placed: %d, skipped: %d, failed: %d\n":                   "Placés : %d, ignorés : %d, en échec : %d\n",
+		"Plan diff: %d changed, %d added, %d no longer planned\n": "Différences du plan : %d modifiés, %d ajoutés, %d plus prévus\n",
+		"Processed %d/%d files, copied %s in %s":                  "%d/%d fichiers traités, %s copiés en %s",
+		"Pulled %d new entries from %s\n":                         "%d nouvelles entrées récupérées depuis %s\n",
+		"Pushed %s to %s\n":                                       "%s envoyé vers %s\n",
+		"Rebased %d of %d manifest entries\n":                     "%d entrées du manifeste sur %d déplacées\n",
+		"Reclaimable: %s in %d directories\n":                     "Récupérable : %s dans %d répertoires\n",
+		"Recycled %s %s %s\n":                                     "Recyclé %s %s %s\n",
+		"Remaining files of %s in %s:\n":                          "Fichiers restants de %s dans %s :\n",
+		"Remove? [yNaq] (a: all remaining, q: quit)":              "Supprimer ? [oNaq] (a : tous les restants, q : quitter)",
+		"Removed: %s in %d directories\n":                         "Supprimé : %s dans %d répertoires\n",
+		"Removing %s\n":                                           "Suppression de %s\n",
+		"Same as before: %s (%s)\n":                               "Comme avant : %s (%s)\n",
+		"Saved copy defaults to %s\n":                             "Valeurs de copie par défaut enregistrées dans %s\n",
+		"Scanned %d directories":                                  "%d répertoires analysés",
+		"Searching %s\n":                                          "Recherche dans %s\n",
+		"Summed the size of %d directories":                       "Taille de %d répertoires additionnée",
+		"Tags, CSV (default: %s)":                                 "Étiquettes, séparées par des virgules (par défaut : %s)",
+		"Tags, CSV (empty for none)":                              "Étiquettes, séparées par des virgules (vide pour aucune)",
+		"The file name looks obfuscated, the query was built from its directory or nfo file. Use %s (%s)? [yN]": "Le nom de fichier semble obscurci, la recherche a été construite à partir de son répertoire ou de son fichier nfo. Utiliser %s (%s) ? [oN]",
+		"Transient failure, will retry at the end of the run:":                                                  "Échec temporaire, nouvel essai à la fin de l'exécution :",
+		"Undid %d placements\n":                                                                    "%d placements annulés\n",
+		"Undo %d placements? [yN]":                                                                 "Annuler %d placements ? [oN]",
+		"Upgrade: ffprobe not found, skipping":                                                     "Amélioration : ffprobe introuvable, ignoré",
+		"Upgrade: unable to probe in file, skipping:":                                              "Amélioration : impossible d'analyser le fichier d'entrée, ignoré :",
+		"Upgrade: unable to probe out file, skipping:":                                             "Amélioration : impossible d'analyser le fichier de sortie, ignoré :",
+		"Use these %d episodes? [yN]":                                                              "Utiliser ces %d épisodes ? [oN]",
+		"Using tokens common to files in this directory: %s\n":                                     "Utilisation des tokens communs aux fichiers de ce répertoire : %s\n",
+		"Verified %d out files, %d failed\n":                                                       "%d fichiers de sortie vérifiés, %d en échec\n",
+		"Warning: %d api requests today, approaching the daily limit of %d\n":                      "Avertissement : %d requêtes api aujourd'hui, proche de la limite quotidienne de %d\n",
+		"Warning: %d api requests today, over the daily limit of %d\n":                             "Avertissement : %d requêtes api aujourd'hui, au-delà de la limite quotidienne de %d\n",
+		"Warning: crc32 mismatch, file name says %s but file is %s, the download may be corrupt\n": "Avertissement : crc32 différent, le nom de fichier indique %s mais le fichier est %s, le téléchargement est peut-être corrompu\n",
+		"Warning: moviedb year %s does not match file name year %s, using %s\n":                    "Avertissement : l'année moviedb %s ne correspond pas à l'année %s du nom de fichier, utilisation de %s\n",
+		"Writing %s of test data to %s\n":                                                          "Écriture de %s de données de test dans %s\n",
+		"Writing nfo file %s\n":                                                                    "Écriture du fichier nfo %s\n",
+		"[e] edit file name, enter to accept":                                                      "[e] modifier le nom de fichier, entrée pour accepter",
+		"[o] overwrite all, [k] keep both for all, [s] skip all, [e] decide for each":              "[o] tout écraser, [k] garder les deux pour tous, [s] tout ignorer, [e] décider pour chacun",
+		"absolute episode: %d":                                                                     "épisode absolu : %d",
+		"air date: %s":                                                                             "date de diffusion : %s",
+		"check that the directory exists and is readable by this user":                             "vérifiez que le répertoire existe et est lisible par cet utilisateur",
+		"check that the directory is writable by this user":                                        "vérifiez que le répertoire est accessible en écriture par cet utilisateur",
+		"check the network connection":                                                             "vérifiez la connexion réseau",
+		"check the value of -api-key (%s)":                                                         "vérifiez la valeur de -api-key (%s)",
+		"compare conflicting files and use media info in templates":                                "comparer les fichiers en conflit et utiliser les infos média dans les modèles",
+		"directed by %s":   "réalisé par %s",
+		"disk space of %s": "espace disque de %s",
+		"extract rar releases, only extracted video files are placed": "extraire les releases rar, seuls les fichiers vidéo extraits sont placés",
+		"fail": "échec",
+		"fix or move the manifest file, a backup may be pulled with the manifest command": "corrigez ou déplacez le fichier manifeste, une sauvegarde peut être récupérée avec la commande manifest",
+		"free space or use -mv on the same file system":                                   "libérez de l'espace ou utilisez -mv sur le même système de fichiers",
+		"hardlinks to %s": "liens physiques vers %s",
+		"in and out dirs are on different file systems, files are copied and seeding falls back to symlinks": "les répertoires d'entrée et de sortie sont sur des systèmes de fichiers différents, les fichiers sont copiés et le seeding utilise des liens symboliques",
+		"in and out dirs are on different file systems, use -link %s to copy files that can't be linked":     "les répertoires d'entrée et de sortie sont sur des systèmes de fichiers différents, utilisez -link %s pour copier les fichiers qui ne peuvent pas être liés",
+		"in dir %s":        "répertoire d'entrée %s",
+		"install it to %s": "installez-le pour %s",
+		"invalid":          "invalide",
+		"it is merged into the manifest by the next run": "la prochaine exécution le fusionne dans le manifeste",
+		"manifest %s": "manifeste %s",
+		"missing out files are placed again on the next run unless their in files are gone": "les fichiers de sortie manquants sont replacés à la prochaine exécution sauf si leurs fichiers d'entrée ont disparu",
+		"movie":                          "film",
+		"not checked, no in files found": "non vérifié, aucun fichier d'entrée trouvé",
+		"not found in PATH":              "introuvable dans le PATH",
+		"not set":                        "non définie",
+		"ok":                             "ok",
+		"out dir %s":                     "répertoire de sortie %s",
+		"readable":                       "lisible",
+		"set -api-key, or api_keys in the config file (%s)": "définissez -api-key, ou api_keys dans le fichier de configuration (%s)",
+		"set -provider to moviedb or tvdb":                  "définissez -provider à moviedb ou tvdb",
+		"tv show":                                           "série",
+		"warn":                                              "avert.",
+		"work":                                              "fonctionnent",
+		"works":                                             "fonctionne",
+		"writable":                                          "accessible en écriture",
+	},
+}
+
+var messageCatalog map[string]string
+
+// detectLanguage returns the two letter language code from
+// the LC_ALL, LC_MESSAGES or LANG environment variables
+func detectLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return "en"
+}
+
+// setLanguage selects the message catalog, ie. "de", "de_DE" or "de_DE.UTF-8"
+func setLanguage(lang string) {
+	code := strings.ToLower(lang)
+	if len(code) > 2 {
+		code = code[:2]
+	}
+	messageCatalog = messageCatalogs[code]
+}
+
+// tr returns the translation of msg in the current language, or msg itself
+func tr(msg string) string {
+	if translated, ok := messageCatalog[msg]; ok {
+		return translated
+	}
+	return msg
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+var formatVerbReg = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+func TestMessageCatalogs(t *testing.T) {
+	reference := messageCatalogs["es"]
+	for lang, catalog := range messageCatalogs {
+		if len(catalog) != len(reference) {
+			t.Errorf("%s catalog has %d messages, es has %d", lang, len(catalog), len(reference))
+		}
+		for msg, translated := range catalog {
+			if _, ok := reference[msg]; !ok {
+				t.Errorf("%s message %q is missing from the es catalog", lang, msg)
+			}
+			// the arguments are passed in the order of the english message
+			want, got := formatVerbReg.FindAllString(msg, -1), formatVerbReg.FindAllString(translated, -1)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s translation of %q has verbs %v, want %v", lang, msg, got, want)
+			}
+		}
+	}
+}
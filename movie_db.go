@@ -2,12 +2,14 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,6 +18,8 @@ var (
 	urlBase   = "https://api.themoviedb.org"
 )
 
+var ErrInvalidApiKey = errors.New("invalid api key")
+
 type cacheResult struct {
 	body      []byte
 	createdAt time.Time
@@ -23,9 +27,14 @@ type cacheResult struct {
 
 type MovieDb struct {
 	ApiKey                string
+	Language              string
 	Client                http.Client
 	cache                 map[string]cacheResult
 	cacheRetensionSeconds float64
+	cacheMutex            sync.Mutex
+	inflight              map[string]chan struct{}
+	tvCache               *tvCache
+	configuration         *Configuration
 }
 
 type Media interface {
@@ -33,10 +42,26 @@ type Media interface {
 	GetName() string
 	GetDate() string
 	GetOverview() string
+	GetYear() string
 	GetPath() string
 	GetType() string
 }
 
+type ProductionCountry struct {
+	Iso31661 string `json:"iso_3166_1"`
+	Name     string `json:"name"`
+}
+
+// Genre is only part of movie and tv details, search results have genre ids
+type Genre struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func yearFromDate(date string) string {
+	return strings.Split(date, "-")[0]
+}
+
 type Movie struct {
 	Id               int64   `json:"id"`
 	Title            string  `json:"title"`
@@ -52,6 +77,13 @@ type Movie struct {
 	Adult            bool    `json:"adult"`
 	Overview         string  `json:"overview"`
 	PosterPath       string  `json:"poster_path"`
+	ImdbId           string  `json:"imdb_id"`
+	Runtime          int     `json:"runtime"`
+	Genres           []Genre `json:"genres"`
+	PathYear         string  `json:"-"`
+	PathFolder       string  `json:"-"`
+
+	ProductionCountries []ProductionCountry `json:"production_countries"`
 }
 
 func (m Movie) GetId() int64 {
@@ -70,10 +102,20 @@ func (m Movie) GetOverview() string {
 	return m.Overview
 }
 
+func (m Movie) GetYear() string {
+	if m.PathYear != "" {
+		return m.PathYear
+	}
+	return yearFromDate(m.ReleaseDate)
+}
+
 func (m Movie) GetPath() string {
-	dateParts := strings.Split(m.ReleaseDate, "-")
-	year := dateParts[0]
-	return fmt.Sprintf("%s (%s)/%s (%s)", m.Title, year, m.Title, year)
+	year := m.GetYear()
+	folder := m.PathFolder
+	if folder == "" {
+		folder = fmt.Sprintf("%s (%s)", m.Title, year)
+	}
+	return fmt.Sprintf("%s/%s (%s)", folder, m.Title, year)
 }
 
 func (m Movie) GetType() string {
@@ -94,6 +136,9 @@ type Tv struct {
 	OriginCountry    []string `json:"origin_country"`
 	GenreIds         []int    `json:"genre_ids"`
 	OriginalLanguage string   `json:"original_language"`
+	NumberOfSeasons  int      `json:"number_of_seasons"`
+	EpisodeRunTime   []int    `json:"episode_run_time"`
+	Genres           []Genre  `json:"genres"`
 }
 
 func (m Tv) GetId() int64 {
@@ -112,6 +157,10 @@ func (m Tv) GetOverview() string {
 	return m.Overview
 }
 
+func (m Tv) GetYear() string {
+	return yearFromDate(m.FirstAirDate)
+}
+
 func (m Tv) GetPath() string {
 	panic("No path for tv")
 }
@@ -150,9 +199,17 @@ type TvEpisode struct {
 	StillPath      string  `json:"still_path"`
 	VoteAverage    float64 `json:"vote_average"`
 	VoteCount      int     `json:"vote_count"`
+	TvId           int64
 	TvName         string
+	TvOriginalName string
 	SeasonName     string
 	FirstAirDate   string
+	PathYear       string
+	PathFolder     string
+	SeasonOffset   int
+	EpisodeOffset  int
+	LastEpisode    int
+	ExtraIds       []int64
 }
 
 func (m TvEpisode) GetId() int64 {
@@ -171,16 +228,56 @@ func (m TvEpisode) GetOverview() string {
 	return m.Overview
 }
 
+func (m TvEpisode) GetYear() string {
+	if m.PathYear != "" {
+		return m.PathYear
+	}
+	return yearFromDate(m.FirstAirDate)
+}
+
 func (m TvEpisode) GetPath() string {
-	dateParts := strings.Split(m.FirstAirDate, "-")
-	year := dateParts[0]
-	return fmt.Sprintf("%s (%s)/%s (%s) S%02dE%02d", m.TvName, year, m.TvName, year, m.SeasonNumber, m.EpisonNumber)
+	year := m.GetYear()
+	folder := m.PathFolder
+	if folder == "" {
+		folder = fmt.Sprintf("%s (%s)", m.TvName, year)
+	}
+	episodes := fmt.Sprintf("S%02dE%02d", m.SeasonNumber+m.SeasonOffset, m.EpisonNumber+m.EpisodeOffset)
+	if m.LastEpisode > m.EpisonNumber {
+		episodes += fmt.Sprintf("-E%02d", m.LastEpisode+m.EpisodeOffset)
+	}
+	return fmt.Sprintf("%s/%s (%s) %s", folder, m.TvName, year, episodes)
 }
 
 func (m TvEpisode) GetType() string {
 	return "tv_episode"
 }
 
+// EpisodeGroup is an alternative episode order of a tv show, ie. dvd or absolute order
+type EpisodeGroup struct {
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Type         int    `json:"type"`
+	EpisodeCount int    `json:"episode_count"`
+	GroupCount   int    `json:"group_count"`
+}
+
+type EpisodeGroupsResponse struct {
+	Id      int64          `json:"id"`
+	Results []EpisodeGroup `json:"results"`
+}
+
+type EpisodeGroupDetails struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Groups []struct {
+		Id       string      `json:"id"`
+		Name     string      `json:"name"`
+		Order    int         `json:"order"`
+		Episodes []TvEpisode `json:"episodes"`
+	} `json:"groups"`
+}
+
 type SearchMovieResponse struct {
 	Page         int     `json:"page"`
 	Results      []Movie `json:"results"`
@@ -217,12 +314,15 @@ func NewMovieDb(apiKey string) *MovieDb {
 		Client: http.Client{
 			Timeout: time.Second * 5,
 		},
-		cache: make(map[string]cacheResult),
+		cache:                 make(map[string]cacheResult),
 		cacheRetensionSeconds: 60.0,
+		inflight:              make(map[string]chan struct{}),
+		tvCache:               newTvCache(),
 	}
 }
 
 func (c *MovieDb) cacheGet(key, url string) ([]byte, error) {
+	c.cacheMutex.Lock()
 	keys := make([]string, len(c.cache))
 	for k := range c.cache {
 		keys = append(keys, k)
@@ -236,9 +336,71 @@ func (c *MovieDb) cacheGet(key, url string) ([]byte, error) {
 	}
 
 	if cacheResult, ok := c.cache[key]; ok {
+		c.cacheMutex.Unlock()
 		return cacheResult.body, nil
 	}
 
+	// wait for a prefetch of the same request instead of repeating it
+	if wait, ok := c.inflight[key]; ok {
+		c.cacheMutex.Unlock()
+		<-wait
+		return c.cacheGet(key, url)
+	}
+	done := make(chan struct{})
+	c.inflight[key] = done
+	c.cacheMutex.Unlock()
+
+	responseBody, err := c.get(url)
+
+	c.cacheMutex.Lock()
+	delete(c.inflight, key)
+	if err == nil {
+		c.cache[key] = cacheResult{responseBody, time.Now()}
+	}
+	c.cacheMutex.Unlock()
+	close(done)
+
+	return responseBody, err
+}
+
+// PrefetchTvSeasons fetches seasons of tv one after the other in the
+// background, throttled by season-fetch-rate, so that switching to
+// them later is served from the cache
+func (c *MovieDb) PrefetchTvSeasons(tv Tv, seasonNumbers ...int) {
+	go func() {
+		for _, seasonNumber := range seasonNumbers {
+			if seasonNumber < 1 || seasonNumber > tv.NumberOfSeasons {
+				continue
+			}
+			if _, ok := c.tvCache.getSeason(tv.Id, seasonNumber); ok {
+				continue
+			}
+			seasonFetchLimiter.Wait()
+			c.GetTvSeason(tv, seasonNumber)
+		}
+	}()
+}
+
+// get sends a request in the metadata language, throttled and retried by
+// withApiRetry
+func (c *MovieDb) get(url string) ([]byte, error) {
+	var response []byte
+	if c.Language != "" {
+		var err error
+		url, err = withLanguage(url, c.Language)
+		if err != nil {
+			return response, err
+		}
+	}
+	err := withApiRetry(func() error {
+		var err error
+		response, err = c.send(url)
+		return err
+	})
+	return response, err
+}
+
+func (c *MovieDb) send(url string) ([]byte, error) {
 	response := []byte{}
 
 	req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -248,22 +410,34 @@ func (c *MovieDb) cacheGet(key, url string) ([]byte, error) {
 
 	req.Header.Set("User-Agent", userAgent)
 
+	apiUsage.Count()
 	res, err := c.Client.Do(req)
 	if err != nil {
 		return response, err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return response, fmt.Errorf("API request error (%s)\n", res.Status)
+		body, _ := ioutil.ReadAll(res.Body)
+		return response, newMovieDbError(req, res, body)
 	}
 
-	responseBody, err := ioutil.ReadAll(res.Body)
+	return ioutil.ReadAll(res.Body)
+}
+
+func (c *MovieDb) Name() string {
+	return movieDbProvider
+}
+
+// Ping checks that the api is reachable and the api key is accepted
+func (c *MovieDb) Ping() error {
+	url, err := configurationUrl(c.ApiKey)
 	if err != nil {
-		return response, err
+		return err
 	}
 
-	c.cache[key] = cacheResult{responseBody, time.Now()}
-	return responseBody, nil
+	_, err = c.get(url)
+	return err
 }
 
 func (c *MovieDb) SearchMovie(query string, page, year int) (SearchMovieResponse, error) {
@@ -318,6 +492,10 @@ func (c *MovieDb) GetMovie(movieId int64) (Movie, error) {
 }
 
 func (c *MovieDb) GetTv(tvId int64) (Tv, error) {
+	if tv, ok := c.tvCache.getTv(tvId); ok {
+		return tv, nil
+	}
+
 	tv := Tv{}
 
 	url, err := tvUrl(c.ApiKey, tvId)
@@ -331,10 +509,17 @@ func (c *MovieDb) GetTv(tvId int64) (Tv, error) {
 	}
 
 	err = json.Unmarshal(body, &tv)
+	if err == nil {
+		c.tvCache.putTv(tv)
+	}
 	return tv, err
 }
 
 func (c *MovieDb) GetTvSeason(tv Tv, seasonNumber int) (TvSeason, error) {
+	if tvSeason, ok := c.tvCache.getSeason(tv.Id, seasonNumber); ok {
+		return tvSeason, nil
+	}
+
 	tvSeason := TvSeason{}
 
 	url, err := tvSeasonUrl(c.ApiKey, tv.Id, seasonNumber)
@@ -357,16 +542,198 @@ func (c *MovieDb) GetTvSeason(tv Tv, seasonNumber int) (TvSeason, error) {
 	episodes := make([]TvEpisode, len(tvSeason.Episodes))
 	for i := 0; i < len(tvSeason.Episodes); i++ {
 		episode := tvSeason.Episodes[i]
+		episode.TvId = tv.Id
 		episode.TvName = tv.Name
+		episode.TvOriginalName = tv.OriginalName
 		episode.SeasonName = tvSeason.Name
 		episode.FirstAirDate = tv.FirstAirDate
 		episodes[i] = episode
 	}
 	tvSeason.Episodes = episodes
+	c.tvCache.putSeason(tv.Id, tvSeason)
 
 	return tvSeason, err
 }
 
+func (c *MovieDb) GetTvEpisodeGroups(tvId int64) (EpisodeGroupsResponse, error) {
+	response := EpisodeGroupsResponse{}
+
+	url, err := tvEpisodeGroupsUrl(c.ApiKey, tvId)
+	if err != nil {
+		return response, err
+	}
+
+	body, err := c.cacheGet(fmt.Sprintf("get-tv-episode-groups-%d", tvId), url)
+	if err != nil {
+		return response, err
+	}
+
+	err = json.Unmarshal(body, &response)
+	return response, err
+}
+
+// GetEpisodeGroupSeason returns the group of an episode group with the given
+// order as a season. Its episodes are listed in group order, but keep their
+// moviedb season and episode numbers.
+func (c *MovieDb) GetEpisodeGroupSeason(tv Tv, groupId string, seasonNumber int) (TvSeason, error) {
+	tvSeason := TvSeason{}
+
+	url, err := episodeGroupUrl(c.ApiKey, groupId)
+	if err != nil {
+		return tvSeason, err
+	}
+
+	body, err := c.cacheGet(fmt.Sprintf("get-episode-group-%s", groupId), url)
+	if err != nil {
+		return tvSeason, err
+	}
+
+	details := EpisodeGroupDetails{}
+	err = json.Unmarshal(body, &details)
+	if err != nil {
+		return tvSeason, err
+	}
+
+	for _, group := range details.Groups {
+		if group.Order != seasonNumber {
+			continue
+		}
+
+		tvSeason.Name = group.Name
+		tvSeason.SeasonNumber = seasonNumber
+		tvSeason.TvName = tv.Name
+		for _, episode := range group.Episodes {
+			episode.TvId = tv.Id
+			episode.TvName = tv.Name
+			episode.TvOriginalName = tv.OriginalName
+			episode.SeasonName = group.Name
+			episode.FirstAirDate = tv.FirstAirDate
+			tvSeason.Episodes = append(tvSeason.Episodes, episode)
+		}
+		return tvSeason, nil
+	}
+
+	return tvSeason, fmt.Errorf("Episode group %s has no season %d", details.Name, seasonNumber)
+}
+
+func (c *MovieDb) GetMovieCredits(movieId int64) (Credits, error) {
+	credits := Credits{}
+
+	url, err := movieCreditsUrl(c.ApiKey, movieId)
+	if err != nil {
+		return credits, err
+	}
+
+	body, err := c.cacheGet(fmt.Sprintf("get-movie-credits-%d", movieId), url)
+	if err != nil {
+		return credits, err
+	}
+
+	err = json.Unmarshal(body, &credits)
+	return credits, err
+}
+
+func (c *MovieDb) GetTvCredits(tvId int64) (Credits, error) {
+	credits := Credits{}
+
+	url, err := tvCreditsUrl(c.ApiKey, tvId)
+	if err != nil {
+		return credits, err
+	}
+
+	body, err := c.cacheGet(fmt.Sprintf("get-tv-credits-%d", tvId), url)
+	if err != nil {
+		return credits, err
+	}
+
+	err = json.Unmarshal(body, &credits)
+	return credits, err
+}
+
+// ExternalIds cross references a movie or tv show to other databases
+type ExternalIds struct {
+	ImdbId string `json:"imdb_id"`
+	TvdbId int64  `json:"tvdb_id"`
+}
+
+func (c *MovieDb) GetMovieExternalIds(movieId int64) (ExternalIds, error) {
+	return c.getExternalIds("movie", movieId)
+}
+
+func (c *MovieDb) GetTvExternalIds(tvId int64) (ExternalIds, error) {
+	return c.getExternalIds("tv", tvId)
+}
+
+// GetEpisodeExternalIds returns the ids of the episode itself, moviedb
+// looks episodes up by their show, season and episode number
+func (c *MovieDb) GetEpisodeExternalIds(episode TvEpisode) (ExternalIds, error) {
+	externalIds := ExternalIds{}
+
+	url, err := episodeExternalIdsUrl(c.ApiKey, episode.TvId, episode.SeasonNumber, episode.EpisonNumber)
+	if err != nil {
+		return externalIds, err
+	}
+
+	body, err := c.cacheGet(fmt.Sprintf("get-episode-external-ids-%d-%d-%d", episode.TvId, episode.SeasonNumber, episode.EpisonNumber), url)
+	if err != nil {
+		return externalIds, err
+	}
+
+	err = json.Unmarshal(body, &externalIds)
+	return externalIds, err
+}
+
+func (c *MovieDb) getExternalIds(kind string, id int64) (ExternalIds, error) {
+	externalIds := ExternalIds{}
+
+	url, err := externalIdsUrl(c.ApiKey, kind, id)
+	if err != nil {
+		return externalIds, err
+	}
+
+	body, err := c.cacheGet(fmt.Sprintf("get-%s-external-ids-%d", kind, id), url)
+	if err != nil {
+		return externalIds, err
+	}
+
+	err = json.Unmarshal(body, &externalIds)
+	return externalIds, err
+}
+
+// FindResponse is the movies and tv shows with an external id
+type FindResponse struct {
+	MovieResults []Movie `json:"movie_results"`
+	TvResults    []Tv    `json:"tv_results"`
+}
+
+func (c *MovieDb) FindByImdbId(imdbId string) (FindResponse, error) {
+	response := FindResponse{}
+
+	url, err := findUrl(c.ApiKey, imdbId, "imdb_id")
+	if err != nil {
+		return response, err
+	}
+
+	body, err := c.cacheGet(fmt.Sprintf("find-imdb-%s", imdbId), url)
+	if err != nil {
+		return response, err
+	}
+
+	err = json.Unmarshal(body, &response)
+	return response, err
+}
+
+func configurationUrl(apiKey string) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/3/configuration", urlBase))
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("api_key", apiKey)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 func movieUrl(apiKey string, movieId int64) (string, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/3/movie/%d", urlBase, movieId))
 	if err != nil {
@@ -400,6 +767,84 @@ func tvSeasonUrl(apiKey string, tvId int64, seasonNumber int) (string, error) {
 	return u.String(), nil
 }
 
+func movieCreditsUrl(apiKey string, movieId int64) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/3/movie/%d/credits", urlBase, movieId))
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("api_key", apiKey)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func tvCreditsUrl(apiKey string, tvId int64) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/3/tv/%d/credits", urlBase, tvId))
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("api_key", apiKey)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func externalIdsUrl(apiKey string, kind string, id int64) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/3/%s/%d/external_ids", urlBase, kind, id))
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("api_key", apiKey)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func episodeExternalIdsUrl(apiKey string, tvId int64, seasonNumber, episodeNumber int) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/3/tv/%d/season/%d/episode/%d/external_ids", urlBase, tvId, seasonNumber, episodeNumber))
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("api_key", apiKey)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func findUrl(apiKey string, externalId, externalSource string) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/3/find/%s", urlBase, url.PathEscape(externalId)))
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("api_key", apiKey)
+	q.Set("external_source", externalSource)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func tvEpisodeGroupsUrl(apiKey string, tvId int64) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/3/tv/%d/episode_groups", urlBase, tvId))
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("api_key", apiKey)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func episodeGroupUrl(apiKey string, groupId string) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/3/tv/episode_group/%s", urlBase, url.PathEscape(groupId)))
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("api_key", apiKey)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 func searchMovieUrl(apiKey string, query string, page, year int) (string, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/3/search/movie", urlBase))
 	if err != nil {
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MovieDbError is a failed api request, the request url has the api key redacted
+type MovieDbError struct {
+	StatusCode    int
+	Status        string
+	TmdbCode      int
+	StatusMessage string
+	Url           string
+	// wait asked for by the Retry-After header of rate limited responses
+	RetryAfter time.Duration
+}
+
+// newMovieDbError reads the moviedb error response, ie.
+// {"status_code": 7, "status_message": "Invalid API key: You must be granted a valid key."}
+func newMovieDbError(req *http.Request, res *http.Response, body []byte) *MovieDbError {
+	e := &MovieDbError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Url:        redactUrl(req.URL),
+	}
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	tmdbErr := struct {
+		StatusCode    int    `json:"status_code"`
+		StatusMessage string `json:"status_message"`
+	}{}
+	if json.Unmarshal(body, &tmdbErr) == nil {
+		e.TmdbCode = tmdbErr.StatusCode
+		e.StatusMessage = tmdbErr.StatusMessage
+	}
+
+	return e
+}
+
+func (e *MovieDbError) Error() string {
+	if e.StatusMessage != "" {
+		return fmt.Sprintf("API request error (%s): %s", e.Status, e.StatusMessage)
+	}
+	return fmt.Sprintf("API request error (%s)", e.Status)
+}
+
+// Is makes errors.Is(err, ErrInvalidApiKey) true for authentication failures
+func (e *MovieDbError) Is(target error) bool {
+	return target == ErrInvalidApiKey && e.IsAuth()
+}
+
+func (e *MovieDbError) IsAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *MovieDbError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+func (e *MovieDbError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// Temporary reports whether repeating the request later may succeed
+func (e *MovieDbError) Temporary() bool {
+	return e.IsRateLimited() || e.StatusCode >= 500
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// fileRole is what an in file of a multi-file movie directory is placed as
+type fileRole int
+
+const (
+	mainRole fileRole = iota
+	extraRole
+	ignoreRole
+)
+
+// extrasDir is the directory extras are placed in, next to the main feature
+const extrasDir = "Extras"
+
+// namesMovie reports whether the path of an in file, relative to its in dir,
+// contains all words of the movie title, and no other year than the movie's
+func namesMovie(moviePath, inDir string, movie Movie, stopWords []string) bool {
+	title := buildQueryTokens(movie.Title, stopWords)
+	if len(title) == 0 {
+		return false
+	}
+
+	name := strings.TrimSuffix(moviePath, filepath.Ext(moviePath))
+	tokens := buildQueryTokens(strings.TrimPrefix(name, inDir+string(filepath.Separator)), stopWords)
+	for _, word := range title {
+		if !stringSliceContains(tokens, word) {
+			return false
+		}
+	}
+
+	year := filenameYear(moviePath, inDir, stopWords)
+	return year == "" || movie.GetYear() == "" || year == movie.GetYear()
+}
+
+// summarizeMovieFiles lists the other pending files of the directory of
+// moviePath, and its sub-directories, that name the movie just selected,
+// ie. parts, different cuts, samples or duplicates, and asks which one is
+// the main feature and which are extras, the others are ignored. It returns
+// the role of every listed file, including moviePath, or nil when no other
+// file names the movie or the user chooses to match each file separately.
+func (s *Selector) summarizeMovieFiles(moviePath string, movieList []string, movie Movie, pending func(string) bool) map[string]fileRole {
+	inDir := inDirFor(s.inDirs, moviePath)
+	dir := filepath.Dir(moviePath)
+
+	files := []string{moviePath}
+	for _, path := range movieList {
+		if path == moviePath || !pending(path) {
+			continue
+		}
+		if filepath.Dir(path) != dir && (dir == inDir || !strings.HasPrefix(path, dir+string(filepath.Separator))) {
+			continue
+		}
+		if namesMovie(path, inDir, movie, s.stopWords) {
+			files = append(files, path)
+		}
+	}
+
+	if len(files) == 1 {
+		return nil
+	}
+
+	width := 0
+	largest := 0
+	sizes := make([]int64, len(files))
+	for i, path := range files {
+		name, _ := filepath.Rel(dir, path)
+		if len(name) > width {
+			width = len(name)
+		}
+		if info, err := os.Stat(path); err == nil {
+			sizes[i] = info.Size()
+		}
+		if sizes[i] > sizes[largest] {
+			largest = i
+		}
+	}
+
+	fmt.Printf(tr("Files of %s (%s) in %s:\n"), ColorStr(GreenColor, movie.Title), movie.GetYear(), dir)
+	for i, path := range files {
+		name, _ := filepath.Rel(dir, path)
+		fmt.Printf("  %s %-*s %s\n", ColorStr(YellowColor, fmt.Sprintf("%d", i+1)), width, name, humanize.Bytes(uint64(sizes[i])))
+	}
+
+	feature := -1
+	for feature < 0 {
+		fmt.Print(promptStr(fmt.Sprintf(tr("Main feature [1-%d], or s to match each file separately (default: %d)"), len(files), largest+1)))
+		raw, err := s.reader.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+		answer := strings.ToLower(strings.TrimSpace(raw))
+		if answer == "s" {
+			fmt.Println()
+			return nil
+		} else if answer == "" {
+			feature = largest
+		} else if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(files) {
+			feature = n - 1
+		} else {
+			fmt.Println(tr("Please select one of the listed options."))
+		}
+	}
+
+	roles := make(map[string]fileRole)
+	for {
+		fmt.Print(promptStr(tr("Extras, CSV of numbers, the other files are ignored (default: none)")))
+		raw, err := s.reader.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+
+		for _, path := range files {
+			roles[path] = ignoreRole
+		}
+		roles[files[feature]] = mainRole
+
+		valid := true
+		for _, answer := range splitCsv(raw) {
+			n, err := strconv.Atoi(answer)
+			if err != nil || n < 1 || n > len(files) || n-1 == feature {
+				valid = false
+				break
+			}
+			roles[files[n-1]] = extraRole
+		}
+		if valid {
+			break
+		}
+		fmt.Println(tr("Please select one of the listed options."))
+	}
+
+	fmt.Println()
+	return roles
+}
+
+// extraOutFile returns the out file of an extra in the extras directory
+// next to the out file of the main feature
+func extraOutFile(outDir, outFile, moviePath string) string {
+	dir := filepath.Dir(outFile)
+	if dir == filepath.Clean(outDir) {
+		// the movie has no directory of its own
+		dir = strings.TrimSuffix(outFile, filepath.Ext(outFile))
+	}
+	return filepath.Join(dir, extrasDir, sanitizeFileName(filepath.Base(moviePath)))
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// mp4 and quicktime containers are tagged by rewriting their moov atom,
+// which holds the metadata, in place, without copying the media data
+var mp4Exts = []string{".mp4", ".m4v", ".mov"}
+
+// moov atoms larger than this are not read, they take a few megabytes for
+// movies of several hours
+const maxMoovSize = 64 << 20
+
+// itunes style metadata items of the ilst atom
+const (
+	mp4TitleItem   = "\xa9nam"
+	mp4DateItem    = "\xa9day"
+	mp4CommentItem = "\xa9cmt"
+)
+
+type mp4Atom struct {
+	typ  string
+	data []byte
+}
+
+// parseMp4Atoms splits b into the atoms it contains
+func parseMp4Atoms(b []byte) ([]mp4Atom, error) {
+	atoms := []mp4Atom{}
+	for len(b) > 0 {
+		if len(b) < 8 {
+			return nil, fmt.Errorf("truncated mp4 atom header")
+		}
+		size, header := uint64(binary.BigEndian.Uint32(b)), uint64(8)
+		typ := string(b[4:8])
+		switch size {
+		case 0:
+			size = uint64(len(b))
+		case 1:
+			if len(b) < 16 {
+				return nil, fmt.Errorf("truncated mp4 atom header")
+			}
+			size, header = binary.BigEndian.Uint64(b[8:]), 16
+		}
+		if size < header || size > uint64(len(b)) {
+			return nil, fmt.Errorf("invalid size %d of mp4 atom %q", size, typ)
+		}
+		atoms = append(atoms, mp4Atom{typ: typ, data: b[header:size]})
+		b = b[size:]
+	}
+	return atoms, nil
+}
+
+func encodeMp4Atom(typ string, data []byte) []byte {
+	b := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint32(b, uint32(8+len(data)))
+	copy(b[4:], typ)
+	return append(b, data...)
+}
+
+func encodeMp4Atoms(atoms []mp4Atom) []byte {
+	b := []byte{}
+	for _, atom := range atoms {
+		b = append(b, encodeMp4Atom(atom.typ, atom.data)...)
+	}
+	return b
+}
+
+// setMp4Child replaces the first child atom of typ, or appends it
+func setMp4Child(atoms []mp4Atom, child mp4Atom) []mp4Atom {
+	for i, atom := range atoms {
+		if atom.typ == child.typ {
+			atoms[i] = child
+			return atoms
+		}
+	}
+	return append(atoms, child)
+}
+
+func findMp4Child(atoms []mp4Atom, typ string) []byte {
+	for _, atom := range atoms {
+		if atom.typ == typ {
+			return atom.data
+		}
+	}
+	return nil
+}
+
+// mp4TextItem is an ilst item holding utf-8 text
+func mp4TextItem(typ, text string) mp4Atom {
+	data := make([]byte, 8, 8+len(text))
+	binary.BigEndian.PutUint32(data, 1)
+	return mp4Atom{typ: typ, data: encodeMp4Atom("data", append(data, text...))}
+}
+
+// tagMoov returns the payload of a moov atom with items set in the ilst atom
+// of its udta>meta atom, creating the atoms it does not have
+func tagMoov(moov []byte, items []mp4Atom) ([]byte, error) {
+	children, err := parseMp4Atoms(moov)
+	if err != nil {
+		return nil, err
+	}
+	udta, err := parseMp4Atoms(findMp4Child(children, "udta"))
+	if err != nil {
+		return nil, err
+	}
+
+	// meta is a full atom in mp4 files, starting with its version and
+	// flags, but not in some quicktime files
+	meta := findMp4Child(udta, "meta")
+	fullBox := len(meta) < 8 || string(meta[4:8]) != "hdlr"
+	version := []byte{0, 0, 0, 0}
+	if fullBox && len(meta) >= 4 {
+		version, meta = meta[:4], meta[4:]
+	}
+	metaChildren, err := parseMp4Atoms(meta)
+	if err != nil {
+		return nil, err
+	}
+	if findMp4Child(metaChildren, "hdlr") == nil {
+		hdlr := append(make([]byte, 8), "mdirappl"...)
+		metaChildren = append([]mp4Atom{{typ: "hdlr", data: append(hdlr, make([]byte, 9)...)}}, metaChildren...)
+	}
+	ilst, err := parseMp4Atoms(findMp4Child(metaChildren, "ilst"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		ilst = setMp4Child(ilst, item)
+	}
+	metaChildren = setMp4Child(metaChildren, mp4Atom{typ: "ilst", data: encodeMp4Atoms(ilst)})
+	meta = encodeMp4Atoms(metaChildren)
+	if fullBox {
+		meta = append(append([]byte{}, version...), meta...)
+	}
+	udta = setMp4Child(udta, mp4Atom{typ: "meta", data: meta})
+	children = setMp4Child(children, mp4Atom{typ: "udta", data: encodeMp4Atoms(udta)})
+	return encodeMp4Atoms(children), nil
+}
+
+// tagMp4 writes title, year and moviedb id into the moov atom of outFile.
+// A moov atom at the end of the file is rewritten where it is, one before
+// the media data is turned into free space and written again at the end,
+// so the offsets of the media data stay valid.
+func tagMp4(outFile string, media Media) (err error) {
+	f, err := os.OpenFile(outFile, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := f.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	moovOffset, moovHeader, moovSize, err := findMoov(f, info.Size())
+	if err != nil {
+		return err
+	} else if moovSize > maxMoovSize {
+		return fmt.Errorf("moov atom of %s is larger than %d bytes", outFile, maxMoovSize)
+	}
+
+	moov := make([]byte, moovSize-moovHeader)
+	_, err = f.ReadAt(moov, moovOffset+moovHeader)
+	if err != nil {
+		return err
+	}
+	tagged, err := tagMoov(moov, []mp4Atom{
+		mp4TextItem(mp4TitleItem, media.GetName()),
+		mp4TextItem(mp4DateItem, media.GetYear()),
+		mp4TextItem(mp4CommentItem, fmt.Sprintf("tmdb:%s", tmdbTag(media))),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", outFile, err)
+	}
+	tagged = encodeMp4Atom("moov", tagged)
+
+	if moovOffset+moovSize == info.Size() {
+		_, err = f.WriteAt(tagged, moovOffset)
+		if err == nil {
+			err = f.Truncate(moovOffset + int64(len(tagged)))
+		}
+		return err
+	}
+
+	// the new moov is written before the old one is freed, so that an
+	// interrupted write leaves a file with the old moov
+	_, err = f.WriteAt(tagged, info.Size())
+	if err == nil {
+		_, err = f.WriteAt([]byte("free"), moovOffset+4)
+	}
+	return err
+}
+
+// findMoov returns the offset, header size and size of the top level moov
+// atom of an mp4 file of size bytes
+func findMoov(r io.ReaderAt, size int64) (int64, int64, int64, error) {
+	header := make([]byte, 16)
+	for offset := int64(0); offset+8 <= size; {
+		_, err := r.ReadAt(header[:8], offset)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		atomSize, headerSize := int64(binary.BigEndian.Uint32(header)), int64(8)
+		switch atomSize {
+		case 0:
+			atomSize = size - offset
+		case 1:
+			_, err = r.ReadAt(header[8:], offset+8)
+			if err != nil {
+				return 0, 0, 0, err
+			}
+			atomSize, headerSize = int64(binary.BigEndian.Uint64(header[8:])), 16
+		}
+		if atomSize < headerSize || offset+atomSize > size {
+			return 0, 0, 0, fmt.Errorf("invalid size %d of mp4 atom %q", atomSize, header[4:8])
+		}
+		if string(header[4:8]) == "moov" {
+			return offset, headerSize, atomSize, nil
+		}
+		offset += atomSize
+	}
+	return 0, 0, 0, fmt.Errorf("no moov atom found")
+}
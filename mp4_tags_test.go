@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mp4ItemText(t *testing.T, moov []byte, typ string) string {
+	children, err := parseMp4Atoms(moov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	udta, err := parseMp4Atoms(findMp4Child(children, "udta"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := findMp4Child(udta, "meta")
+	metaChildren, err := parseMp4Atoms(meta[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ilst, err := parseMp4Atoms(findMp4Child(metaChildren, "ilst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := parseMp4Atoms(findMp4Child(ilst, typ))
+	if err != nil || len(data) != 1 || len(data[0].data) < 8 {
+		t.Fatalf("invalid %q item: %v", typ, err)
+	}
+	return string(data[0].data[8:])
+}
+
+func TestTagMp4(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mp4-tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ftyp := encodeMp4Atom("ftyp", []byte("isom\x00\x00\x02\x00isommp41"))
+	moov := encodeMp4Atom("moov", encodeMp4Atom("mvhd", make([]byte, 100)))
+	mdat := encodeMp4Atom("mdat", bytes.Repeat([]byte{0xab}, 1000))
+	movie := Movie{Id: 603, Title: "The Matrix", ReleaseDate: "1999-03-30"}
+
+	tests := []struct {
+		name   string
+		layout [][]byte
+	}{
+		{"moov-last.mp4", [][]byte{ftyp, mdat, moov}},
+		{"moov-first.mp4", [][]byte{ftyp, moov, mdat}},
+	}
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		err := ioutil.WriteFile(path, bytes.Join(test.layout, nil), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// tagging twice replaces the items
+		for i := 0; i < 2; i++ {
+			err = tagMp4(path, movie)
+			if err != nil {
+				t.Fatalf("tagMp4(%s) error: %v", test.name, err)
+			}
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atoms, err := parseMp4Atoms(b)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		types := []string{}
+		for _, atom := range atoms {
+			types = append(types, atom.typ)
+		}
+		if !bytes.Contains(b, mdat) {
+			t.Errorf("%s: media data moved or changed", test.name)
+		}
+		if bytes.Index(b, mdat) != bytes.Index(bytes.Join(test.layout, nil), mdat) {
+			t.Errorf("%s: media data offset changed", test.name)
+		}
+
+		moovData := findMp4Child(atoms, "moov")
+		if moovData == nil {
+			t.Fatalf("%s: no moov atom in %v", test.name, types)
+		}
+		for typ, want := range map[string]string{mp4TitleItem: "The Matrix", mp4DateItem: "1999", mp4CommentItem: "tmdb:movie/603"} {
+			if got := mp4ItemText(t, moovData, typ); got != want {
+				t.Errorf("%s: %q item = %q, want %q", test.name, typ, got, want)
+			}
+		}
+		if findMp4Child(mustParseMp4Atoms(t, moovData), "mvhd") == nil {
+			t.Errorf("%s: mvhd atom lost", test.name)
+		}
+	}
+}
+
+func mustParseMp4Atoms(t *testing.T, b []byte) []mp4Atom {
+	atoms, err := parseMp4Atoms(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return atoms
+}
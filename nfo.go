@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+// movieNfoName is the nfo file kodi and jellyfin read for every video file
+// of a movie directory
+const movieNfoName = "movie.nfo"
+
+// at most this many actors are written, movies often credit hundreds
+const maxNfoActors = 20
+
+type nfoRating struct {
+	Name    string  `xml:"name,attr"`
+	Max     int     `xml:"max,attr"`
+	Default bool    `xml:"default,attr"`
+	Value   float64 `xml:"value"`
+	Votes   int     `xml:"votes"`
+}
+
+type nfoUniqueId struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr,omitempty"`
+	Id      string `xml:",chardata"`
+}
+
+type nfoActor struct {
+	Name  string `xml:"name"`
+	Role  string `xml:"role,omitempty"`
+	Order int    `xml:"order"`
+}
+
+// movieNfo is the kodi movie.nfo format, which jellyfin also reads
+type movieNfo struct {
+	XMLName       xml.Name      `xml:"movie"`
+	Title         string        `xml:"title"`
+	OriginalTitle string        `xml:"originaltitle,omitempty"`
+	Year          string        `xml:"year,omitempty"`
+	Premiered     string        `xml:"premiered,omitempty"`
+	Plot          string        `xml:"plot,omitempty"`
+	Runtime       int           `xml:"runtime,omitempty"`
+	Genres        []string      `xml:"genre"`
+	Ratings       []nfoRating   `xml:"ratings>rating"`
+	UniqueIds     []nfoUniqueId `xml:"uniqueid"`
+	Actors        []nfoActor    `xml:"actor"`
+}
+
+// episodeNfo is the kodi episode nfo format, named after the episode file
+type episodeNfo struct {
+	XMLName   xml.Name      `xml:"episodedetails"`
+	Title     string        `xml:"title"`
+	ShowTitle string        `xml:"showtitle,omitempty"`
+	Season    int           `xml:"season"`
+	Episode   int           `xml:"episode"`
+	Aired     string        `xml:"aired,omitempty"`
+	Plot      string        `xml:"plot,omitempty"`
+	Ratings   []nfoRating   `xml:"ratings>rating"`
+	UniqueIds []nfoUniqueId `xml:"uniqueid"`
+	Actors    []nfoActor    `xml:"actor"`
+}
+
+// nfoFile returns the nfo file written for outFile, movie.nfo in the
+// directory of a movie or the out file with the .nfo extension
+func nfoFile(outFile string, media Media) string {
+	if _, ok := media.(Movie); ok && !*mirrorFlag {
+		return filepath.Join(filepath.Dir(outFile), movieNfoName)
+	}
+	return filepath.Join(filepath.Dir(outFile), fNameSansExtension(outFile)+".nfo")
+}
+
+// ratingName is the kodi name of the ratings of a provider
+func ratingName(provider string) string {
+	if provider == tvdbProvider {
+		return "tvdb"
+	}
+	return "themoviedb"
+}
+
+func nfoRatings(provider string, voteAverage float64, voteCount int) []nfoRating {
+	if voteCount == 0 {
+		return nil
+	}
+	return []nfoRating{{Name: ratingName(provider), Max: 10, Default: true, Value: voteAverage, Votes: voteCount}}
+}
+
+func nfoActors(credits Credits) []nfoActor {
+	cast := append([]CastMember{}, credits.Cast...)
+	sort.SliceStable(cast, func(i, j int) bool { return cast[i].Order < cast[j].Order })
+	if len(cast) > maxNfoActors {
+		cast = cast[:maxNfoActors]
+	}
+
+	actors := []nfoActor{}
+	for i, member := range cast {
+		actors = append(actors, nfoActor{Name: member.Name, Role: member.Character, Order: i})
+	}
+	return actors
+}
+
+// buildNfo returns the nfo of media, with the cast fetched from provider.
+// The imdb id is left out of episodes, it is the one of their tv show.
+func buildNfo(provider MetadataProvider, media Media, imdbId string, details *MediaDetails) (interface{}, error) {
+	if details == nil {
+		details = &MediaDetails{}
+	}
+	uniqueIds := []nfoUniqueId{{Type: idSource(provider.Name()), Default: true, Id: fmt.Sprintf("%d", media.GetId())}}
+
+	switch m := media.(type) {
+	case Movie:
+		credits, err := provider.GetMovieCredits(m.Id)
+		if err != nil {
+			return nil, err
+		}
+		if imdbId != "" {
+			uniqueIds = append(uniqueIds, nfoUniqueId{Type: "imdb", Id: imdbId})
+		}
+		nfo := movieNfo{
+			Title:     m.Title,
+			Year:      m.GetYear(),
+			Premiered: m.ReleaseDate,
+			Plot:      m.Overview,
+			Runtime:   details.Runtime,
+			Genres:    details.Genres,
+			Ratings:   nfoRatings(provider.Name(), m.VoteAverage, m.VoteCount),
+			UniqueIds: uniqueIds,
+			Actors:    nfoActors(credits),
+		}
+		if m.OriginalTitle != m.Title {
+			nfo.OriginalTitle = m.OriginalTitle
+		}
+		return nfo, nil
+	case TvEpisode:
+		credits, err := provider.GetTvCredits(m.TvId)
+		if err != nil {
+			return nil, err
+		}
+		return episodeNfo{
+			Title:     m.Name,
+			ShowTitle: m.TvName,
+			Season:    m.SeasonNumber + m.SeasonOffset,
+			Episode:   m.EpisonNumber + m.EpisodeOffset,
+			Aired:     m.AirDate,
+			Plot:      m.Overview,
+			Ratings:   nfoRatings(provider.Name(), m.VoteAverage, m.VoteCount),
+			UniqueIds: uniqueIds,
+			Actors:    nfoActors(credits),
+		}, nil
+	default:
+		return nil, fmt.Errorf("no nfo for %s", media.GetType())
+	}
+}
+
+// writeNfo writes the nfo of media next to outFile, keeping nfo files that
+// are already there, ie. ones placed with -sidecars
+func writeNfo(provider MetadataProvider, outFile string, media Media, imdbId string, details *MediaDetails) error {
+	nfoPath := nfoFile(outFile, media)
+	for _, path := range []string{nfoPath, filepath.Join(filepath.Dir(outFile), fNameSansExtension(outFile)+".nfo")} {
+		exists, err := fileExists(path)
+		if err != nil {
+			return err
+		} else if exists {
+			fmt.Printf(tr("Keeping nfo file %s\n"), path)
+			return nil
+		}
+	}
+
+	nfo, err := buildNfo(provider, media, imdbId, details)
+	if err != nil {
+		return err
+	}
+	b, err := xml.MarshalIndent(nfo, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.Write(b)
+	buf.WriteString("\n")
+	fmt.Printf(tr("Writing nfo file %s\n"), ColorStr(GreenColor, nfoPath))
+	return ioutil.WriteFile(nfoPath, buf.Bytes(), 0644)
+}
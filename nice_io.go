@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log"
+	"runtime"
+)
+
+// withLowPriority runs fn on an OS thread of its own in the idle io class,
+// and at cpu niceness nice when positive, so that placing files in the
+// background doesn't slow down interactive use of the machine
+func withLowPriority(nice int, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		// the locked thread is discarded when the goroutine ends,
+		// instead of being reused at low priority
+		runtime.LockOSThread()
+		err := lowerPriority(nice)
+		if err != nil {
+			log.Println("Error lowering io priority:", err)
+		}
+		done <- fn()
+	}()
+	return <-done
+}
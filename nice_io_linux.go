@@ -0,0 +1,25 @@
+package main
+
+import "syscall"
+
+const niceIoSupported = true
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+)
+
+// lowerPriority sets the io and cpu priority of the calling thread,
+// which linux tracks per thread
+func lowerPriority(nice int) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, ioprioClassIdle<<ioprioClassShift)
+	if errno != 0 {
+		return errno
+	}
+
+	if nice > 0 {
+		return syscall.Setpriority(syscall.PRIO_PROCESS, 0, nice)
+	}
+	return nil
+}
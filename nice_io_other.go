@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import "errors"
+
+const niceIoSupported = false
+
+func lowerPriority(nice int) error {
+	return errors.New("not supported on this platform")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"unicode"
+)
+
+var (
+	hexNameReg    = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	randomNameReg = regexp.MustCompile(`^[0-9a-zA-Z]{20,}$`)
+	nfoTitleReg   = regexp.MustCompile(`(?is)<title>\s*(.*?)\s*</title>`)
+)
+
+// isObfuscatedName reports whether a file name without extension looks like
+// the hashed or random names of usenet downloads, ie. "a3f9c0d1e4b5a697" or
+// "Xk29fLq8ZpR3mW7tY0bN". Random names switch between letters and digits far
+// more often than names written together, ie. "TheMatrix1999BluRay1080p".
+func isObfuscatedName(name string) bool {
+	if hexNameReg.MatchString(name) {
+		return true
+	}
+	if !randomNameReg.MatchString(name) {
+		return false
+	}
+
+	switches := 0
+	runes := []rune(name)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsDigit(runes[i]) != unicode.IsDigit(runes[i-1]) {
+			switches += 1
+		}
+	}
+	return switches >= len(runes)/4
+}
+
+// nfoTitle returns the title of the first nfo file next to moviePath that
+// has one, ie. the "<title>The Matrix</title>" of kodi nfo files
+func nfoTitle(moviePath, inDir string) string {
+	for _, nfo := range nfoFiles(moviePath, inDir) {
+		b, err := readHintFile(nfo, maxNfoSize)
+		if err != nil {
+			continue
+		}
+		if m := nfoTitleReg.FindSubmatch(b); m != nil && len(m[1]) > 0 {
+			return string(m[1])
+		}
+	}
+	return ""
+}
+
+// obfuscatedQuery builds the query of an in file with an obfuscated name from
+// the name of its directory, or else the title of an nfo file or the release
+// name of an nfo or torrent file next to it
+func obfuscatedQuery(moviePath, inDir string, stopWords []string) string {
+	dir := filepath.Dir(moviePath)
+	if dir != filepath.Clean(inDir) && !isObfuscatedName(filepath.Base(dir)) {
+		if query := buildQuery(filepath.Base(dir), stopWords); query != "" {
+			return query
+		}
+	}
+	if title := nfoTitle(moviePath, inDir); title != "" {
+		return buildQuery(title, stopWords)
+	}
+	if query, _ := releaseHintQuery(moviePath, inDir, stopWords); query != "" {
+		return query
+	}
+	return buildQuery(fNameSansExtension(moviePath), stopWords)
+}
@@ -0,0 +1,762 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Organizer matches media files in the in dir and places them in the out dirs
+type Organizer struct {
+	inDirs        []string
+	movieOutDir   string
+	tvOutDir      string
+	manifestPath  string
+	exts          []string
+	stopWords     []string
+	selector      *Selector
+	reader        *bufio.Reader
+	verb          string
+	onConflict    conflictPolicy
+	qualityLadder []string
+	config        *Config
+	ownership     *Ownership
+	manifest      []ManifestEntry
+	manifestIndex *ManifestIndex
+	selections    map[string]Media
+	selectedBy    map[string]string
+	matches       map[string]Match
+	roles         map[string]fileRole
+	outIndex      *OutIndex
+
+	// in files left for the user to look at, with attentionOnly
+	// only these are processed
+	attention     *Attention
+	attentionPath string
+	attentionOnly bool
+	inFiles       []string
+
+	// conflicts found during the run, and the policy they are resolved
+	// with at the end of it
+	conflicts          []conflictItem
+	conflictPolicy     conflictPolicy
+	resolvingConflicts bool
+
+	// background copies with copy-workers, and the failures of
+	// finished copies not yet handled by the run loop
+	copies       *copyPool
+	copyFailures []retryItem
+}
+
+// Run processes all in files not yet in the manifest once
+func (o *Organizer) Run() *Session {
+	if *trashSourceFlag && *quarantineDirFlag != "" {
+		err := purgeQuarantine(*quarantineDirFlag, *quarantineRetentionFlag)
+		if err != nil {
+			log.Println("Error purging quarantine dir:", err)
+		}
+	}
+
+	if *recycleDirFlag != "" {
+		err := purgeQuarantine(*recycleDirFlag, *recycleRetentionFlag)
+		if err != nil {
+			log.Println("Error purging recycle dir:", err)
+		}
+	}
+
+	manifest, err := readManifest(o.manifestPath)
+	o.manifest = manifest
+	o.manifestIndex = NewManifestIndex(manifest)
+	o.selections = make(map[string]Media)
+	o.selectedBy = make(map[string]string)
+	o.matches = make(map[string]Match)
+	o.roles = make(map[string]fileRole)
+	o.outIndex = NewOutIndex()
+	o.conflicts = nil
+	if err != nil {
+		log.Println("Manifest error:", err)
+		session := NewSession(0)
+		session.Failed(o.manifestPath, err)
+		return session
+	}
+
+	movieList, err := lsMoviesAll(o.inDirs, o.exts)
+	if err != nil {
+		log.Println("List movies error:", err)
+		session := NewSession(0)
+		session.Failed(strings.Join(o.inDirs, ","), err)
+		return session
+	}
+
+	o.attention.prune(o.inDirs, movieList, o.manifestIndex)
+	o.inFiles = movieList
+	if o.attentionOnly {
+		movieList = o.attention.filter(movieList)
+	}
+	numMovies := len(movieList)
+
+	session := NewSession(numMovies)
+	planStart := len(o.manifest)
+
+	retryQueue := []retryItem{}
+	stop := false
+	failed := func(i int, moviePath string, err error) {
+		if errors.Is(err, ErrQuit) {
+			session.Quit()
+			stop = true
+			return
+		}
+
+		if *retryAttemptsFlag > 0 && isTransient(err) {
+			fmt.Println(tr("Transient failure, will retry at the end of the run:"), err)
+			retryQueue = append(retryQueue, retryItem{i, moviePath, err})
+			return
+		}
+
+		session.Failed(moviePath, err)
+		if !*keepGoingFlag {
+			stop = true
+		}
+	}
+	handleCopyFailures := func() {
+		for _, item := range o.copyFailures {
+			failed(item.index, item.moviePath, item.err)
+		}
+		o.copyFailures = nil
+	}
+
+	if *copyWorkersFlag > 0 && !*dryRunFlag {
+		o.copies = newCopyPool(*copyWorkersFlag, o.place)
+	}
+
+	for i, moviePath := range movieList {
+		session.Start(i)
+		err := o.processFile(session, i, moviePath, movieList)
+		if err != nil {
+			failed(i, moviePath, err)
+		}
+		o.recordCopies(session, o.copies.Collect())
+		handleCopyFailures()
+		if stop {
+			break
+		}
+	}
+
+	if o.copies != nil {
+		// retries and deferred conflicts are placed in the foreground
+		o.recordCopies(session, o.copies.Wait())
+		handleCopyFailures()
+		o.copies.Close()
+		o.copies = nil
+	}
+
+	if session.HasQuit() {
+		for _, item := range retryQueue {
+			session.Failed(item.moviePath, item.err)
+		}
+	} else {
+		o.retry(session, retryQueue, movieList)
+	}
+
+	if !session.HasQuit() {
+		o.resolveDeferredConflicts(session, movieList)
+	}
+
+	if *diffFlag != "" {
+		oldPlan, err := readManifest(*diffFlag)
+		if err != nil {
+			log.Println("Error reading previous plan:", err)
+		} else {
+			printPlanDiff(oldPlan, o.manifest, o.manifest[planStart:], movieList)
+		}
+	}
+
+	if session.Worked() {
+		err = writeManifest(o.manifestPath, o.manifest)
+		if err != nil {
+			log.Println("Error writing manifest:", err)
+			session.Failed(o.manifestPath, err)
+		}
+	}
+
+	if o.attention != nil && o.attention.changed && !*dryRunFlag {
+		err = writeAttention(o.attentionPath, o.attention)
+		if err != nil {
+			log.Println("Error writing attention queue:", err)
+		}
+	}
+
+	return session
+}
+
+// processFile matches and places a single in file. It returns nil when the
+// file was placed or skipped, ErrQuit when the user quit, or the failure.
+func (o *Organizer) processFile(session *Session, i int, moviePath string, movieList []string) error {
+	numMovies := len(movieList)
+	inDir := inDirFor(o.inDirs, moviePath)
+	movieOutDir, tvOutDir, verb, onConflict, batch := o.movieOutDir, o.tvOutDir, o.verb, o.onConflict, *batchFlag
+	if route := o.config.routeFor(moviePath, inDir); route != nil {
+		if route.MovieOut != "" {
+			movieOutDir = route.MovieOut
+		}
+		if route.TvOut != "" {
+			tvOutDir = route.TvOut
+		}
+		if route.Mode != "" {
+			verb = route.Mode
+		}
+		if route.OnConflict != "" {
+			onConflict = route.onConflict
+		}
+		if route.Batch {
+			batch = true
+		}
+	}
+	if *seedingFlag || *linkFlag != "" {
+		// never move or modify in files that are being seeded
+		verb = "link"
+	}
+	if *strmUrlFlag != "" {
+		// in files stay where they are, the .strm file points at them
+		verb = strmVerb
+	}
+	if o.conflictPolicy != "" {
+		onConflict = o.conflictPolicy
+	}
+	if batch && onConflict == promptConflict {
+		// nobody is there to answer
+		onConflict = skipConflict
+	}
+	info := fmt.Sprintf("%s%s", movieInfo(i, numMovies, moviePath, inDir), session.Status())
+	if o.manifestIndex.Seen(moviePath) {
+		fmt.Println(info)
+		fmt.Printf("%s\n\n", tr("Skipping because we've seen this in-file before"))
+		session.Skipped()
+		return nil
+	}
+
+	recent, err := isTooRecent(moviePath, *minAgeFlag)
+	if err != nil {
+		log.Println("Error checking in file age:", err)
+		return err
+	}
+	if recent {
+		fmt.Println(info)
+		fmt.Printf("%s\n\n", tr("Deferring because the in-file was modified too recently"))
+		session.Deferred()
+		return nil
+	}
+
+	stable, err := isStable(moviePath, *stableForFlag)
+	if err != nil {
+		log.Println("Error checking in file is complete:", err)
+		return err
+	}
+	if !stable {
+		fmt.Println(info)
+		fmt.Printf("%s\n\n", tr("Deferring because the in-file is still being written"))
+		session.Deferred()
+		return nil
+	}
+
+	common := []string{}
+	if !*noCommonDirFlag {
+		// all in files, not only those that need attention, share tokens
+		common, err = commonDirWords(moviePath, o.inFiles, o.stopWords, *commonDirScopeFlag, *commonDirMinPeersFlag)
+		if err != nil {
+			log.Println("Error getting common directory query tokens:", err)
+			return err
+		}
+	}
+
+	movie, selected := o.selections[moviePath]
+	if !selected {
+		movie, err = o.selector.Handle(i, numMovies, moviePath, common, info, batch)
+	}
+	if err != nil {
+		if errors.Is(err, ErrNoResults) {
+			o.attention.add(moviePath, noResultsAttention, "")
+			session.Skipped()
+			return nil
+		} else if errors.Is(err, ErrSkipped) {
+			o.attention.add(moviePath, skippedAttention, "")
+			session.Skipped()
+			return nil
+		} else if errors.Is(err, ErrQuit) {
+			return err
+		} else if errors.Is(err, ErrNeedsReview) {
+			fmt.Printf("%s\n\n", err)
+			o.attention.add(moviePath, needsReviewAttention, err.Error())
+			session.NeedsReview(moviePath, err)
+			return nil
+		} else {
+			log.Println("Error searching movies:", err)
+			return err
+		}
+	}
+
+	// remember the selection, and the provider its ids belong to,
+	// in case placing the file has to be retried
+	o.selections[moviePath] = movie
+	if !selected {
+		o.selectedBy[moviePath] = o.selector.provider.Name()
+		o.matches[moviePath] = o.selector.match
+	}
+
+	pending := func(path string) bool {
+		_, ok := o.selections[path]
+		return !ok && !o.manifestIndex.Seen(path)
+	}
+	if !selected && !*noSeasonSummaryFlag && !batch {
+		for path, media := range o.selector.summarizeSeason(moviePath, movieList, common, pending) {
+			o.selections[path] = media
+			o.selectedBy[path] = o.selector.provider.Name()
+			o.matches[path] = Match{Method: summaryMatch}
+		}
+	}
+	if m, ok := movie.(Movie); ok && !selected && !*noMovieSummaryFlag && !batch && !*mirrorFlag {
+		for path, role := range o.selector.summarizeMovieFiles(moviePath, movieList, m, pending) {
+			o.selections[path] = movie
+			o.selectedBy[path] = o.selector.provider.Name()
+			o.matches[path] = Match{Method: summaryMatch}
+			o.roles[path] = role
+		}
+	}
+	if o.roles[moviePath] == ignoreRole {
+		fmt.Printf(tr("Ignoring %s, it is neither the main feature nor an extra\n\n"), moviePath)
+		session.Skipped()
+		return nil
+	}
+	provider := providerNamed(o.selector.provider, o.selectedBy[moviePath])
+
+	movie = applyYearPolicy(movie, filenameYear(moviePath, inDir, o.stopWords), *yearSourceFlag)
+	movie = applyOverride(movie, o.config, provider.Name())
+
+	var outDir string
+	if movie.GetType() == "tv_episode" {
+		outDir = tvOutDir
+	} else {
+		outDir = movieOutDir
+	}
+
+	var outFile string
+	if *mirrorFlag {
+		outFile, err = buildMirrorOutFile(moviePath, inDir, outDir)
+	} else {
+		outFile, err = buildOutFile(moviePath, outDir, movie, provider.Name())
+	}
+
+	if err != nil {
+		log.Println("Unable to build out file:", err)
+		return err
+	}
+
+	if m, ok := movie.(Movie); ok && !*mirrorFlag {
+		outFile = o.disambiguateOutFile(provider, outFile, m)
+		if o.roles[moviePath] == extraRole {
+			outFile = extraOutFile(outDir, outFile, moviePath)
+		}
+	}
+
+	var mediaUrl string
+	if verb == strmVerb {
+		outFile = strmOutFile(outFile)
+		mediaUrl, err = strmUrl(*strmUrlFlag, moviePath, inDir)
+	} else if isStrm(moviePath) {
+		mediaUrl, err = readStrmUrl(moviePath)
+	}
+	if err != nil {
+		log.Println("Error getting url of .strm file:", err)
+		return err
+	}
+
+	if *previewFlag && !batch {
+		outFile = previewOutFile(outFile, o.reader)
+	}
+
+	if o.copies.Pending(outFile) {
+		// a background copy to the same out file must finish before checking it
+		o.recordCopies(session, o.copies.Wait())
+	}
+
+	doCopy := true
+	overwrite := false
+	if outFile == moviePath {
+		fmt.Println(tr("In file and out file are the same path"))
+	} else if outInfo, err := o.outIndex.Stat(outFile); err == nil || errors.Is(err, ErrOpTimeout) {
+		if err != nil {
+			log.Println("Error checking out file:", err)
+			return err
+		}
+
+		// outFile exists
+		isSameFile, err := sameFileWithTimeout(moviePath, outFile)
+		if err != nil {
+			log.Println("Error comparing files:", err)
+			return err
+		}
+
+		if isSameFile {
+			fmt.Println(tr("Out file exists and is same content as in file, updating manifest"))
+			doCopy = false
+		} else {
+			if onConflict == promptConflict && !*upgradeFlag && !*noDeferConflictsFlag && !o.resolvingConflicts {
+				fmt.Printf("%s\n\n", tr("Out file exists with different content, deciding at the end of the run"))
+				o.conflicts = append(o.conflicts, conflictItem{i, moviePath, outFile})
+				return nil
+			}
+
+			inInfo, err := statWithTimeout(moviePath)
+			if err != nil {
+				log.Println("Error getting info for in file:", err)
+				return err
+			}
+
+			printConflict(moviePath, outFile, inInfo, outInfo)
+
+			var action conflictAction
+			if *upgradeFlag {
+				action = resolveUpgrade(moviePath, outFile, o.qualityLadder)
+			} else {
+				action = resolveConflict(onConflict, inInfo, outInfo, verb, o.reader)
+				if onConflict != promptConflict {
+					fmt.Printf(tr("Conflict policy %s: %s\n"), onConflict, conflictActionName(action))
+				}
+			}
+
+			if action == skipAction {
+				o.attention.add(moviePath, conflictAttention, outFile)
+				session.Skipped()
+				return nil
+			} else if action == overwriteAction {
+				overwrite = true
+			} else if action == keepBothAction {
+				outFile, err = keepBothPath(outFile, parseSource(moviePath))
+				if err != nil {
+					log.Println("Error finding path to keep both files:", err)
+					return err
+				}
+			}
+		}
+	}
+
+	details, err := fetchDetails(provider, movie)
+	if err != nil {
+		log.Println("Error fetching details:", err)
+	}
+
+	imdbId, err := fetchImdbId(provider, movie)
+	if err != nil {
+		log.Println("Error fetching imdb id:", err)
+	}
+
+	crcCheck, err := verifyCrc(moviePath)
+	if err != nil {
+		log.Println("Error verifying crc32:", err)
+		return err
+	}
+
+	fmt.Printf("%s %s %s %s\n", tr(strings.Title(verb)), ColorStr(RedColor, moviePath), ColorStr(WhiteColor, arrowStr()), ColorStr(GreenColor, outFile))
+
+	tags, note := splitCsv(*tagsFlag), *noteFlag
+	if *askTagsFlag && !batch {
+		tags, note = askTags(tags, note, o.reader)
+	}
+
+	p := placement{
+		index:     i,
+		moviePath: moviePath,
+		outFile:   outFile,
+		verb:      verb,
+		movie:     movie,
+		provider:  provider.Name(),
+		overwrite: overwrite,
+		imdbId:    imdbId,
+		details:   details,
+		crcCheck:  crcCheck,
+		tags:      tags,
+		note:      note,
+		url:       mediaUrl,
+		writeNfo:  *writeNfoFlag && o.roles[moviePath] != extraRole,
+		artwork:   *downloadArtworkFlag && o.roles[moviePath] != extraRole,
+	}
+	if match, ok := o.matches[moviePath]; ok && match.Method != "" {
+		p.match = &match
+	}
+
+	if !*dryRunFlag && doCopy {
+		if *confirmFlag && !batch {
+			if !confirm(promptStr(fmt.Sprintf(tr("%s? [yN]"), tr(strings.Title(verb)))), o.reader) {
+				o.attention.add(moviePath, skippedAttention, "")
+				session.Skipped()
+				return nil
+			}
+		}
+
+		if o.copies != nil {
+			// recorded by the run loop once the copy is done
+			o.copies.Submit(p)
+			return nil
+		}
+
+		err = o.place(&p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return o.record(session, p)
+}
+
+// placeTmpFile returns the hidden file next to outFile it is placed as
+// before being renamed into place
+func placeTmpFile(outFile string) string {
+	return filepath.Join(filepath.Dir(outFile), fmt.Sprintf(".%s.%s.tmp", filepath.Base(outFile), BinName))
+}
+
+// place copies, moves or links the in file of p to its out file,
+// along with its subtitles and sidecar files
+func (o *Organizer) place(p *placement) error {
+	moviePath, outFile, verb, movie := p.moviePath, p.outFile, p.verb, p.movie
+
+	if *preHookFlag != "" {
+		err := runHook("pre-hook", *preHookFlag, moviePath, outFile, verb, movie)
+		if err != nil {
+			log.Println("Error running pre-hook, skipping:", err)
+			return err
+		}
+	}
+
+	createdDirs, err := mkdirAllCreated(filepath.Dir(outFile), 0755)
+	if err != nil {
+		log.Println("Error creating out directory:", err)
+		return err
+	}
+
+	// the out file is placed under a temporary name and renamed into place,
+	// so that a copy left running after a timeout never writes to it, and an
+	// out file being overwritten is only recycled once the copy succeeded
+	tmpFile := placeTmpFile(outFile)
+	removeTmp := func() { os.Remove(tmpFile) }
+
+	copyStart := time.Now()
+	checksum, err := withPathTimeout(fmt.Sprintf("%s %s", verb, moviePath), outFile, func() (interface{}, error) {
+		// left behind by an interrupted run
+		removeTmp()
+		checksum := ""
+		place := func() error {
+			if verb == "link" {
+				return linkFile(moviePath, tmpFile, *linkFlag)
+			} else if verb == strmVerb {
+				return writeStrm(tmpFile, p.url)
+			}
+			if *verifyFlag != "" {
+				var err error
+				checksum, err = copyFileVerified(moviePath, tmpFile)
+				return err
+			}
+			return CopyFile(moviePath, tmpFile)
+		}
+		var err error
+		if *niceIoFlag {
+			err = withLowPriority(*niceCpuFlag, place)
+		} else {
+			err = place()
+		}
+		return checksum, err
+	}, removeTmp)
+	if err == nil && p.overwrite && *recycleDirFlag != "" {
+		p.displaced, err = recycle(outFile)
+		if err != nil {
+			removeTmp()
+			log.Println("Error recycling out file:", err)
+			return err
+		}
+		fmt.Printf(tr("Recycled %s %s %s\n"), outFile, arrowStr(), p.displaced)
+	}
+	if err == nil {
+		err = os.Rename(tmpFile, outFile)
+	}
+	if err != nil {
+		if !errors.Is(err, ErrOpTimeout) {
+			removeTmp()
+		}
+		log.Println("Error copying file:", err)
+		return err
+	}
+	// renaming a hard link onto the same file leaves it in place
+	removeTmp()
+	p.checksum = checksum.(string)
+
+	p.placed = true
+	if outInfo, err := os.Stat(outFile); err == nil && verb != "link" && verb != strmVerb {
+		p.copied = outInfo.Size()
+		p.copyTime = time.Since(copyStart)
+	}
+
+	if verb == "move" {
+		err = withTimeout(fmt.Sprintf("remove %s", moviePath), func() error {
+			if *trashSourceFlag {
+				return trashSource(moviePath)
+			}
+			return os.Remove(moviePath)
+		})
+		if err != nil {
+			log.Println("Error moving file:", err)
+			return err
+		}
+	}
+
+	if o.ownership != nil {
+		err = o.ownership.Apply(createdDirs, outFile, verb == "link")
+		if err != nil {
+			log.Println("Error setting out file ownership:", err)
+		}
+	}
+
+	if *subtitlesFlag || *sidecarsFlag {
+		err = placeSubtitles(moviePath, outFile, verb == "move")
+		if err != nil {
+			log.Println("Error placing subtitles:", err)
+		}
+	}
+
+	if *sidecarsFlag {
+		err = placeSidecars(moviePath, outFile, verb == "move")
+		if err != nil {
+			log.Println("Error placing sidecar files:", err)
+		}
+	}
+
+	if p.writeNfo {
+		err = writeNfo(providerNamed(o.selector.provider, p.provider), outFile, movie, p.imdbId, p.details)
+		if err != nil {
+			log.Println("Error writing nfo file:", err)
+		}
+	}
+
+	if p.artwork {
+		err = downloadArtwork(providerNamed(o.selector.provider, p.provider), outFile, movie)
+		if err != nil {
+			log.Println("Error downloading artwork:", err)
+		}
+	}
+
+	if *tagMetadataFlag && verb == "link" {
+		fmt.Println(tr("Not tagging out file metadata, it is linked to the in file"))
+	} else if *tagMetadataFlag && isStrm(outFile) {
+		fmt.Println(tr("Not tagging out file metadata, it is a .strm file"))
+	} else if *tagMetadataFlag {
+		err = tagMetadata(moviePath, outFile, movie)
+		if err != nil {
+			log.Println("Error tagging out file metadata:", err)
+		}
+		// tagging rewrites the out file, the checksum of the copy is
+		// replaced by the one of the tagged file when it is recorded
+		p.checksum = ""
+	}
+
+	if *postHookFlag != "" {
+		err = runHook("post-hook", *postHookFlag, moviePath, outFile, verb, movie)
+		if err != nil {
+			log.Println("Error running post-hook:", err)
+		}
+	}
+
+	return nil
+}
+
+// record adds the manifest entry of a placed, or already present, out file
+func (o *Organizer) record(session *Session, p placement) error {
+	if p.placed {
+		o.outIndex.Add(p.outFile)
+	}
+	o.attention.remove(p.moviePath)
+	if p.copied > 0 {
+		session.Copied(p.copied, p.copyTime)
+	}
+
+	checksum := p.checksum
+	if *verifyFlag != "" && checksum == "" && !*dryRunFlag {
+		// out files that were linked, tagged or already in place are hashed as they are
+		var err error
+		checksum, err = fileSha256(p.outFile)
+		if err != nil {
+			log.Println("Error hashing out file:", err)
+		}
+	}
+
+	entry := ManifestEntry{
+		InFile:     p.moviePath,
+		OutFile:    p.outFile,
+		MovieDbId:  p.movie.GetId(),
+		Provider:   p.provider,
+		ImdbId:     p.imdbId,
+		ExtraIds:   extraIds(p.movie),
+		Type:       p.movie.GetType(),
+		Source:     parseSource(p.moviePath),
+		Url:        p.url,
+		Crc32Check: p.crcCheck,
+		Sha256:     checksum,
+		Displaced:  p.displaced,
+		Created:    &p.placed,
+		Tags:       p.tags,
+		Note:       p.note,
+		Match:      p.match,
+		Details:    p.details,
+		User:       currentUsername(),
+		Host:       currentHostname(),
+		Version:    Version,
+		CreatedAt:  time.Now(),
+	}
+	o.manifest = append(o.manifest, entry)
+	o.manifestIndex.Add(len(o.manifest)-1, entry)
+
+	err := appendManifestJournal(o.manifestPath, entry)
+	if err != nil {
+		log.Println("Error updating manifest: ", err)
+		return err
+	}
+
+	session.Placed()
+	return nil
+}
+
+// recordCopies records placements finished in the background in the
+// manifest, keeping failures for the run loop
+func (o *Organizer) recordCopies(session *Session, placements []placement) {
+	for _, p := range placements {
+		err := p.err
+		if err == nil {
+			err = o.record(session, p)
+		}
+		if err != nil {
+			o.copyFailures = append(o.copyFailures, retryItem{p.index, p.moviePath, err})
+		}
+	}
+}
+
+// finishRun prints the run report and emails it when configured
+func finishRun(session *Session) {
+	report := session.Report()
+	if apiUsage != nil {
+		report += apiUsage.EndRun()
+		err := writeApiUsage(*apiUsageFlag, apiUsage)
+		if err != nil {
+			log.Println("Error writing api usage:", err)
+		}
+	}
+	fmt.Printf("\n%s", report)
+
+	if *emailToFlag != "" && (*emailOnFlag != emailOnFailure || session.HasFailures()) {
+		err := sendReport(session.Subject(), report)
+		if err != nil {
+			log.Println("Error sending email report:", err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// OutIndex caches the listings of out directories, so that checking
+// out files for conflicts reads each directory once per run instead
+// of stat-ing every out file on a possibly slow network mount
+type OutIndex struct {
+	dirs map[string]map[string]os.FileInfo
+}
+
+func NewOutIndex() *OutIndex {
+	return &OutIndex{dirs: make(map[string]map[string]os.FileInfo)}
+}
+
+func (x *OutIndex) load(dir string) (map[string]os.FileInfo, error) {
+	if entries, ok := x.dirs[dir]; ok {
+		return entries, nil
+	}
+
+	listed, err := withPathTimeout(fmt.Sprintf("list %s", dir), "", func() (interface{}, error) {
+		entries := make(map[string]os.FileInfo)
+		files, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			entries[f.Name()] = f
+		}
+		return entries, nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := listed.(map[string]os.FileInfo)
+	x.dirs[dir] = entries
+	return entries, nil
+}
+
+// Stat returns the info of path like os.Stat, from the listing of its directory
+func (x *OutIndex) Stat(path string) (os.FileInfo, error) {
+	entries, err := x.load(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	info, ok := entries[filepath.Base(path)]
+	if !ok {
+		return nil, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		// listings don't follow symlinks
+		return statWithTimeout(path)
+	}
+	return info, nil
+}
+
+// Add records a file placed at path
+func (x *OutIndex) Add(path string) {
+	entries, ok := x.dirs[filepath.Dir(path)]
+	if !ok {
+		return
+	}
+	info, err := os.Lstat(path)
+	if err == nil {
+		entries[filepath.Base(path)] = info
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Ownership is applied to placed out files and the directories
+// created for them, so that a media server's account can manage them
+type Ownership struct {
+	uid      int
+	gid      int
+	fileMode os.FileMode
+	dirMode  os.FileMode
+}
+
+// parseOwnership parses "user[:group]" and "filemode[/dirmode]" specs,
+// either may be empty. It returns nil when both are empty.
+func parseOwnership(chownSpec, chmodSpec string) (*Ownership, error) {
+	if chownSpec == "" && chmodSpec == "" {
+		return nil, nil
+	}
+
+	o := &Ownership{uid: -1, gid: -1}
+
+	if chownSpec != "" {
+		parts := strings.SplitN(chownSpec, ":", 2)
+		u, err := user.Lookup(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		o.uid, err = strconv.Atoi(u.Uid)
+		if err != nil {
+			return nil, err
+		}
+		gid := u.Gid
+		if len(parts) == 2 && parts[1] != "" {
+			g, err := user.LookupGroup(parts[1])
+			if err != nil {
+				return nil, err
+			}
+			gid = g.Gid
+		}
+		o.gid, err = strconv.Atoi(gid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if chmodSpec != "" {
+		parts := strings.SplitN(chmodSpec, "/", 2)
+		fileMode, err := strconv.ParseUint(parts[0], 8, 32)
+		if err != nil || fileMode > 0777 {
+			return nil, fmt.Errorf("invalid file mode %q", parts[0])
+		}
+		o.fileMode = os.FileMode(fileMode)
+		if len(parts) == 2 {
+			dirMode, err := strconv.ParseUint(parts[1], 8, 32)
+			if err != nil || dirMode > 0777 {
+				return nil, fmt.Errorf("invalid directory mode %q", parts[1])
+			}
+			o.dirMode = os.FileMode(dirMode)
+		}
+	}
+
+	return o, nil
+}
+
+func (o *Ownership) applyTo(path string, mode os.FileMode) error {
+	if o.uid >= 0 {
+		err := os.Lchown(path, o.uid, o.gid)
+		if err != nil {
+			return err
+		}
+	}
+	if mode != 0 {
+		return os.Chmod(path, mode)
+	}
+	return nil
+}
+
+// Apply sets ownership and modes of outFile and of dirs, the directories
+// created for it. The file itself is left alone when skipFile is set, ie.
+// because it is linked to an in file.
+func (o *Ownership) Apply(dirs []string, outFile string, skipFile bool) error {
+	for _, dir := range dirs {
+		err := o.applyTo(dir, o.dirMode)
+		if err != nil {
+			return err
+		}
+	}
+
+	if skipFile {
+		return nil
+	}
+	return o.applyTo(outFile, o.fileMode)
+}
+
+// mkdirAllCreated is os.MkdirAll returning the directories it created,
+// outermost first
+func mkdirAllCreated(dir string, perm os.FileMode) ([]string, error) {
+	missing := []string{}
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || !os.IsNotExist(err) {
+			break
+		}
+		missing = append([]string{d}, missing...)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	err := os.MkdirAll(dir, perm)
+	if err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
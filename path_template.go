@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var resolutionReg = regexp.MustCompile(`(?i)^(480|576|720|1080|2160|4320)[pi]$`)
+
+// out path templates set with -movie-template and -tv-template,
+// nil renders the default GetPath format
+var (
+	movieTemplate *template.Template
+	tvTemplate    *template.Template
+)
+
+type namingPreset struct {
+	movie string
+	tv    string
+}
+
+// namingPresets follow the naming conventions recommended by media servers,
+// with moviedb or tvdb ids tagged the way each server reads them
+var namingPresets = map[string]namingPreset{
+	"plex": {
+		movie: `{{.Title}} ({{.Year}}) {{"{"}}{{.IdSource}}-{{.Id}}}/{{.Title}} ({{.Year}}) {{"{"}}{{.IdSource}}-{{.Id}}}`,
+		tv:    `{{.Show}} ({{.Year}}) {{"{"}}{{.IdSource}}-{{.ShowId}}}/Season {{printf "%02d" .Season}}/{{.Show}} ({{.Year}}) - {{.Episodes}} - {{.EpisodeTitle}}`,
+	},
+	"jellyfin": {
+		movie: `{{.Title}} ({{.Year}}) [{{.IdSource}}id-{{.Id}}]/{{.Title}} ({{.Year}}) [{{.IdSource}}id-{{.Id}}]`,
+		tv:    `{{.Show}} ({{.Year}}) [{{.IdSource}}id-{{.ShowId}}]/Season {{printf "%02d" .Season}}/{{.Show}} {{.Episodes}} {{.EpisodeTitle}}`,
+	},
+	"kodi": {
+		movie: `{{.Title}} ({{.Year}})/{{.Title}} ({{.Year}})`,
+		tv:    `{{.Show}} ({{.Year}})/Season {{printf "%02d" .Season}}/{{.Show}} {{.Episodes}}`,
+	},
+}
+
+// presetTemplates returns the movie and tv templates of a naming preset,
+// templates given explicitly take precedence
+func presetTemplates(preset, movieStr, tvStr string) (string, string, error) {
+	if preset == "" {
+		return movieStr, tvStr, nil
+	}
+
+	p, ok := namingPresets[preset]
+	if !ok {
+		return "", "", fmt.Errorf("invalid naming preset %q, must be one of: plex, jellyfin, kodi", preset)
+	}
+	if movieStr == "" {
+		movieStr = p.movie
+	}
+	if tvStr == "" {
+		tvStr = p.tv
+	}
+	return movieStr, tvStr, nil
+}
+
+// PathFields are the fields available to out path templates
+type PathFields struct {
+	Id        int64
+	IdSource  string
+	ShowId    int64
+	Title     string
+	SortTitle string
+	// names in the original language, and the translated names when they
+	// differ from those, empty otherwise
+	OriginalTitle  string
+	OriginalShow   string
+	LocalizedTitle string
+	LocalizedShow  string
+	Year           string
+	Folder         string
+	Show           string
+	SortShow       string
+	Season         int
+	Episode        int
+	LastEpisode    int
+	EpisodeTitle   string
+	Episodes       string
+	Source         string
+	Resolution     string
+	VideoCodec     string
+	AudioCodec     string
+}
+
+// parsePathTemplate parses an out path template, checking that
+// it only refers to known fields
+func parsePathTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	err = t.Execute(ioutil.Discard, PathFields{})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parsePathTemplates parses the movie and tv templates, empty ones are left unset
+func parsePathTemplates(movieStr, tvStr string) error {
+	var err error
+	movieTemplate, err = parsePathTemplate("movie-template", movieStr)
+	if err != nil {
+		return err
+	}
+	tvTemplate, err = parsePathTemplate("tv-template", tvStr)
+	return err
+}
+
+// parseResolution returns the resolution token of a file name, ie. "1080p"
+func parseResolution(moviePath string) string {
+	for _, token := range strings.Fields(wordReg.ReplaceAllString(fNameSansExtension(moviePath), " ")) {
+		if resolutionReg.MatchString(token) {
+			return strings.ToLower(token)
+		}
+	}
+	return ""
+}
+
+// usesMediaInfo reports whether rendering t needs ffprobe
+func usesMediaInfo(t *template.Template) bool {
+	text := t.Root.String()
+	return strings.Contains(text, ".Resolution") || strings.Contains(text, ".VideoCodec") || strings.Contains(text, ".AudioCodec")
+}
+
+// idSource names the provider of ids the way media servers tag them
+func idSource(provider string) string {
+	if provider == tvdbProvider {
+		return "tvdb"
+	}
+	return "tmdb"
+}
+
+func newPathFields(moviePath string, media Media, provider string, probe bool) PathFields {
+	fields := PathFields{
+		Id:       media.GetId(),
+		IdSource: idSource(provider),
+		Title:    sanitizeFileName(media.GetName()),
+		Year:     media.GetYear(),
+		Source:   parseSource(moviePath),
+	}
+
+	switch m := media.(type) {
+	case Movie:
+		fields.Folder = m.PathFolder
+		fields.OriginalTitle = sanitizeFileName(m.OriginalTitle)
+	case TvEpisode:
+		fields.Folder = m.PathFolder
+		fields.ShowId = m.TvId
+		fields.Show = sanitizeFileName(m.TvName)
+		fields.Title = fields.Show
+		fields.OriginalShow = sanitizeFileName(m.TvOriginalName)
+		fields.OriginalTitle = fields.OriginalShow
+		fields.EpisodeTitle = sanitizeFileName(m.Name)
+		fields.Season = m.SeasonNumber + m.SeasonOffset
+		fields.Episode = m.EpisonNumber + m.EpisodeOffset
+		fields.Episodes = fmt.Sprintf("S%02dE%02d", fields.Season, fields.Episode)
+		if m.LastEpisode > m.EpisonNumber {
+			fields.LastEpisode = m.LastEpisode + m.EpisodeOffset
+			fields.Episodes += fmt.Sprintf("-E%02d", fields.LastEpisode)
+		}
+	}
+
+	fields.OriginalTitle, fields.LocalizedTitle = dualNames(fields.OriginalTitle, fields.Title)
+	fields.OriginalShow, fields.LocalizedShow = dualNames(fields.OriginalShow, fields.Show)
+	if *preferOriginalTitleFlag {
+		fields.Title, fields.Show = fields.OriginalTitle, fields.OriginalShow
+	}
+
+	articles := splitCsv(*articleListFlag)
+	fields.SortTitle = sortTitle(fields.Title, moveArticles, articles)
+	fields.SortShow = sortTitle(fields.Show, moveArticles, articles)
+
+	fields.Resolution = parseResolution(moviePath)
+	if probe && ffprobeAvailable() {
+		info, err := probeMediaInfo(moviePath)
+		if err == nil {
+			fields.VideoCodec = info.VideoCodec
+			fields.AudioCodec = info.AudioCodec
+			if fields.Resolution == "" && info.Height > 0 {
+				fields.Resolution = fmt.Sprintf("%dp", info.Height)
+			}
+		}
+	}
+
+	return fields
+}
+
+// dualNames returns the original name, the translated name when there is
+// none, and the translated name when it differs from the original one
+func dualNames(original, translated string) (string, string) {
+	if original == "" || strings.EqualFold(original, translated) {
+		return translated, ""
+	}
+	return original, translated
+}
+
+// renderPath renders an out path template, relative to the out dir
+// and separated by forward slashes
+func renderPath(t *template.Template, moviePath string, media Media, provider string) (string, error) {
+	var b bytes.Buffer
+	err := t.Execute(&b, newPathFields(moviePath, media, provider, usesMediaInfo(t)))
+	if err != nil {
+		return "", err
+	}
+
+	// drop empty brackets, doubled spaces and empty directories left by
+	// empty fields, ie. an empty {{.Folder}}/, accepting backslash
+	// separators in templates written on windows
+	rendered := strings.NewReplacer("[]", "", "()", "").Replace(filepath.ToSlash(b.String()))
+	parts := []string{}
+	for _, part := range strings.Split(rendered, "/") {
+		if part = strings.Join(strings.Fields(part), " "); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	rendered = path.Clean(strings.Join(parts, "/"))
+
+	if rendered == "." || rendered == ".." || strings.HasPrefix(rendered, "../") ||
+		path.IsAbs(rendered) || filepath.VolumeName(filepath.FromSlash(rendered)) != "" {
+		return "", fmt.Errorf("%s rendered invalid path %q", t.Name(), rendered)
+	}
+	return rendered, nil
+}
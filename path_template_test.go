@@ -0,0 +1,82 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"text/template"
+)
+
+var (
+	testMovie   = Movie{Id: 603, Title: "The Matrix", OriginalTitle: "The Matrix", ReleaseDate: "1999-03-30"}
+	testEpisode = TvEpisode{Id: 62085, Name: "Pilot", EpisonNumber: 1, SeasonNumber: 1, TvId: 1396, TvName: "Breaking Bad", TvOriginalName: "Breaking Bad", FirstAirDate: "2008-01-20"}
+)
+
+func TestRenderPath(t *testing.T) {
+	tests := []struct {
+		template string
+		media    Media
+		want     string
+	}{
+		{`{{.Title}} ({{.Year}})/{{.Title}} ({{.Year}})`, testMovie, "The Matrix (1999)/The Matrix (1999)"},
+		{`{{.Folder}}/{{.Title}} ({{.Year}})`, testMovie, "The Matrix (1999)"},
+		{`{{.Folder}}/{{.Folder}}/{{.Title}} [{{.Resolution}}]`, testMovie, "The Matrix"},
+		{`{{.Show}}/Season {{printf "%02d" .Season}}/{{.Show}} {{.Episodes}}`, testEpisode, "Breaking Bad/Season 01/Breaking Bad S01E01"},
+		{`{{.Folder}}/{{.Show}} {{.Episodes}} {{.EpisodeTitle}}`, testEpisode, "Breaking Bad S01E01 Pilot"},
+	}
+	for _, test := range tests {
+		tmpl, err := parsePathTemplate("test", test.template)
+		if err != nil {
+			t.Fatalf("parsePathTemplate(%q) error: %v", test.template, err)
+		}
+		got, err := renderPath(tmpl, "/in/file.mkv", test.media, movieDbProvider)
+		if err != nil {
+			t.Errorf("renderPath(%q) error: %v", test.template, err)
+		} else if got != test.want {
+			t.Errorf("renderPath(%q) = %q, want %q", test.template, got, test.want)
+		}
+	}
+}
+
+func TestRenderPathInvalid(t *testing.T) {
+	for _, text := range []string{`{{.Folder}}`, `..`, `{{.Folder}}/../{{.Title}}/../..`} {
+		tmpl, err := parsePathTemplate("test", text)
+		if err != nil {
+			t.Fatalf("parsePathTemplate(%q) error: %v", text, err)
+		}
+		if got, err := renderPath(tmpl, "/in/file.mkv", testMovie, movieDbProvider); err == nil {
+			t.Errorf("renderPath(%q) = %q, want error", text, got)
+		}
+	}
+}
+
+func TestBuildOutFile(t *testing.T) {
+	defer func(t *template.Template) { movieTemplate = t }(movieTemplate)
+
+	tests := []struct {
+		outDir        string
+		movieTemplate string
+		media         Media
+		want          string
+	}{
+		{`D:\Media`, "", testMovie, "The Matrix (1999)/The Matrix (1999)"},
+		{`\\nas\media`, "", testMovie, "The Matrix (1999)/The Matrix (1999)"},
+		{`\\nas\media`, "", testEpisode, "Breaking Bad (2008)/Breaking Bad (2008) S01E01"},
+		{`D:\Media`, `{{.Folder}}/{{.Title}} ({{.Year}})`, testMovie, "The Matrix (1999)"},
+		{`\\nas\media`, `{{.Folder}}/{{.Title}} ({{.Year}})`, testMovie, "The Matrix (1999)"},
+		{"/media", `{{.Folder}}/{{.Title}}`, testMovie, "The Matrix"},
+	}
+	for _, test := range tests {
+		var err error
+		movieTemplate, err = parsePathTemplate("movie-template", test.movieTemplate)
+		if err != nil {
+			t.Fatalf("parsePathTemplate(%q) error: %v", test.movieTemplate, err)
+		}
+		got, err := buildOutFile("/in/The.Matrix.1999.mkv", test.outDir, test.media, movieDbProvider)
+		want := filepath.Join(test.outDir, filepath.FromSlash(test.want)) + ".mkv"
+		if err != nil {
+			t.Errorf("buildOutFile(%q, %q) error: %v", test.outDir, test.movieTemplate, err)
+		} else if got != want {
+			t.Errorf("buildOutFile(%q, %q) = %q, want %q", test.outDir, test.movieTemplate, got, want)
+		}
+	}
+}
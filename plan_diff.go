@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// printPlanDiff compares the entries planned by a dry run with those of a
+// previous dry-run manifest, printing only in files whose out file changed,
+// in files that are newly planned, and in files that are no longer planned
+func printPlanDiff(oldPlan, manifest, planned []ManifestEntry, movieList []string) {
+	oldOut := make(map[string]string, len(oldPlan))
+	for _, e := range oldPlan {
+		oldOut[e.InFile] = e.OutFile
+	}
+
+	inManifest := make(map[string]bool, len(manifest))
+	for _, e := range manifest {
+		inManifest[e.InFile] = true
+	}
+
+	changed, added, removed := 0, 0, 0
+	fmt.Println()
+	for _, e := range planned {
+		out, ok := oldOut[e.InFile]
+		if !ok {
+			fmt.Printf("%s %s %s %s\n", ColorStr(GreenColor, "+"), e.InFile, arrowStr(), ColorStr(GreenColor, e.OutFile))
+			added += 1
+		} else if out != e.OutFile {
+			fmt.Printf("%s %s\n", ColorStr(YellowColor, "~"), e.InFile)
+			fmt.Printf("    %s %s\n", ColorStr(RedColor, "-"), ColorStr(RedColor, out))
+			fmt.Printf("    %s %s\n", ColorStr(GreenColor, "+"), ColorStr(GreenColor, e.OutFile))
+			changed += 1
+		}
+	}
+
+	notPlanned := []string{}
+	for _, moviePath := range movieList {
+		if _, ok := oldOut[moviePath]; ok && !inManifest[moviePath] {
+			notPlanned = append(notPlanned, moviePath)
+		}
+	}
+	sort.Strings(notPlanned)
+	for _, moviePath := range notPlanned {
+		fmt.Printf("%s %s %s %s\n", ColorStr(RedColor, "-"), moviePath, arrowStr(), ColorStr(RedColor, oldOut[moviePath]))
+		removed += 1
+	}
+
+	fmt.Printf(tr("Plan diff: %d changed, %d added, %d no longer planned\n"), changed, added, removed)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+var windowsReplacer = strings.NewReplacer(":", " -", "<", "", ">", "", "\"", "'", "|", " ", "?", "", "*", "")
+
+// sanitizeFileName removes characters that are not allowed in file names
+func sanitizeFileName(name string) string {
+	replacer := strings.NewReplacer("/", " ", "\\", " ", "\x00", "")
+	name = replacer.Replace(name)
+	if runtime.GOOS == "windows" {
+		name = windowsReplacer.Replace(name)
+	}
+	return strings.TrimSpace(name)
+}
+
+// previewOutFile shows the rendered out file and lets the user
+// edit its file name, keeping the directory and extension
+func previewOutFile(outFile string, reader *bufio.Reader) string {
+	for {
+		fmt.Printf(tr("Out file: %s\n"), ColorStr(GreenColor, outFile))
+		fmt.Print(promptStr(tr("[e] edit file name, enter to accept")))
+		raw, err := reader.ReadString('\n')
+		if err != nil {
+			return outFile
+		}
+
+		if strings.ToLower(strings.TrimSpace(raw)) != "e" {
+			return outFile
+		}
+
+		ext := filepath.Ext(outFile)
+		current := filepath.Base(outFile[0 : len(outFile)-len(ext)])
+		fmt.Printf(tr("Current file name: %s\n"), current)
+		fmt.Print(promptStr(tr("New file name (without extension)")))
+		raw, err = reader.ReadString('\n')
+		if err != nil {
+			return outFile
+		}
+
+		name := sanitizeFileName(raw)
+		if name == "" {
+			continue
+		}
+		outFile = filepath.Join(filepath.Dir(outFile), name+ext)
+	}
+}
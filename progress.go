@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	isatty "github.com/mattn/go-isatty"
+)
+
+var progressInterval = 250 * time.Millisecond
+
+// showProgress reports whether copies print an updating progress line,
+// which needs a terminal and is avoided for screen readers and for
+// background copies that would overwrite each other's line
+func showProgress() bool {
+	fd := os.Stdout.Fd()
+	return !plainOutput && *copyWorkersFlag == 0 && (isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd))
+}
+
+// progressLine formats the copy progress, with a bar filling the rest of width
+func progressLine(written, total int64, elapsed time.Duration, width int) string {
+	percent := 100
+	if total > 0 {
+		percent = int(written * 100 / total)
+	}
+	// sources growing while they are copied write more than their size
+	if percent > 100 {
+		percent = 100
+	} else if percent < 0 {
+		percent = 0
+	}
+
+	rate := int64(0)
+	eta := "-"
+	if elapsed > 0 && written > 0 {
+		rate = int64(float64(written) / elapsed.Seconds())
+		remaining := time.Duration(float64(total-written)/float64(written)*float64(elapsed)) / time.Second * time.Second
+		if remaining < 0 {
+			remaining = 0
+		}
+		eta = remaining.String()
+	}
+
+	text := fmt.Sprintf("%3d%% %s / %s %s/s ETA %s", percent, humanize.Bytes(uint64(written)), humanize.Bytes(uint64(total)), humanize.Bytes(uint64(rate)), eta)
+
+	// leave room for the brackets and a space
+	barWidth := width - len(text) - 3
+	if barWidth < 10 {
+		return text
+	}
+	done := barWidth * percent / 100
+	bar := strings.Repeat("=", done) + strings.Repeat(" ", barWidth-done)
+	return fmt.Sprintf("[%s] %s", bar, text)
+}
+
+// startCountProgress prints the number of items done so far in place until
+// the returned function is called, ie. the directories scanned by clean
+func startCountProgress(format string, count func() int64) func() {
+	fd := os.Stdout.Fd()
+	if plainOutput || !(isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)) {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		printed := ""
+		for {
+			select {
+			case <-done:
+				if printed != "" {
+					fmt.Printf("\r%s\r", strings.Repeat(" ", len(printed)))
+				}
+				return
+			case <-ticker.C:
+				printed = fmt.Sprintf(format, count())
+				fmt.Printf("\r%s", printed)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// startCopyProgress prints the progress of a copy of total bytes to dst
+// in place, by polling the size of dst so that the copy itself can still
+// be handed to the kernel. The returned function stops it and clears the line.
+func startCopyProgress(dst *os.File, total int64) func() {
+	if !showProgress() {
+		return func() {}
+	}
+
+	width, err := terminalWidth()
+	if err != nil {
+		width = 80
+	}
+	// the cursor must not wrap to the next line
+	width -= 1
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		start := time.Now()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		printed := false
+		for {
+			select {
+			case <-done:
+				if printed {
+					fmt.Printf("\r%s\r", strings.Repeat(" ", width))
+				}
+				return
+			case <-ticker.C:
+				info, err := dst.Stat()
+				if err != nil {
+					continue
+				}
+				fmt.Printf("\r%s", progressLine(info.Size(), total, time.Since(start), width))
+				printed = true
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
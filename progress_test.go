@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressLine(t *testing.T) {
+	line := progressLine(50, 100, time.Second, 80)
+	if !strings.HasPrefix(line, "[") || !strings.Contains(line, " 50% ") || len(line) != 80 {
+		t.Errorf("progressLine(50, 100) = %q, want a half full bar of width 80", line)
+	}
+
+	line = progressLine(1000, 10, 0, 20)
+	if !strings.HasPrefix(line, "100% ") {
+		t.Errorf("progressLine without room for a bar = %q, want only the text", line)
+	}
+}
+
+func TestProgressLineGrowingSource(t *testing.T) {
+	// the source grew after its size was read
+	line := progressLine(150, 100, time.Second, 80)
+	end := strings.Index(line, "]")
+	if !strings.HasPrefix(line, "[") || end < 0 || strings.Trim(line[1:end], "=") != "" {
+		t.Fatalf("progressLine(150, 100) = %q, want a full bar", line)
+	}
+	if !strings.Contains(line, "100% ") || !strings.HasSuffix(line, "ETA 0s") {
+		t.Errorf("progressLine(150, 100) = %q, want 100%% and no time remaining", line)
+	}
+}
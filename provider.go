@@ -0,0 +1,88 @@
+package main
+
+import "fmt"
+
+const (
+	movieDbProvider = "moviedb"
+	tvdbProvider    = "tvdb"
+)
+
+// where api keys of the providers are managed
+var providerApiKeyUrls = map[string]string{
+	movieDbProvider: "https://www.themoviedb.org/settings/api",
+	tvdbProvider:    "https://thetvdb.com/dashboard/account/apikey",
+}
+
+// MetadataProvider looks up movies, tv shows and their episodes
+type MetadataProvider interface {
+	Name() string
+	Ping() error
+	RecordHttp(dir string)
+	ReplayHttp(dir string)
+	SearchMovie(query string, page, year int) (SearchMovieResponse, error)
+	SearchTv(query string, page, year int) (SearchTvResponse, error)
+	GetMovie(movieId int64) (Movie, error)
+	GetTv(tvId int64) (Tv, error)
+	GetTvSeason(tv Tv, seasonNumber int) (TvSeason, error)
+	PrefetchTvSeasons(tv Tv, seasonNumbers ...int)
+	GetTvEpisodeGroups(tvId int64) (EpisodeGroupsResponse, error)
+	GetEpisodeGroupSeason(tv Tv, groupId string, seasonNumber int) (TvSeason, error)
+	GetMovieCredits(movieId int64) (Credits, error)
+	GetTvCredits(tvId int64) (Credits, error)
+	GetMovieExternalIds(movieId int64) (ExternalIds, error)
+	GetTvExternalIds(tvId int64) (ExternalIds, error)
+	GetEpisodeExternalIds(episode TvEpisode) (ExternalIds, error)
+	FindByImdbId(imdbId string) (FindResponse, error)
+}
+
+func newProvider(name, apiKey string) (MetadataProvider, error) {
+	switch name {
+	case movieDbProvider:
+		movieDb := NewMovieDb(apiKey)
+		movieDb.Language = *languageFlag
+		return movieDb, nil
+	case tvdbProvider:
+		return NewTvdb(apiKey), nil
+	default:
+		return nil, fmt.Errorf("invalid provider %q, must be one of: %s, %s", name, movieDbProvider, tvdbProvider)
+	}
+}
+
+// providerApiKey returns the api key of a provider from the config file,
+// -api-key is the key of the first provider when it is not configured
+func providerApiKey(name string, first bool, apiKey string, apiKeys map[string]string) string {
+	if key := apiKeys[name]; key != "" {
+		return key
+	}
+	if first {
+		return apiKey
+	}
+	return ""
+}
+
+// newProviders creates the providers of a CSV list, chained when there is
+// more than one. Every provider needs an api key unless replaying.
+func newProviders(names, apiKey string, apiKeys map[string]string, replaying bool) (MetadataProvider, error) {
+	providers := []MetadataProvider{}
+	for i, name := range splitCsv(names) {
+		key := providerApiKey(name, i == 0, apiKey, apiKeys)
+		if key == "" && !replaying {
+			if i == 0 {
+				return nil, fmt.Errorf("api-key is required")
+			}
+			return nil, fmt.Errorf("no api key for %s, set it in api_keys of the config file", name)
+		}
+		provider, err := newProvider(name, key)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("provider is required")
+	} else if len(providers) == 1 {
+		return providers[0], nil
+	}
+	return NewProviderChain(providers), nil
+}
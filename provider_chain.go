@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ProviderError names the provider of a chain that failed
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Provider, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderChain searches the next provider when a search finds nothing or
+// the provider is unreachable. Ids are only valid within their provider,
+// so lookups go to the provider that answered the last search.
+type ProviderChain struct {
+	providers []MetadataProvider
+
+	mu      sync.Mutex
+	current MetadataProvider
+}
+
+func NewProviderChain(providers []MetadataProvider) *ProviderChain {
+	return &ProviderChain{providers: providers, current: providers[0]}
+}
+
+func (c *ProviderChain) active() MetadataProvider {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+func (c *ProviderChain) use(provider MetadataProvider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current != provider {
+		fmt.Printf(tr("Searching %s\n"), provider.Name())
+	}
+	c.current = provider
+}
+
+// member returns the provider of the chain called name
+func (c *ProviderChain) member(name string) (MetadataProvider, bool) {
+	for _, provider := range c.providers {
+		if provider.Name() == name {
+			return provider, true
+		}
+	}
+	return nil, false
+}
+
+// providerNamed returns the provider called name of a chain, or provider itself
+func providerNamed(provider MetadataProvider, name string) MetadataProvider {
+	if chain, ok := provider.(*ProviderChain); ok {
+		if member, ok := chain.member(name); ok {
+			return member
+		}
+	}
+	return provider
+}
+
+// useProvider makes the provider called name the one a chain looks ids up
+// with, it returns false when provider neither is nor has a provider called name
+func useProvider(provider MetadataProvider, name string) bool {
+	member := providerNamed(provider, name)
+	if member.Name() != name {
+		return false
+	}
+	if chain, ok := provider.(*ProviderChain); ok && member != provider {
+		chain.use(member)
+	}
+	return true
+}
+
+// providerNames returns the name of provider, or the names of the providers
+// of a chain starting with the one ids are looked up with
+func providerNames(provider MetadataProvider) []string {
+	names := []string{provider.Name()}
+	if chain, ok := provider.(*ProviderChain); ok {
+		for _, member := range chain.providers {
+			if member.Name() != names[0] {
+				names = append(names, member.Name())
+			}
+		}
+	}
+	return names
+}
+
+// search runs fn with each provider in order until one finds results,
+// skipping providers that are unreachable. Further pages go to the
+// provider of the first page.
+func (c *ProviderChain) search(page int, fn func(MetadataProvider) (int, error)) {
+	if page > 1 {
+		fn(c.active())
+		return
+	}
+
+	for i, provider := range c.providers {
+		n, err := fn(provider)
+		last := i == len(c.providers)-1
+		if err == nil && n > 0 || err != nil && !isTransient(err) || last {
+			c.use(provider)
+			return
+		}
+	}
+}
+
+func (c *ProviderChain) Name() string {
+	return c.active().Name()
+}
+
+// Ping checks every provider of the chain
+func (c *ProviderChain) Ping() error {
+	var firstErr error
+	for _, provider := range c.providers {
+		err := provider.Ping()
+		if errors.Is(err, ErrInvalidApiKey) {
+			return &ProviderError{provider.Name(), err}
+		} else if err != nil && firstErr == nil {
+			firstErr = &ProviderError{provider.Name(), err}
+		}
+	}
+	return firstErr
+}
+
+func (c *ProviderChain) RecordHttp(dir string) {
+	for _, provider := range c.providers {
+		provider.RecordHttp(dir)
+	}
+}
+
+func (c *ProviderChain) ReplayHttp(dir string) {
+	for _, provider := range c.providers {
+		provider.ReplayHttp(dir)
+	}
+}
+
+func (c *ProviderChain) SearchMovie(query string, page, year int) (response SearchMovieResponse, err error) {
+	c.search(page, func(provider MetadataProvider) (int, error) {
+		response, err = provider.SearchMovie(query, page, year)
+		return len(response.Results), err
+	})
+	return
+}
+
+func (c *ProviderChain) SearchTv(query string, page, year int) (response SearchTvResponse, err error) {
+	c.search(page, func(provider MetadataProvider) (int, error) {
+		response, err = provider.SearchTv(query, page, year)
+		return len(response.Results), err
+	})
+	return
+}
+
+// FindByImdbId asks the providers in order, imdb ids are valid in all of them
+func (c *ProviderChain) FindByImdbId(imdbId string) (response FindResponse, err error) {
+	c.search(1, func(provider MetadataProvider) (int, error) {
+		response, err = provider.FindByImdbId(imdbId)
+		return len(response.MovieResults) + len(response.TvResults), err
+	})
+	return
+}
+
+func (c *ProviderChain) GetMovie(movieId int64) (Movie, error) {
+	return c.active().GetMovie(movieId)
+}
+
+func (c *ProviderChain) GetTv(tvId int64) (Tv, error) {
+	return c.active().GetTv(tvId)
+}
+
+func (c *ProviderChain) GetTvSeason(tv Tv, seasonNumber int) (TvSeason, error) {
+	return c.active().GetTvSeason(tv, seasonNumber)
+}
+
+func (c *ProviderChain) PrefetchTvSeasons(tv Tv, seasonNumbers ...int) {
+	c.active().PrefetchTvSeasons(tv, seasonNumbers...)
+}
+
+func (c *ProviderChain) GetTvEpisodeGroups(tvId int64) (EpisodeGroupsResponse, error) {
+	return c.active().GetTvEpisodeGroups(tvId)
+}
+
+func (c *ProviderChain) GetEpisodeGroupSeason(tv Tv, groupId string, seasonNumber int) (TvSeason, error) {
+	return c.active().GetEpisodeGroupSeason(tv, groupId, seasonNumber)
+}
+
+func (c *ProviderChain) GetMovieCredits(movieId int64) (Credits, error) {
+	return c.active().GetMovieCredits(movieId)
+}
+
+func (c *ProviderChain) GetTvCredits(tvId int64) (Credits, error) {
+	return c.active().GetTvCredits(tvId)
+}
+
+func (c *ProviderChain) GetMovieExternalIds(movieId int64) (ExternalIds, error) {
+	return c.active().GetMovieExternalIds(movieId)
+}
+
+func (c *ProviderChain) GetTvExternalIds(tvId int64) (ExternalIds, error) {
+	return c.active().GetTvExternalIds(tvId)
+}
+
+func (c *ProviderChain) GetEpisodeExternalIds(episode TvEpisode) (ExternalIds, error) {
+	return c.active().GetEpisodeExternalIds(episode)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+var defaultQualityLadder = []string{"av1", "hevc", "h264", "vp9", "vc1", "mpeg4", "mpeg2video"}
+
+func parseQualityLadder(csv string) []string {
+	ladder := splitCsv(csv)
+	for i, codec := range ladder {
+		ladder[i] = strings.ToLower(codec)
+	}
+	return ladder
+}
+
+// codecRank returns the position of codec in the quality ladder,
+// lower is better. Unknown codecs rank below all known codecs.
+func codecRank(codec string, ladder []string) int {
+	for i, c := range ladder {
+		if c == strings.ToLower(codec) {
+			return i
+		}
+	}
+	return len(ladder)
+}
+
+// isUpgrade reports whether in is measurably better than out,
+// first by resolution and then by codec preference
+func isUpgrade(in, out MediaInfo, ladder []string) (bool, string) {
+	if in.Height > out.Height {
+		return true, fmt.Sprintf("higher resolution (%s > %s)", in.Resolution(), out.Resolution())
+	} else if in.Height < out.Height {
+		return false, fmt.Sprintf("lower resolution (%s < %s)", in.Resolution(), out.Resolution())
+	}
+
+	inRank := codecRank(in.VideoCodec, ladder)
+	outRank := codecRank(out.VideoCodec, ladder)
+	if inRank < outRank {
+		return true, fmt.Sprintf("preferred codec (%s over %s)", in.VideoCodec, out.VideoCodec)
+	} else if inRank > outRank {
+		return false, fmt.Sprintf("less preferred codec (%s under %s)", in.VideoCodec, out.VideoCodec)
+	}
+
+	return false, "same quality"
+}
+
+// resolveUpgrade replaces the out file only when the in file is an upgrade
+func resolveUpgrade(inFile, outFile string, ladder []string) conflictAction {
+	if !ffprobeAvailable() {
+		fmt.Println(tr("Upgrade: ffprobe not found, skipping"))
+		return skipAction
+	}
+
+	inInfo, err := probeMediaInfo(inFile)
+	if err != nil {
+		fmt.Println(tr("Upgrade: unable to probe in file, skipping:"), err)
+		return skipAction
+	}
+
+	outInfo, err := probeMediaInfo(outFile)
+	if err != nil {
+		fmt.Println(tr("Upgrade: unable to probe out file, skipping:"), err)
+		return skipAction
+	}
+
+	upgrade, reason := isUpgrade(inInfo, outInfo, ladder)
+	if upgrade {
+		fmt.Printf(tr("Upgrade: %s, replacing\n"), reason)
+		return overwriteAction
+	}
+
+	fmt.Printf(tr("Upgrade: %s, skipping\n"), reason)
+	return skipAction
+}
@@ -0,0 +1,46 @@
+package main
+
+import "sort"
+
+// originalLanguage returns the original language of movie and tv show results
+func originalLanguage(media Media) string {
+	switch m := media.(type) {
+	case Movie:
+		return m.OriginalLanguage
+	case Tv:
+		return m.OriginalLanguage
+	default:
+		return ""
+	}
+}
+
+// preferLanguages moves results with one of the given original languages
+// to the top, keeping the moviedb order otherwise. With only, other results
+// are removed, unless none of the results match.
+func preferLanguages(results []Media, languages []string, only bool) []Media {
+	if len(languages) == 0 {
+		return results
+	}
+
+	ranked := make([]Media, len(results))
+	copy(ranked, results)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return stringSliceContains(languages, originalLanguage(ranked[i])) &&
+			!stringSliceContains(languages, originalLanguage(ranked[j]))
+	})
+
+	if !only {
+		return ranked
+	}
+
+	filtered := []Media{}
+	for _, result := range ranked {
+		if stringSliceContains(languages, originalLanguage(result)) {
+			filtered = append(filtered, result)
+		}
+	}
+	if len(filtered) == 0 {
+		return ranked
+	}
+	return filtered
+}
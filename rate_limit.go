@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces out requests to at most a given number per second
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter returns nil, which never waits, when perSecond is not positive
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// Wait blocks until the next request may be sent
+func (l *rateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(wait)
+}
+
+// seasonFetchLimiter throttles the seasons fetched in the background
+var seasonFetchLimiter *rateLimiter
+
+// apiLimiter throttles all requests to the metadata providers
+var apiLimiter *rateLimiter
+
+// wait before the first repeat of a failed api request without a
+// Retry-After header, doubled for each following one
+const apiRetryBackoff = time.Second
+
+// withApiRetry waits for apiLimiter and runs send, running it again with
+// exponential backoff, or after the wait the provider asked for, while it
+// fails with a temporary api error, up to api-retries times
+func withApiRetry(send func() error) error {
+	for attempt := 1; ; attempt++ {
+		apiLimiter.Wait()
+		err := send()
+
+		var apiErr *MovieDbError
+		if err == nil || attempt > *apiRetriesFlag || !errors.As(err, &apiErr) || !apiErr.Temporary() {
+			return err
+		}
+
+		wait := apiErr.RetryAfter
+		if wait <= 0 {
+			wait = retryBackoff(apiRetryBackoff, attempt)
+		}
+		fmt.Printf(tr("%s, retrying in %s (attempt %d/%d)\n"), err, wait, attempt, *apiRetriesFlag)
+		time.Sleep(wait)
+	}
+}
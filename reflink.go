@@ -0,0 +1,25 @@
+package main
+
+import "os"
+
+// reflinkFile makes dst a copy of src that shares its blocks until either is
+// written, on file systems with copy on write like btrfs and xfs. Unlike hard
+// links this also works across btrfs subvolumes, and dst is a file of its own.
+func reflinkFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return
+	}
+	defer func() {
+		cerr := out.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+	return reflink(out, in)
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+func reflink(out, in *os.File) error {
+	return errors.New("not supported on this platform")
+}
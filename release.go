@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type sourceTag struct {
+	name   string
+	tokens []string
+}
+
+// source indicators are matched against single lower case tokens
+// of the original file name, and against adjacent token pairs joined
+// together, so that "web-dl" and "web.dl" both match "webdl"
+var sourceTags = []sourceTag{
+	{"BluRay", []string{"bluray", "blueray", "bdrip", "brrip", "bdremux", "bd"}},
+	{"WEB-DL", []string{"webdl"}},
+	{"WEBRip", []string{"webrip"}},
+	{"HDTV", []string{"hdtv", "pdtv"}},
+	{"DVDRip", []string{"dvdrip"}},
+	{"DVDScr", []string{"dvdscr"}},
+	{"DVD", []string{"dvd", "dvdr", "dvd5", "dvd9"}},
+	{"HDRip", []string{"hdrip"}},
+	{"WEB", []string{"web"}},
+}
+
+func sourceTagForToken(token string) string {
+	for _, tag := range sourceTags {
+		if stringSliceContains(tag.tokens, token) {
+			return tag.name
+		}
+	}
+	return ""
+}
+
+// parseSource extracts the release source (BluRay, WEB-DL, HDTV, etc.)
+// from the original file name or its directory, or returns an empty string
+func parseSource(moviePath string) string {
+	if source := parseSourceName(fNameSansExtension(moviePath)); source != "" {
+		return source
+	}
+	return parseSourceName(filepath.Base(filepath.Dir(moviePath)))
+}
+
+func parseSourceName(name string) string {
+	cleaned := wordReg.ReplaceAllString(name, " ")
+	tokens := strings.Fields(strings.ToLower(cleaned))
+
+	for i := 0; i < len(tokens); i++ {
+		if i+1 < len(tokens) {
+			if tag := sourceTagForToken(tokens[i] + tokens[i+1]); tag != "" {
+				return tag
+			}
+		}
+		if tag := sourceTagForToken(tokens[i]); tag != "" {
+			return tag
+		}
+	}
+
+	return ""
+}
+
+const (
+	tmdbYearSource     = "tmdb"
+	filenameYearSource = "filename"
+)
+
+// filenameYear returns the year embedded in the in file name, or an empty string
+func filenameYear(moviePath, inDir string, stopWords []string) string {
+	// the year of an air date is not the year of the show
+	query, _ := extractAirDate(GetQuery(moviePath, inDir, stopWords))
+	_, _, _, year := extractTvSeasonEpisodeFromQuery(query)
+	if year == 0 {
+		return ""
+	}
+	return strconv.Itoa(year)
+}
+
+// applyYearPolicy warns when the file name year and the moviedb year disagree,
+// and uses the file name year for the out path when yearSource is "filename"
+func applyYearPolicy(media Media, fileYear, yearSource string) Media {
+	mediaYear := media.GetYear()
+	if fileYear == "" || mediaYear == "" || fileYear == mediaYear {
+		return media
+	}
+
+	if yearSource != filenameYearSource {
+		fmt.Printf(tr("Warning: moviedb year %s does not match file name year %s, using %s\n"), mediaYear, fileYear, mediaYear)
+		return media
+	}
+
+	fmt.Printf(tr("Warning: moviedb year %s does not match file name year %s, using %s\n"), mediaYear, fileYear, fileYear)
+	switch m := media.(type) {
+	case Movie:
+		m.PathYear = fileYear
+		return m
+	case TvEpisode:
+		m.PathYear = fileYear
+		return m
+	default:
+		return media
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// renameDiff shows a rename as the path before above the path after,
+// aligned, with the directories and file name that differ colored
+func renameDiff(before, after string) string {
+	sep := string(filepath.Separator)
+	b, a := strings.Split(before, sep), strings.Split(after, sep)
+
+	prefix := 0
+	for prefix < len(b) && prefix < len(a) && b[prefix] == a[prefix] {
+		prefix += 1
+	}
+	suffix := 0
+	for suffix < len(b)-prefix && suffix < len(a)-prefix && b[len(b)-1-suffix] == a[len(a)-1-suffix] {
+		suffix += 1
+	}
+
+	line := func(parts []string, color FragmentColor) string {
+		colored := make([]string, len(parts))
+		for i, part := range parts {
+			if i >= prefix && i < len(parts)-suffix {
+				part = ColorStr(color, part)
+			}
+			colored[i] = part
+		}
+		return strings.Join(colored, sep)
+	}
+	arrow := arrowStr()
+	return fmt.Sprintf("%s %s\n%s %s", strings.Repeat(" ", utf8.RuneCountInString(arrow)), line(b, RedColor), ColorStr(WhiteColor, arrow), line(a, GreenColor))
+}
+
+// renameApproval asks whether to apply each rename shown, until the
+// remaining ones, or those of a show or movie directory, are all approved or
+// the user quits. Nothing is asked with -dry-run or when not run in a
+// terminal.
+type renameApproval struct {
+	reader *bufio.Reader
+	all    bool
+	groups map[string]bool
+}
+
+func newRenameApproval(reader *bufio.Reader) *renameApproval {
+	return &renameApproval{reader: reader, all: *dryRunFlag || !isInteractive(), groups: make(map[string]bool)}
+}
+
+// renameGroup returns the show or movie directory of path, the first
+// directory below root, or the directory of path when it is not below root
+func renameGroup(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return filepath.Dir(path)
+	}
+	return filepath.Join(root, strings.Split(rel, string(filepath.Separator))[0])
+}
+
+// approve returns whether to apply the rename shown last, of the show or
+// movie directory group, or ErrQuit
+func (a *renameApproval) approve(group string) (bool, error) {
+	if a.all || a.groups[group] {
+		return true, nil
+	}
+
+	fmt.Print(promptStr(tr("Apply? [yNsaq] (s: all remaining of this show or movie, a: all remaining, q: quit)")))
+	raw, err := a.reader.ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	answer := strings.ToLower(strings.TrimSpace(raw))
+	// yes wins where it is "s" itself, the spanish prompt leaves out the group
+	if answer == "y" || answer == tr("y") {
+		return true, nil
+	} else if answer == "q" {
+		return false, ErrQuit
+	} else if answer == "a" {
+		a.all = true
+		return true, nil
+	} else if answer == "s" {
+		a.groups[group] = true
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameGroup(t *testing.T) {
+	root := filepath.FromSlash("/media/tv")
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/media/tv/Breaking Bad (2008)/Season 01/Breaking Bad S01E01.mkv", "/media/tv/Breaking Bad (2008)"},
+		{"/media/tv/The Matrix (1999).mkv", "/media/tv/The Matrix (1999).mkv"},
+		{"/other/The Matrix (1999)/The Matrix (1999).mkv", "/other/The Matrix (1999)"},
+	}
+	for _, test := range tests {
+		if got := renameGroup(root, filepath.FromSlash(test.path)); got != filepath.FromSlash(test.want) {
+			t.Errorf("renameGroup(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestRenameApproval(t *testing.T) {
+	a := &renameApproval{reader: bufio.NewReader(strings.NewReader("n\ns\ny\na\n")), groups: make(map[string]bool)}
+	answers := []struct {
+		group string
+		want  bool
+	}{
+		{"show", false},
+		{"show", true},
+		// approved without asking
+		{"show", true},
+		{"movie", true},
+		{"other", true},
+		{"last", true},
+	}
+	for i, answer := range answers {
+		ok, err := a.approve(answer.group)
+		if err != nil || ok != answer.want {
+			t.Errorf("approve(%q) #%d = %v, %v, want %v", answer.group, i, ok, err, answer.want)
+		}
+	}
+
+	a = &renameApproval{reader: bufio.NewReader(strings.NewReader("q\n")), groups: make(map[string]bool)}
+	if _, err := a.approve("show"); err != ErrQuit {
+		t.Errorf("approve error = %v, want %v", err, ErrQuit)
+	}
+}
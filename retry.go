@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+var transientErrnos = []syscall.Errno{
+	syscall.EAGAIN,
+	syscall.EBUSY,
+	syscall.EINTR,
+	syscall.ETXTBSY,
+	syscall.ETIMEDOUT,
+	syscall.ECONNRESET,
+	syscall.ECONNREFUSED,
+	syscall.EHOSTUNREACH,
+	syscall.ENETUNREACH,
+	syscall.ESTALE,
+}
+
+// isTransient reports whether err is likely to go away on its own,
+// ie. network blips or temporarily locked files
+func isTransient(err error) bool {
+	if errors.Is(err, ErrOpTimeout) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var movieDbErr *MovieDbError
+	if errors.As(err, &movieDbErr) {
+		return movieDbErr.Temporary()
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		for _, e := range transientErrnos {
+			if errno == e {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type retryItem struct {
+	index     int
+	moviePath string
+	err       error
+}
+
+// retryBackoff returns the wait before the given attempt, doubling each time
+func retryBackoff(base time.Duration, attempt int) time.Duration {
+	return base * time.Duration(1<<uint(attempt-1))
+}
+
+// retry processes queued in files again until they succeed, fail permanently
+// or run out of attempts
+func (o *Organizer) retry(session *Session, queue []retryItem, movieList []string) {
+	for attempt := 1; attempt <= *retryAttemptsFlag && len(queue) > 0; attempt++ {
+		wait := retryBackoff(*retryBackoffFlag, attempt)
+		fmt.Printf(tr("\nRetrying %d failed files in %s (attempt %d/%d)\n"), len(queue), wait, attempt, *retryAttemptsFlag)
+		time.Sleep(wait)
+
+		next := []retryItem{}
+		for _, item := range queue {
+			session.Start(item.index)
+			err := o.processFile(session, item.index, item.moviePath, movieList)
+			if err == nil {
+				continue
+			}
+
+			if errors.Is(err, ErrQuit) {
+				session.Quit()
+				for _, rest := range queue {
+					session.Failed(rest.moviePath, rest.err)
+				}
+				return
+			}
+
+			if isTransient(err) {
+				next = append(next, retryItem{item.index, item.moviePath, err})
+			} else {
+				session.Failed(item.moviePath, err)
+			}
+		}
+		queue = next
+	}
+
+	for _, item := range queue {
+		session.Failed(item.moviePath, fmt.Errorf("%s (gave up after %d retries)", item.err, *retryAttemptsFlag))
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Review replays the interactive selector for the manifest entries that were
+// auto-matched with a score below maxConfidence, or below -batch-threshold
+// when it is 0. Confirmed matches are
+// recorded as interactive, corrected ones have their out file, and the
+// subtitles and sidecars placed with it, moved to the out file of the new
+// match and their manifest entry updated.
+func (o *Organizer) Review(manifestPath string, maxConfidence float64) error {
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	o.manifestIndex = NewManifestIndex(manifest)
+	if maxConfidence <= 0 {
+		maxConfidence = *batchThresholdFlag
+	}
+
+	queue := []int{}
+	for i, entry := range manifest {
+		if entry.lowConfidence(maxConfidence) {
+			queue = append(queue, i)
+		}
+	}
+	if len(queue) == 0 {
+		fmt.Printf(tr("No auto matches with a score below %.2f\n"), maxConfidence)
+		return nil
+	}
+
+	approval := newRenameApproval(o.reader)
+	confirmed, corrected := 0, 0
+	for n, i := range queue {
+		entry := manifest[i]
+		inDir := inDirFor(o.inDirs, entry.InFile)
+		info := fmt.Sprintf("%s%s\n", movieInfo(n, len(queue), entry.InFile, inDir), fmt.Sprintf(tr("Matched %s (%s)"), ColorStr(GreenColor, entry.OutFile), entry.Match))
+
+		media, err := o.selector.HandleQuery(n, len(queue), entry.InFile, GetQuery(entry.InFile, inDir, o.stopWords), false, []string{}, info, 1)
+		if errors.Is(err, ErrQuit) {
+			break
+		} else if errors.Is(err, ErrSkipped) {
+			continue
+		} else if err != nil {
+			log.Println("Error searching movies:", err)
+			continue
+		}
+
+		match := o.selector.match
+		entry, err = o.reviewEntry(entry, media, approval)
+		if errors.Is(err, ErrQuit) {
+			break
+		} else if errors.Is(err, ErrSkipped) {
+			continue
+		} else if err != nil {
+			log.Println("Error correcting match:", err)
+			continue
+		}
+		entry.Match = &match
+		if entry.OutFile == manifest[i].OutFile && entry.mediaId() == manifest[i].mediaId() {
+			confirmed += 1
+		} else {
+			corrected += 1
+		}
+		manifest[i] = entry
+		fmt.Println()
+	}
+
+	fmt.Printf(tr("Confirmed %d and corrected %d of %d auto matches\n"), confirmed, corrected, len(queue))
+	if *dryRunFlag {
+		return nil
+	}
+	return writeManifest(manifestPath, manifest)
+}
+
+// reviewEntry returns entry matched to media, moving its out file when the
+// out file of media differs and the move is approved
+func (o *Organizer) reviewEntry(entry ManifestEntry, media Media, approval *renameApproval) (ManifestEntry, error) {
+	provider := providerNamed(o.selector.provider, o.selector.provider.Name())
+	media = applyYearPolicy(media, filenameYear(entry.InFile, inDirFor(o.inDirs, entry.InFile), o.stopWords), *yearSourceFlag)
+	media = applyOverride(media, o.config, provider.Name())
+
+	outDir := o.movieOutDir
+	if media.GetType() == "tv_episode" {
+		outDir = o.tvOutDir
+	}
+	outFile, err := buildOutFile(entry.InFile, outDir, media, provider.Name())
+	if err != nil {
+		return entry, err
+	}
+	if m, ok := media.(Movie); ok && (mediaId{provider.Name(), m.Id}) != entry.mediaId() {
+		outFile = o.disambiguateOutFile(provider, outFile, m)
+	}
+
+	if outFile != entry.OutFile {
+		exists, err := fileExists(outFile)
+		if err != nil {
+			return entry, err
+		} else if exists {
+			return entry, fmt.Errorf("out file %s already exists", outFile)
+		}
+
+		fmt.Println(renameDiff(entry.OutFile, outFile))
+		ok, err := approval.approve(renameGroup(outDir, entry.OutFile))
+		if err != nil {
+			return entry, err
+		} else if !ok {
+			return entry, ErrSkipped
+		}
+		if !*dryRunFlag {
+			err = os.MkdirAll(filepath.Dir(outFile), 0755)
+			if err == nil {
+				err = moveFile(entry.OutFile, outFile)
+			}
+			if err != nil {
+				return entry, err
+			}
+
+			// the out file has moved, the entry is updated whatever
+			// happens to its subtitles and sidecars
+			oldOutFile := entry.OutFile
+			entry.OutFile = outFile
+			err = placeSubtitles(oldOutFile, outFile, true)
+			if err != nil {
+				log.Println("Warning: error moving subtitles:", err)
+			}
+			err = placeSidecars(oldOutFile, outFile, true)
+			if err != nil {
+				log.Println("Warning: error moving sidecar files:", err)
+			}
+			// remove the old out directory if nothing else is left there,
+			// ignoring the error when it is not empty
+			os.Remove(filepath.Dir(oldOutFile))
+		}
+	}
+
+	if (mediaId{provider.Name(), media.GetId()}) != entry.mediaId() {
+		entry.ImdbId, err = fetchImdbId(provider, media)
+		if err != nil {
+			log.Println("Error fetching imdb id:", err)
+		}
+		entry.Details, err = fetchDetails(provider, media)
+		if err != nil {
+			log.Println("Error fetching details:", err)
+		}
+	}
+	entry.OutFile = outFile
+	entry.MovieDbId = media.GetId()
+	entry.Provider = provider.Name()
+	entry.ExtraIds = extraIds(media)
+	entry.Type = media.GetType()
+	return entry, nil
+}
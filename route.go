@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Route sends in files matching a glob pattern, relative to their in dir,
+// to other out dirs and with another mode or conflict policy, and matches
+// them without prompting when Batch is set. Empty values keep the ones
+// given on the command line.
+type Route struct {
+	Match      string `json:"match"`
+	MovieOut   string `json:"movie_out,omitempty"`
+	TvOut      string `json:"tv_out,omitempty"`
+	Mode       string `json:"mode,omitempty"`
+	OnConflict string `json:"on_conflict,omitempty"`
+	Batch      bool   `json:"batch,omitempty"`
+
+	matchReg   *regexp.Regexp
+	onConflict conflictPolicy
+}
+
+// globRegexp translates a glob pattern to a regular expression,
+// "**" matches across directories while "*" and "?" do not
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileRoutes validates the routes of the config before they are used
+func (c *Config) compileRoutes() error {
+	for i := range c.Routes {
+		route := &c.Routes[i]
+
+		reg, err := globRegexp(filepath.ToSlash(route.Match))
+		if err != nil {
+			return fmt.Errorf("route %q: %w", route.Match, err)
+		}
+		route.matchReg = reg
+
+		if route.Mode != "" && route.Mode != "move" && route.Mode != "copy" {
+			return fmt.Errorf("route %q: invalid mode %q, must be one of: move, copy", route.Match, route.Mode)
+		}
+
+		if route.OnConflict != "" {
+			route.onConflict, err = parseConflictPolicy(route.OnConflict)
+			if err != nil {
+				return fmt.Errorf("route %q: %w", route.Match, err)
+			}
+		}
+
+		if route.MovieOut != "" {
+			route.MovieOut, err = getOutDir(route.MovieOut, "")
+			if err != nil {
+				return fmt.Errorf("route %q: %w", route.Match, err)
+			}
+		}
+
+		if route.TvOut != "" {
+			route.TvOut, err = getOutDir(route.TvOut, "")
+			if err != nil {
+				return fmt.Errorf("route %q: %w", route.Match, err)
+			}
+		}
+	}
+	return nil
+}
+
+// routeFor returns the first route matching moviePath, or nil
+func (c *Config) routeFor(moviePath, inDir string) *Route {
+	rel, err := filepath.Rel(inDir, moviePath)
+	if err != nil {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+
+	for i := range c.Routes {
+		route := &c.Routes[i]
+		if route.matchReg != nil && route.matchReg.MatchString(rel) {
+			return route
+		}
+	}
+	return nil
+}
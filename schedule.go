@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a cron-style schedule with the five standard fields:
+// minute, hour, day of month, month and day of week
+type Schedule struct {
+	minutes  []bool
+	hours    []bool
+	days     []bool
+	months   []bool
+	weekdays []bool
+	// as in cron, a day matches either day field when both are
+	// restricted, ie. not starting with "*"
+	anyDay bool
+}
+
+// parseScheduleField parses a single cron field supporting "*", numbers,
+// ranges "a-b", lists "a,b" and steps "*/n", "a-b/n" or "a/n", the latter
+// from a to the maximum
+func parseScheduleField(field string, min, max int) ([]bool, error) {
+	values := make([]bool, max+1)
+
+	for _, part := range strings.Split(field, ",") {
+		step, stepped := 1, false
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return values, fmt.Errorf("Invalid step in %q", part)
+			}
+			part, stepped = part[:i], true
+		}
+
+		low, high := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			low, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return values, fmt.Errorf("Invalid value %q", part)
+			}
+			high = low
+			if len(bounds) == 2 {
+				high, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return values, fmt.Errorf("Invalid value %q", part)
+				}
+			} else if stepped {
+				high = max
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return values, fmt.Errorf("Value %q out of range %d-%d", part, min, max)
+		}
+
+		for v := low; v <= high; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+func parseSchedule(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Schedule %q must have 5 fields: minute hour day-of-month month day-of-week", spec)
+	}
+
+	var err error
+	s := &Schedule{}
+	if s.minutes, err = parseScheduleField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if s.hours, err = parseScheduleField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if s.days, err = parseScheduleField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if s.months, err = parseScheduleField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if s.weekdays, err = parseScheduleField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// both 0 and 7 are sunday
+	if s.weekdays[7] {
+		s.weekdays[0] = true
+	}
+	s.anyDay = !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*")
+
+	return s, nil
+}
+
+func (s *Schedule) matches(t time.Time) bool {
+	day := s.days[t.Day()] && s.weekdays[int(t.Weekday())]
+	if s.anyDay {
+		day = s.days[t.Day()] || s.weekdays[int(t.Weekday())]
+	}
+	return s.minutes[t.Minute()] &&
+		s.hours[t.Hour()] &&
+		s.months[int(t.Month())] &&
+		day
+}
+
+// Next returns the first matching minute after t
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	// a valid schedule always matches within 5 years (leap days)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		if s.matches(next) {
+			return next
+		}
+		next = next.Add(time.Minute)
+	}
+	return limit
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     []int
+	}{
+		{"*", 0, 5, []int{0, 1, 2, 3, 4, 5}},
+		{"3", 0, 5, []int{3}},
+		{"1-3", 0, 5, []int{1, 2, 3}},
+		{"1,4", 0, 5, []int{1, 4}},
+		{"*/2", 0, 5, []int{0, 2, 4}},
+		{"1-5/2", 0, 5, []int{1, 3, 5}},
+		{"2/2", 0, 7, []int{2, 4, 6}},
+		{"10/20", 0, 59, []int{10, 30, 50}},
+	}
+	for _, test := range tests {
+		values, err := parseScheduleField(test.field, test.min, test.max)
+		if err != nil {
+			t.Errorf("parseScheduleField(%q) error: %v", test.field, err)
+			continue
+		}
+		got := []int{}
+		for v, ok := range values {
+			if ok {
+				got = append(got, v)
+			}
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("parseScheduleField(%q) = %v, want %v", test.field, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("parseScheduleField(%q) = %v, want %v", test.field, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseScheduleFieldInvalid(t *testing.T) {
+	for _, field := range []string{"", "a", "1-", "6", "3-1", "*/0", "1/x"} {
+		_, err := parseScheduleField(field, 0, 5)
+		if err == nil {
+			t.Errorf("parseScheduleField(%q) expected an error", field)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// 2024-01-01 is a monday
+	from := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/30 * * * *", time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)},
+		{"15/30 * * * *", time.Date(2024, 1, 1, 12, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)},
+		// restricted days of month and week match either
+		{"0 0 15 * 5", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 3 * 0", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		// a "*" day field leaves the other one alone
+		{"0 0 * * 0", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 */10 * *", time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * */3", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		s, err := parseSchedule(test.spec)
+		if err != nil {
+			t.Errorf("parseSchedule(%q) error: %v", test.spec, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(test.want) {
+			t.Errorf("parseSchedule(%q).Next() = %v, want %v", test.spec, got, test.want)
+		}
+	}
+}
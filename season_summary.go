@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// summarizeSeason offers to place the remaining files of the directory of
+// moviePath in one go when they all belong to the tv season just selected.
+// It returns the accepted selections by in file, or nil when any of the
+// files is ambiguous or the user declines, so that they are prompted for
+// one by one.
+func (s *Selector) summarizeSeason(moviePath string, movieList []string, common []string, pending func(string) bool) map[string]Media {
+	if !s.isTvSeasonEpisodeMode() {
+		return nil
+	}
+
+	dir := filepath.Dir(moviePath)
+	episodes := s.tvSeason.MediaResults()
+	selections := make(map[string]Media)
+	files := []string{}
+	seen := make(map[int]bool)
+
+	for _, path := range movieList {
+		if path == moviePath || filepath.Dir(path) != dir || !pending(path) {
+			continue
+		}
+
+		query := GetQuery(path, inDirFor(s.inDirs, path), s.stopWords)
+		if extractLastEpisode(query) > 0 {
+			// multi-episode files need the range prompt
+			return nil
+		}
+
+		showQuery, releaseSeason, releaseEpisode, _ := extractTvSeasonEpisodeFromQuery(query)
+		show := mediaId{s.provider.Name(), s.tvId}
+		if s.tvShowSelections[selectionKey{show.provider, showQuery}] != show &&
+			(len(common) == 0 || s.tvShowSelections[selectionKey{show.provider, strings.Join(common, " ")}] != show) {
+			return nil
+		}
+
+		season, episode := s.config.mapEpisode(s.provider.Name(), s.tvId, releaseSeason, releaseEpisode)
+		if season != s.seasonNumber || episode < 1 || episode > len(episodes) || seen[episode] {
+			return nil
+		}
+		seen[episode] = true
+
+		selections[path] = episodes[episode-1]
+		files = append(files, path)
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+
+	width := 0
+	for _, path := range files {
+		if len(filepath.Base(path)) > width {
+			width = len(filepath.Base(path))
+		}
+	}
+
+	fmt.Printf(tr("Remaining files of %s in %s:\n"), ColorStr(GreenColor, s.tvSeason.TvName), dir)
+	for _, path := range files {
+		episode := selections[path].(TvEpisode)
+		fmt.Printf("  %-*s %s %s %s %s\n", width, filepath.Base(path), arrowStr(),
+			ColorStr(YellowColor, fmt.Sprintf("S%02dE%02d", episode.SeasonNumber, episode.EpisonNumber)), arrowStr(), episode.Name)
+	}
+
+	if !confirm(promptStr(fmt.Sprintf(tr("Use these %d episodes? [yN]"), len(files))), s.reader) {
+		return nil
+	}
+	fmt.Println()
+	return selections
+}
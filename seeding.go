@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// how -link places out files, without one hard links fall back to symlinks
+const (
+	symlinkMode        = "symlink"
+	hardlinkMode       = "hardlink"
+	hardlinkOrCopyMode = "hardlink-or-copy"
+)
+
+var linkModes = []string{symlinkMode, hardlinkMode, hardlinkOrCopyMode}
+
+// partial download files left by common torrent clients
+var torrentPartialExts = []string{".part", ".!qb", ".!ut", ".!bt", ".crdownload", ".aria2", ".parts"}
+
+var errFound = errors.New("found")
+
+// linkFile places src at dst without modifying src, as a hard link or, when
+// src and dst are on different file systems, a symlink. Link modes force
+// either one, hard links fail across file systems unless copies are allowed.
+func linkFile(src, dst, mode string) error {
+	if sfi, err := os.Stat(src); err != nil {
+		return err
+	} else if dfi, err := os.Stat(dst); err == nil && os.SameFile(sfi, dfi) {
+		return nil
+	}
+
+	// an out file being overwritten must not be written through
+	err := os.Remove(dst)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if mode != symlinkMode {
+		err = os.Link(src, dst)
+		if err == nil {
+			return nil
+		} else if mode == hardlinkOrCopyMode {
+			return copyFileContents(src, dst)
+		} else if mode == hardlinkMode && errors.Is(err, syscall.EXDEV) {
+			return fmt.Errorf("%s and %s are on different file systems, use -link %s to copy instead: %w", src, dst, hardlinkOrCopyMode, err)
+		} else if mode == hardlinkMode {
+			return err
+		}
+	}
+
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	return os.Symlink(abs, dst)
+}
+
+// looksLikeTorrentDir reports whether dir contains torrent files or
+// partial downloads of a torrent client, returning the first one found
+func looksLikeTorrentDir(dir string) (bool, string) {
+	found := ""
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext == ".torrent" || stringSliceContains(torrentPartialExts, ext) {
+			found = path
+			return errFound
+		}
+		return nil
+	})
+	return found != "", found
+}
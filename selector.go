@@ -16,13 +16,33 @@ import (
 
 type selectorMode int
 
+// outcomes of Selector.Handle other than a selected media
+var (
+	ErrSkipped = errors.New("skipped")
+	// ErrNoResults is an ErrSkipped for in files skipped without any search result
+	ErrNoResults = fmt.Errorf("%w: no results", ErrSkipped)
+	ErrQuit      = errors.New("quit")
+)
+
 var (
 	yearReg               = regexp.MustCompile(`^\d{4}$`)
 	seasonReg             = regexp.MustCompile(`s(?P<season>\d+)`)
 	episodeReg            = regexp.MustCompile(`e(?P<episode>\d+)`)
+	multiEpisodeReg       = regexp.MustCompile(`^s\d+((?:e\d+)+)$`)
+	nextEpisodeReg        = regexp.MustCompile(`^e(\d+)$`)
 	queryReg              = regexp.MustCompile(`[^a-zA-Z0-9]+`)
 	intReg                = regexp.MustCompile(`^\d+$`)
+	rangeReg              = regexp.MustCompile(`^(\d+)-(\d+)$`)
 	validSingleCharTokens = []string{"a", "i", "0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+	selectorHelp          = []string{
+		"q quit",
+		"s skip",
+		"h this help",
+		"p next page of results (if available)",
+		"g choose episode group (dvd, absolute order) of tv show",
+		"N-M select episodes N to M of a multi-episode file",
+		"any other text is new query",
+	}
 )
 
 const (
@@ -33,29 +53,55 @@ const (
 
 type Selector struct {
 	mode             selectorMode
-	movieDb          *MovieDb
-	inDir            string
+	provider         MetadataProvider
+	inDirs           []string
 	reader           *bufio.Reader
 	stopWords        []string
 	tvId             int64
 	seasonNumber     int
 	tvSeason         TvSeason
 	query            string
-	tvShowSelections map[string]int64
+	tvShowSelections map[selectionKey]mediaId
+	movieSelections  map[selectionKey]mediaId
+	config           *Config
+	configPath       string
+	seasonMapAsked   map[string]bool
+	state            *State
+	statePath        string
+	// the type files are matched as regardless of their names, see DirDecision
+	forcedType string
+	// how the media last returned by Handle was matched, and the scores
+	// of tv shows auto-selected in the session
+	match      Match
+	showScores map[mediaId]float64
 }
 
-func NewSelector(movieDb *MovieDb, inDir string, reader *bufio.Reader, stopWords []string) *Selector {
+// selectionKey is a query answered with a provider, the id selected for it
+// is only valid with that provider
+type selectionKey struct {
+	provider string
+	query    string
+}
+
+func NewSelector(provider MetadataProvider, inDirs []string, reader *bufio.Reader, stopWords []string, config *Config, configPath string, state *State, statePath string) *Selector {
 	return &Selector{
 		mode:             movieSelector,
-		movieDb:          movieDb,
-		inDir:            inDir,
+		provider:         provider,
+		inDirs:           inDirs,
 		reader:           reader,
 		stopWords:        stopWords,
 		tvId:             0,
 		seasonNumber:     0,
 		tvSeason:         TvSeason{},
 		query:            "",
-		tvShowSelections: make(map[string]int64),
+		tvShowSelections: make(map[selectionKey]mediaId),
+		movieSelections:  make(map[selectionKey]mediaId),
+		config:           config,
+		configPath:       configPath,
+		seasonMapAsked:   make(map[string]bool),
+		showScores:       make(map[mediaId]float64),
+		state:            state,
+		statePath:        statePath,
 	}
 }
 
@@ -84,12 +130,19 @@ func (s *Selector) isTvMode() bool {
 }
 
 func (s *Selector) setTvSeasonEpisodeMode(tvId int64, seasonNumber int, query string) error {
-	tv, err := s.movieDb.GetTv(tvId)
+	tv, err := s.provider.GetTv(tvId)
 	if err != nil {
 		return err
 	}
 
-	tvSeason, err := s.movieDb.GetTvSeason(tv, seasonNumber)
+	var tvSeason TvSeason
+	if groupId := s.config.episodeGroup(s.provider.Name(), tvId); groupId != "" {
+		tvSeason, err = s.provider.GetEpisodeGroupSeason(tv, groupId, seasonNumber)
+	} else {
+		tvSeason, err = s.provider.GetTvSeason(tv, seasonNumber)
+		// directories commonly mix seasons
+		s.provider.PrefetchTvSeasons(tv, seasonNumber-1, seasonNumber+1)
+	}
 	if err != nil {
 		return err
 	}
@@ -99,10 +152,29 @@ func (s *Selector) setTvSeasonEpisodeMode(tvId int64, seasonNumber int, query st
 	s.seasonNumber = seasonNumber
 	s.tvSeason = tvSeason
 	s.query = query
-	s.tvShowSelections[query] = tvId
+	s.selectShow(query, tvId)
 	return nil
 }
 
+// selectShow remembers tvId as the tv show of query with the active provider
+func (s *Selector) selectShow(query string, tvId int64) {
+	provider := s.provider.Name()
+	s.tvShowSelections[selectionKey{provider, query}] = mediaId{provider, tvId}
+}
+
+// selectedShow returns the tv show selected for query before, with the active
+// provider or else another provider of the chain, and makes its provider the
+// one ids are looked up with
+func (s *Selector) selectedShow(query string) (int64, bool) {
+	for _, name := range providerNames(s.provider) {
+		show, ok := s.tvShowSelections[selectionKey{name, query}]
+		if ok && useProvider(s.provider, show.provider) {
+			return show.id, true
+		}
+	}
+	return 0, false
+}
+
 func (s *Selector) isTvSeasonEpisodeMode() bool {
 	return s.mode == tvSeasonEpisodeSelector
 }
@@ -110,11 +182,11 @@ func (s *Selector) isTvSeasonEpisodeMode() bool {
 func (s *Selector) modeName() string {
 	switch s.mode {
 	case movieSelector:
-		return "Movie"
+		return tr("Movie")
 	case tvSelector:
-		return "Tv show"
+		return tr("Tv show")
 	case tvSeasonEpisodeSelector:
-		return "Episode"
+		return tr("Episode")
 	default:
 		return "unknown"
 	}
@@ -123,8 +195,11 @@ func (s *Selector) modeName() string {
 func GetQuery(moviePath, inDir string, stopWords []string) string {
 	ext := filepath.Ext(moviePath)
 	name := moviePath[0 : len(moviePath)-len(ext)]
-	relativeName := strings.TrimPrefix(name, fmt.Sprintf("%s/", inDir))
+	relativeName := strings.TrimPrefix(name, inDir+string(filepath.Separator))
 	fileName := filepath.Base(name)
+	if isObfuscatedName(fileName) {
+		return obfuscatedQuery(moviePath, inDir, stopWords)
+	}
 	myQuery := buildQuery(fileName, stopWords)
 	testQuery, _, _, _ := extractTvSeasonEpisodeFromQuery(myQuery)
 
@@ -135,53 +210,179 @@ func GetQuery(moviePath, inDir string, stopWords []string) string {
 		myQuery = buildQuery(relativeName, stopWords)
 	}
 
+	// the release name of a scene nfo or torrent file is often more
+	// informative than the file name, ie. "movie.mkv" of a release directory
+	if !informativeQuery(myQuery) {
+		if query, _ := releaseHintQuery(moviePath, inDir, stopWords); query != "" {
+			myQuery = query
+		}
+	}
+
 	return myQuery
 }
 
-func (s *Selector) Handle(i, n int, moviePath string, common []string, info string) (Media, error) {
-	myQuery := GetQuery(moviePath, s.inDir, s.stopWords)
-	return s.HandleQuery(i, n, moviePath, myQuery, false, common, info, 1)
+// Handle matches moviePath, without prompting when batch is set
+func (s *Selector) Handle(i, n int, moviePath string, common []string, info string, batch bool) (Media, error) {
+	inDir := inDirFor(s.inDirs, moviePath)
+
+	// files of a directory decided before are matched as the same type,
+	// dropping the same stop words, and reuse the tv show chosen for them,
+	// tv ids are only valid with the provider they were chosen from. Files
+	// of the in dir itself are unrelated.
+	dir := filepath.Dir(moviePath)
+	decidable := dir != filepath.Clean(inDir)
+	decision, decided := s.state.Dirs[dir]
+	if !decidable {
+		decision, decided = DirDecision{}, false
+	}
+	stopWords := append(append([]string{}, s.stopWords...), decision.StopWords...)
+	s.forcedType = decision.Type
+	defer func() { s.forcedType = "" }()
+	var tvId int64
+	if decided && decision.TvId > 0 && useProvider(s.provider, decision.Provider) {
+		tvId = decision.TvId
+	}
+
+	myQuery := GetQuery(moviePath, inDir, stopWords)
+	showQuery, isEpisode := tvShowQuery(myQuery)
+	if s.forcedType == "movie" {
+		showQuery, isEpisode = myQuery, false
+	}
+
+	// releases that embed an imdb or tmdb id are looked up instead of searched
+	var (
+		byId       Media
+		externalId string
+	)
+	if ids := findExternalIds(moviePath, inDir); !ids.empty() {
+		found, err := s.mediaByExternalIds(ids, isEpisode)
+		if err != nil {
+			fmt.Printf(tr("Error looking up %s: %s\n"), ids, err)
+		} else if isEpisode {
+			tvId = found.GetId()
+			externalId = ids.id()
+		} else {
+			externalId = ids.id()
+			byId = found
+			fmt.Println(info)
+			fmt.Printf(tr("Found %s (%s) by %s\n"), ColorStr(GreenColor, found.GetName()), found.GetYear(), ids)
+		}
+	}
+
+	if tvId > 0 && isEpisode {
+		s.selectShow(showQuery, tvId)
+		if len(common) > 0 {
+			s.selectShow(strings.Join(common, " "), tvId)
+		}
+	}
+
+	// obfuscated file names are searched by their directory or nfo title,
+	// matches need to be confirmed
+	obfuscated := byId == nil && isObfuscatedName(fNameSansExtension(moviePath))
+
+	var (
+		media Media
+		err   error
+	)
+	s.match = Match{}
+	if byId != nil {
+		s.setMovieMode(showQuery)
+		media = byId
+		s.match = Match{Method: idMatch}
+	} else if obfuscated && batch {
+		fmt.Println(info)
+		err = fmt.Errorf("%w: obfuscated file name, query %q built from its directory or nfo file", ErrNeedsReview, myQuery)
+	} else if batch {
+		fmt.Println(info)
+		media, err = s.autoSelect(myQuery, common, *batchThresholdFlag)
+	} else {
+		media, err = s.HandleQuery(i, n, moviePath, myQuery, false, common, info, 1)
+	}
+	s.match.ExternalId = externalId
+	if err == nil && obfuscated && !batch && !confirm(promptStr(fmt.Sprintf(tr("The file name looks obfuscated, the query was built from its directory or nfo file. Use %s (%s)? [yN]"), media.GetName(), media.GetYear())), s.reader) {
+		err = ErrSkipped
+	}
+	if err == nil && decidable && s.state.decide(dir, s.provider.Name(), media, appliedStopWords(moviePath, stopWords)) {
+		werr := writeState(s.statePath, s.state)
+		if werr != nil {
+			log.Println("Error saving directory decision:", werr)
+		}
+	}
+	return media, err
 }
 
 func (s *Selector) HandleQuery(i, n int, moviePath, query string, manual bool, common []string, info string, page int) (Media, error) {
 	fmt.Println(info)
 
-	myQuery, season, episode, year := extractTvSeasonEpisodeFromQuery(strings.TrimSpace(query))
+	// daily shows are named by air date instead of season and episode
+	dateQuery, airDate := extractAirDate(strings.TrimSpace(query))
+	myQuery, releaseSeason, releaseEpisode, year := extractTvSeasonEpisodeFromQuery(dateQuery)
+	season, episode := releaseSeason, releaseEpisode
+	if s.isTvSeasonEpisodeMode() {
+		season, episode = s.config.mapEpisode(s.provider.Name(), s.tvId, releaseSeason, releaseEpisode)
+	}
+
+	// anime is commonly numbered by absolute episode
+	absolute := 0
+	if *animeFlag && season == 0 && episode == 0 && airDate == "" {
+		myQuery, absolute = extractAbsoluteEpisode(myQuery)
+	}
 
 	suffixTerms := []string{}
+	if airDate != "" {
+		suffixTerms = append(suffixTerms, fmt.Sprintf(tr("air date: %s"), airDate))
+	}
+	if absolute > 0 {
+		suffixTerms = append(suffixTerms, fmt.Sprintf(tr("absolute episode: %d"), absolute))
+	}
 	if year > 0 {
-		suffixTerms = append(suffixTerms, fmt.Sprintf("year: %d", year))
+		suffixTerms = append(suffixTerms, fmt.Sprintf(tr("year: %d"), year))
 	}
 	if season > 0 {
-		suffixTerms = append(suffixTerms, fmt.Sprintf("season: %d", season))
+		suffixTerms = append(suffixTerms, fmt.Sprintf(tr("season: %d"), season))
 	}
 	if episode > 0 {
-		suffixTerms = append(suffixTerms, fmt.Sprintf("episode: %d", episode))
+		suffixTerms = append(suffixTerms, fmt.Sprintf(tr("episode: %d"), episode))
 	}
 	displayQuerySuffix := strings.Join(suffixTerms, ", ")
 	if displayQuerySuffix != "" {
 		displayQuerySuffix = fmt.Sprintf(" (%s)", displayQuerySuffix)
 	}
 
-	if season == 0 && episode == 0 {
+	if (season == 0 && episode == 0 && airDate == "" && absolute == 0) || (!manual && s.forcedType == "movie") {
 		s.setMovieMode(myQuery)
 	} else if s.isMovieMode() {
 		s.setTvMode(myQuery)
-	} else if s.isTvSeasonEpisodeMode() && (s.seasonNumber != season || s.query != myQuery) {
+	} else if s.isTvSeasonEpisodeMode() && (s.query != myQuery ||
+		(airDate == "" && s.seasonNumber != season) || (airDate != "" && s.tvSeason.airDateEpisode(airDate) == 0)) {
 		if !manual && len(common) > 0 {
 			myQuery = strings.Join(common, " ")
+			fmt.Printf(tr("Using tokens common to files in this directory: %s\n"), myQuery)
 		}
 		s.setTvMode(myQuery)
 	}
 
 	if s.isTvMode() {
-		if tvId, ok := s.tvShowSelections[myQuery]; ok {
-			err := s.setTvSeasonEpisodeMode(tvId, season, myQuery)
+		if tvId, ok := s.selectedShow(myQuery); ok {
+			var err error
+			if airDate != "" {
+				season, err = s.airDateSeason(tvId, airDate)
+			} else if absolute > 0 {
+				season, episode, err = s.absoluteEpisode(tvId, absolute)
+			} else {
+				season, episode = s.config.mapEpisode(s.provider.Name(), tvId, releaseSeason, releaseEpisode)
+			}
+			if err == nil {
+				err = s.setTvSeasonEpisodeMode(tvId, season, myQuery)
+			}
 			if err != nil {
-				fmt.Println("Error selecting tv show based on previous query:", err)
+				fmt.Println(tr("Error selecting tv show based on previous query:"), err)
 			}
 		}
 	}
+	if airDate != "" && s.isTvSeasonEpisodeMode() {
+		season, episode = s.seasonNumber, s.tvSeason.airDateEpisode(airDate)
+	}
 
 	var (
 		results      []Media
@@ -191,20 +392,20 @@ func (s *Selector) HandleQuery(i, n int, moviePath, query string, manual bool, c
 
 	if myQuery != "" && s.isMovieMode() {
 		// search movies
-		response, err := s.movieDb.SearchMovie(myQuery, page, year)
+		response, err := s.provider.SearchMovie(myQuery, page, year)
 		if err != nil {
-			fmt.Println("Error searching movies:", err)
+			fmt.Println(tr("Error searching movies:"), err)
 		}
-		results = response.MediaResults()
+		results = preferLanguages(response.MediaResults(), splitCsv(*preferLanguageFlag), *onlyPreferredLanguageFlag)
 		totalPages = response.TotalPages
 		displayQuery = fmt.Sprintf("%s%s", myQuery, displayQuerySuffix)
 	} else if myQuery != "" && s.isTvMode() {
 		// search tv shows
-		response, err := s.movieDb.SearchTv(myQuery, page, year)
+		response, err := s.provider.SearchTv(myQuery, page, year)
 		if err != nil {
-			fmt.Println("Error searching tv shows:", err)
+			fmt.Println(tr("Error searching tv shows:"), err)
 		}
-		results = response.MediaResults()
+		results = preferLanguages(response.MediaResults(), splitCsv(*preferLanguageFlag), *onlyPreferredLanguageFlag)
 		totalPages = response.TotalPages
 		displayQuery = fmt.Sprintf("%s%s", myQuery, displayQuerySuffix)
 	} else if s.isTvSeasonEpisodeMode() {
@@ -219,15 +420,19 @@ func (s *Selector) HandleQuery(i, n int, moviePath, query string, manual bool, c
 	}
 
 	if totalPages > 1 {
-		fmt.Printf("%s query (page %d/%d): %s\n", s.modeName(), page, totalPages, ColorStr(RedColor, displayQuery))
+		fmt.Printf(tr("%s query (page %d/%d): %s\n"), s.modeName(), page, totalPages, ColorStr(RedColor, displayQuery))
 	} else {
-		fmt.Printf("%s query: %s\n", s.modeName(), ColorStr(RedColor, displayQuery))
+		fmt.Printf(tr("%s query: %s\n"), s.modeName(), ColorStr(RedColor, displayQuery))
 	}
 
 	numResults := len(results)
 
 	if numResults == 0 {
-		fmt.Println("No results!")
+		fmt.Println(tr("No results!"))
+	}
+
+	if s.isTvSeasonEpisodeMode() && episode > numResults && s.askSeasonMapping(releaseSeason, releaseEpisode) {
+		return s.HandleQuery(i, n, moviePath, query, manual, common, info, page)
 	}
 
 	var defaultSelection int
@@ -237,7 +442,38 @@ func (s *Selector) HandleQuery(i, n int, moviePath, query string, manual bool, c
 		defaultSelection = 1
 	}
 
-	printMediaOptions(results)
+	// a query answered earlier in the session, ie. for multi-part or
+	// duplicate files, defaults to the same movie
+	movieKey := fmt.Sprintf("%s\x00%d", myQuery, year)
+	previous := -1
+	if selected, ok := s.movieSelections[selectionKey{s.provider.Name(), movieKey}]; ok && s.isMovieMode() {
+		for k, result := range results {
+			if result.GetId() == selected.id {
+				defaultSelection = k + 1
+				previous = k
+				break
+			}
+		}
+	}
+
+	// files with two or more episodes, ie. "s05e01e02" or "s05e01-e02",
+	// default to selecting the range of episodes
+	defaultStr := strconv.Itoa(defaultSelection)
+	if lastEpisode := extractLastEpisode(query); s.isTvSeasonEpisodeMode() && defaultSelection == episode && lastEpisode > releaseEpisode {
+		last := episode + lastEpisode - releaseEpisode
+		if last <= numResults {
+			defaultStr = fmt.Sprintf("%d-%d", episode, last)
+		}
+	}
+
+	var hints map[int]string
+	if !s.isTvSeasonEpisodeMode() {
+		hints = disambiguationHints(s.provider, results)
+	}
+	printMediaOptions(results, hints)
+	if previous >= 0 {
+		fmt.Printf(tr("Same as before: %s (%s)\n"), ColorStr(GreenColor, results[previous].GetName()), results[previous].GetDate())
+	}
 
 	var selection string
 	for {
@@ -245,13 +481,16 @@ func (s *Selector) HandleQuery(i, n int, moviePath, query string, manual bool, c
 		if totalPages > 1 {
 			options += "p"
 		}
+		if s.isTvSeasonEpisodeMode() {
+			options += "g"
+		}
 		if numResults <= 0 {
-			fmt.Printf("[%s] ➜ ", ColorStr(RedColor, options))
+			fmt.Print(promptStr(fmt.Sprintf("[%s]", ColorStr(RedColor, options))))
 		} else if numResults == 1 {
-			fmt.Printf("[%s] (default: 1) ➜ ", ColorStr(RedColor, "1"+options))
+			fmt.Print(promptStr(fmt.Sprintf("[%s] %s", ColorStr(RedColor, "1"+options), fmt.Sprintf(tr("(default: %s)"), "1"))))
 		} else {
 			choices := fmt.Sprintf("1-%d%s", numResults, options)
-			fmt.Printf("[%s] (default: %d) ➜ ", ColorStr(RedColor, choices), defaultSelection)
+			fmt.Print(promptStr(fmt.Sprintf("[%s] %s", ColorStr(RedColor, choices), fmt.Sprintf(tr("(default: %s)"), defaultStr))))
 		}
 		rawSelection, err := s.reader.ReadString('\n')
 		if err != nil {
@@ -260,40 +499,57 @@ func (s *Selector) HandleQuery(i, n int, moviePath, query string, manual bool, c
 		}
 
 		selection = strings.TrimSpace(rawSelection)
+		if selection == "" {
+			selection = defaultStr
+		}
 
 		if selection == "q" {
-			return Movie{}, errors.New("quit")
+			return Movie{}, ErrQuit
 		} else if selection == "s" {
-			return Movie{}, errors.New("skipped")
+			if numResults == 0 {
+				return Movie{}, ErrNoResults
+			}
+			return Movie{}, ErrSkipped
 		} else if selection == "p" {
 			if page < totalPages {
 				return s.HandleQuery(i, n, moviePath, query, manual, common, info, page+1)
 			} else {
 				return s.HandleQuery(i, n, moviePath, query, manual, common, info, 1)
 			}
+		} else if selection == "g" && s.isTvSeasonEpisodeMode() {
+			if s.chooseEpisodeGroup() {
+				return s.HandleQuery(i, n, moviePath, query, manual, common, info, 1)
+			}
+			continue
 		} else if selection == "h" {
 			if numResults == 1 {
-				fmt.Println("1 select\ndefault (empty string) select choice 1")
+				fmt.Println(tr("1 select"))
+				fmt.Printf(tr("default (empty string) select choice %d")+"\n", 1)
 			} else if numResults > 1 {
-				fmt.Printf("1-%d select\ndefault (empty string) select choice %d\n", numResults, defaultSelection)
+				fmt.Printf(tr("1-%d select")+"\n", numResults)
+				fmt.Printf(tr("default (empty string) select choice %d")+"\n", defaultSelection)
 			}
-			fmt.Printf(strings.TrimSpace(`
-q quit
-s skip
-h this help
-p next page of results (if available)
-any other text is new query
-			`) + "\n\n")
+			for _, line := range selectorHelp {
+				fmt.Println(tr(line))
+			}
+			fmt.Println()
 			continue
+		} else if rm := rangeReg.FindStringSubmatch(selection); rm != nil && s.isTvSeasonEpisodeMode() {
+			first, _ := strconv.Atoi(rm[1])
+			last, _ := strconv.Atoi(rm[2])
+			if first < 1 || last > numResults || first >= last {
+				fmt.Println(tr("Please select one of the listed options."))
+				continue
+			}
+			s.match = Match{Method: interactiveMatch, Selection: first, Results: numResults, SeasonMap: s.config.hasSeasonMapping(s.provider.Name(), s.tvId, releaseSeason)}
+			return multiEpisode(results[first-1 : last]), nil
 		} else {
 			var iSel int
-			if selection == "" {
-				iSel = defaultSelection
-			} else if intReg.MatchString(selection) {
+			if intReg.MatchString(selection) {
 				iSel, err = strconv.Atoi(selection)
 				if err != nil {
 					// shouldn't happen due to regex check above
-					fmt.Println("Invalid selection:", err)
+					fmt.Println(tr("Invalid selection:"), err)
 					continue
 				}
 			} else {
@@ -303,30 +559,161 @@ any other text is new query
 
 			if iSel >= 1 && iSel <= numResults {
 				if s.isTvMode() {
-					if season > 0 {
+					if season > 0 || airDate != "" || absolute > 0 {
 						// we've selected a tv show, now need to select season and episode
-						err = s.setTvSeasonEpisodeMode(results[iSel-1].GetId(), season, myQuery)
+						tvId := results[iSel-1].GetId()
+						mappedSeason, _ := s.config.mapEpisode(s.provider.Name(), tvId, releaseSeason, releaseEpisode)
+						if airDate != "" {
+							mappedSeason, err = s.airDateSeason(tvId, airDate)
+						} else if absolute > 0 {
+							mappedSeason, _, err = s.absoluteEpisode(tvId, absolute)
+						}
+						if err == nil {
+							err = s.setTvSeasonEpisodeMode(tvId, mappedSeason, myQuery)
+						}
 						if err != nil {
-							fmt.Println("Invalid tv season selection:", err)
+							fmt.Println(tr("Invalid tv season selection:"), err)
 							continue
 						}
 						return s.HandleQuery(i, n, moviePath, query, manual, common, info, page)
 					} else {
-						fmt.Println("Unable to extract season number from query string.")
+						fmt.Println(tr("Unable to extract season number from query string."))
 						continue
 					}
 				} else {
 					// we've selected either a movie or a tv show, season & episode
+					if s.isMovieMode() {
+						provider := s.provider.Name()
+						s.movieSelections[selectionKey{provider, movieKey}] = mediaId{provider, results[iSel-1].GetId()}
+					}
+					s.match = Match{Method: interactiveMatch, Selection: iSel, Results: numResults}
+					if s.isTvSeasonEpisodeMode() {
+						s.match.SeasonMap = s.config.hasSeasonMapping(s.provider.Name(), s.tvId, releaseSeason)
+					}
 					return results[iSel-1], nil
 				}
 			} else {
-				fmt.Println("Please select one of the listed options.")
+				fmt.Println(tr("Please select one of the listed options."))
 				continue
 			}
 		}
 	}
 }
 
+// multiEpisode combines consecutive episodes of a file into the first one
+func multiEpisode(results []Media) Media {
+	episode := results[0].(TvEpisode)
+	last := results[len(results)-1].(TvEpisode)
+	episode.LastEpisode = last.EpisonNumber
+	for _, result := range results[1:] {
+		episode.ExtraIds = append(episode.ExtraIds, result.GetId())
+	}
+	return episode
+}
+
+// extraIds returns the ids of further episodes contained in the same file
+func extraIds(media Media) []int64 {
+	if episode, ok := media.(TvEpisode); ok {
+		return episode.ExtraIds
+	}
+	return nil
+}
+
+// askSeasonMapping offers to map a release season of the current tv show
+// to a different moviedb season once, when the episode is not found.
+// It returns true when a new mapping was saved.
+func (s *Selector) askSeasonMapping(releaseSeason, releaseEpisode int) bool {
+	key := fmt.Sprintf("%d-%d", s.tvId, releaseSeason)
+	if s.seasonMapAsked[key] || s.config.hasSeasonMapping(s.provider.Name(), s.tvId, releaseSeason) {
+		return false
+	}
+	s.seasonMapAsked[key] = true
+
+	fmt.Printf(tr("Episode %d is not in season %d of %s on moviedb.\n"), releaseEpisode, s.seasonNumber, s.tvSeason.TvName)
+	fmt.Print(promptStr(fmt.Sprintf(tr("Map release season %d to moviedb season (empty to skip)"), releaseSeason)))
+	season, ok := s.readInt()
+	if !ok {
+		return false
+	}
+
+	fmt.Print(promptStr(tr("Episode offset (empty for 0)")))
+	offset, _ := s.readInt()
+
+	s.config.setSeasonMapping(s.provider.Name(), s.tvId, releaseSeason, SeasonMapping{Season: season, EpisodeOffset: offset})
+	err := writeConfig(s.configPath, s.config)
+	if err != nil {
+		log.Println("Error saving season mapping:", err)
+	}
+
+	// reload the season the release season now maps to
+	mappedSeason, _ := s.config.mapEpisode(s.provider.Name(), s.tvId, releaseSeason, releaseEpisode)
+	err = s.setTvSeasonEpisodeMode(s.tvId, mappedSeason, s.query)
+	if err != nil {
+		fmt.Println(tr("Invalid tv season selection:"), err)
+	}
+	return true
+}
+
+// chooseEpisodeGroup lets the user pick the episode order used for the
+// current tv show, and saves it to the config. It returns true when changed.
+func (s *Selector) chooseEpisodeGroup() bool {
+	response, err := s.provider.GetTvEpisodeGroups(s.tvId)
+	if err != nil {
+		fmt.Println(tr("Error getting episode groups:"), err)
+		return false
+	}
+
+	current := s.config.episodeGroup(s.provider.Name(), s.tvId)
+	fmt.Printf(" 0 %s\n", tr("Aired order (default)"))
+	for i, group := range response.Results {
+		marker := ""
+		if group.Id == current {
+			marker = " *"
+		}
+		fmt.Printf(tr("%2d %s (%d episodes, %d groups)%s\n"), i+1, group.Name, group.EpisodeCount, group.GroupCount, marker)
+	}
+
+	fmt.Print(promptStr(fmt.Sprintf("[0-%d]", len(response.Results))))
+	choice, ok := s.readInt()
+	if !ok || choice < 0 || choice > len(response.Results) {
+		fmt.Println(tr("Please select one of the listed options."))
+		return false
+	}
+
+	groupId := ""
+	if choice > 0 {
+		groupId = response.Results[choice-1].Id
+	}
+	if groupId == current {
+		return false
+	}
+
+	s.config.setEpisodeGroup(s.provider.Name(), s.tvId, groupId)
+	err = writeConfig(s.configPath, s.config)
+	if err != nil {
+		log.Println("Error saving episode group:", err)
+	}
+
+	err = s.setTvSeasonEpisodeMode(s.tvId, s.seasonNumber, s.query)
+	if err != nil {
+		fmt.Println(tr("Invalid tv season selection:"), err)
+	}
+	return true
+}
+
+// readInt reads a number from the reader, ok is false for empty or invalid input
+func (s *Selector) readInt() (int, bool) {
+	raw, err := s.reader.ReadString('\n')
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
 func terminalWidth() (int, error) {
 	cmd := exec.Command("stty", "size")
 	cmd.Stdin = os.Stdin
@@ -346,7 +733,7 @@ func terminalWidth() (int, error) {
 	return width, nil
 }
 
-func printMediaOptions(options []Media) {
+func printMediaOptions(options []Media, hints map[int]string) {
 	width, err := terminalWidth()
 	if err != nil {
 		width = 120
@@ -361,6 +748,10 @@ func printMediaOptions(options []Media) {
 			line.Addf("(%s)", option.GetDate())
 		}
 
+		if hint, ok := hints[i]; ok {
+			line.AddColorf(YellowColor, "[%s]", hint)
+		}
+
 		overview := strings.TrimSpace(option.GetOverview())
 		if overview != "" {
 			line.AddFields(overview)
@@ -376,21 +767,81 @@ func isQueryToken(token string, stopWords []string) bool {
 }
 
 func buildQueryTokens(movieStr string, stopWords []string) []string {
-	cleaned := queryReg.ReplaceAllString(movieStr, " ")
-	lower := strings.ToLower(cleaned)
+	// air dates are kept whole before the separators of the name are dropped
+	fields := []string{}
+	parts, dates := splitAirDates(movieStr)
+	for i, part := range parts {
+		if dates[i] {
+			fields = append(fields, part)
+		} else {
+			fields = append(fields, strings.Fields(strings.ToLower(queryReg.ReplaceAllString(part, " ")))...)
+		}
+	}
 	words := []string{}
-	for _, word := range strings.Fields(lower) {
-		if isQueryToken(word, stopWords) {
+	ids := idTokens(fields)
+	for i, word := range fields {
+		if !ids[i] && isQueryToken(word, stopWords) {
 			words = append(words, word)
 		}
 	}
 	return words
 }
 
+// appliedStopWords returns the stop words dropped from the file name of moviePath
+func appliedStopWords(moviePath string, stopWords []string) []string {
+	applied := []string{}
+	for _, word := range strings.Fields(strings.ToLower(queryReg.ReplaceAllString(fNameSansExtension(moviePath), " "))) {
+		if stringSliceContains(stopWords, word) {
+			applied = append(applied, word)
+		}
+	}
+	return applied
+}
+
 func buildQuery(movieStr string, stopWords []string) string {
 	return strings.Join(buildQueryTokens(movieStr, stopWords), " ")
 }
 
+// extractLastEpisode returns the highest episode number of the query
+// when it has more than one, ie. 2 for "s05e01e02" or "s05e01 e02". Only
+// the first sNNeNN field and the eNN fields right after it count, so that
+// words like "se7en" are not taken for episodes.
+func extractLastEpisode(query string) int {
+	episodes := []int{}
+	add := func(digits string) {
+		episode, err := strconv.Atoi(digits)
+		if err == nil && episode > 0 {
+			episodes = append(episodes, episode)
+		}
+	}
+	for _, field := range strings.Fields(query) {
+		if len(episodes) == 0 {
+			if m := multiEpisodeReg.FindStringSubmatch(field); m != nil {
+				for _, em := range episodeReg.FindAllStringSubmatch(m[1], -1) {
+					add(em[1])
+				}
+			}
+			continue
+		}
+		m := nextEpisodeReg.FindStringSubmatch(field)
+		if m == nil {
+			break
+		}
+		add(m[1])
+	}
+
+	if len(episodes) < 2 {
+		return 0
+	}
+	last := episodes[0]
+	for _, episode := range episodes[1:] {
+		if episode > last {
+			last = episode
+		}
+	}
+	return last
+}
+
 func extractTvSeasonEpisodeFromQuery(query string) (string, int, int, int) {
 	newQuery := []string{}
 	season := 0
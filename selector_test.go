@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newReplaySelector returns a selector answering api requests from the
+// responses in testdata/replay, as recorded with -record-http, and prompts
+// from input
+func newReplaySelector(t *testing.T, input string) *Selector {
+	movieDb := NewMovieDb("test")
+	movieDb.ReplayHttp(filepath.Join("testdata", "replay"))
+	state := &State{Dirs: make(map[string]DirDecision)}
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	return NewSelector(movieDb, []string{"/in"}, bufio.NewReader(strings.NewReader(input)), []string{}, NewConfig(), "", state, statePath)
+}
+
+func TestHandleQueryMovie(t *testing.T) {
+	s := newReplaySelector(t, "\n")
+	media, err := s.HandleQuery(0, 1, "/in/The.Matrix.1999.mkv", "the matrix 1999", false, []string{}, "", 1)
+	if err != nil {
+		t.Fatalf("HandleQuery error: %v", err)
+	}
+	if media.GetId() != 603 || media.GetType() != "movie" {
+		t.Errorf("HandleQuery = %s (%d), want The Matrix (603)", media.GetName(), media.GetId())
+	}
+	if s.match.Method != interactiveMatch || s.match.Selection != 1 || s.match.Results != 2 {
+		t.Errorf("match = %+v, want the first of 2 interactive results", s.match)
+	}
+}
+
+func TestHandleQueryMovieSelection(t *testing.T) {
+	s := newReplaySelector(t, "2\n")
+	media, err := s.HandleQuery(0, 1, "/in/The.Matrix.1999.mkv", "the matrix 1999", false, []string{}, "", 1)
+	if err != nil {
+		t.Fatalf("HandleQuery error: %v", err)
+	}
+	if media.GetId() != 684428 {
+		t.Errorf("HandleQuery = %s (%d), want The Matrix Revisited (684428)", media.GetName(), media.GetId())
+	}
+}
+
+func TestHandleQueryEpisode(t *testing.T) {
+	// the show is selected first, then the episode of its season
+	s := newReplaySelector(t, "\n\n")
+	media, err := s.HandleQuery(0, 1, "/in/Breaking.Bad.S01E02.mkv", "breaking bad s01e02", false, []string{}, "", 1)
+	if err != nil {
+		t.Fatalf("HandleQuery error: %v", err)
+	}
+	episode, ok := media.(TvEpisode)
+	if !ok || episode.Id != 62086 || episode.TvId != 1396 {
+		t.Fatalf("HandleQuery = %s (%d), want Cat's in the Bag... (62086)", media.GetName(), media.GetId())
+	}
+
+	// the show is reused for the next episode
+	s.reader = bufio.NewReader(strings.NewReader("\n"))
+	media, err = s.HandleQuery(0, 1, "/in/Breaking.Bad.S01E03.mkv", "breaking bad s01e03", false, []string{}, "", 1)
+	if err != nil {
+		t.Fatalf("HandleQuery error: %v", err)
+	}
+	if media.GetId() != 62087 {
+		t.Errorf("HandleQuery = %s (%d), want ...And the Bag's in the River (62087)", media.GetName(), media.GetId())
+	}
+}
+
+func TestHandleQueryEpisodeRange(t *testing.T) {
+	s := newReplaySelector(t, "\n\n")
+	media, err := s.HandleQuery(0, 1, "/in/Breaking.Bad.S01E01E02.mkv", "breaking bad s01e01e02", false, []string{}, "", 1)
+	if err != nil {
+		t.Fatalf("HandleQuery error: %v", err)
+	}
+	episode, ok := media.(TvEpisode)
+	if !ok || episode.Id != 62085 || episode.LastEpisode != 2 || len(episode.ExtraIds) != 1 || episode.ExtraIds[0] != 62086 {
+		t.Errorf("HandleQuery = %+v, want episodes 1-2", media)
+	}
+}
+
+func TestHandleQuerySkipAndQuit(t *testing.T) {
+	s := newReplaySelector(t, "s\n")
+	_, err := s.HandleQuery(0, 1, "/in/The.Matrix.1999.mkv", "the matrix 1999", false, []string{}, "", 1)
+	if err != ErrSkipped {
+		t.Errorf("HandleQuery error = %v, want %v", err, ErrSkipped)
+	}
+
+	s = newReplaySelector(t, "q\n")
+	_, err = s.HandleQuery(0, 1, "/in/The.Matrix.1999.mkv", "the matrix 1999", false, []string{}, "", 1)
+	if err != ErrQuit {
+		t.Errorf("HandleQuery error = %v, want %v", err, ErrQuit)
+	}
+}
+
+func TestExtractLastEpisode(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"show s05e01e02", 2},
+		{"show s05e01 e02 720p", 2},
+		{"show s05e01 e02 e03", 3},
+		{"show s05e01", 0},
+		{"se7en 1995", 0},
+		{"show s01e03 se7en e09", 0},
+		{"the e20 show s01e01", 0},
+	}
+	for _, test := range tests {
+		if got := extractLastEpisode(test.query); got != test.want {
+			t.Errorf("extractLastEpisode(%q) = %d, want %d", test.query, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Session tracks progress across all in files of a single run
+type Session struct {
+	total        int
+	current      int
+	bytesCopied  int64
+	copyDuration time.Duration
+	startedAt    time.Time
+	placed       int
+	skipped      int
+	deferred     int
+	failures     []sessionFailure
+	reviews      []sessionFailure
+	quit         bool
+}
+
+type sessionFailure struct {
+	file string
+	err  error
+}
+
+func NewSession(total int) *Session {
+	return &Session{
+		total:     total,
+		startedAt: time.Now(),
+	}
+}
+
+// Start marks the in file at index i as the current one,
+// all previous files have been handled
+func (s *Session) Start(i int) {
+	s.current = i + 1
+}
+
+func (s *Session) Placed() {
+	s.placed += 1
+}
+
+func (s *Session) Skipped() {
+	s.skipped += 1
+}
+
+// Deferred counts an in file left for the next run
+func (s *Session) Deferred() {
+	s.deferred += 1
+}
+
+func (s *Session) Failed(file string, err error) {
+	s.failures = append(s.failures, sessionFailure{file, err})
+}
+
+// NeedsReview records an in file that couldn't be matched without prompting
+func (s *Session) NeedsReview(file string, err error) {
+	s.reviews = append(s.reviews, sessionFailure{file, err})
+}
+
+// Quit marks the session as ended early by the user
+func (s *Session) Quit() {
+	s.quit = true
+}
+
+func (s *Session) HasQuit() bool {
+	return s.quit
+}
+
+// Worked reports whether any file was placed or failed
+func (s *Session) Worked() bool {
+	return s.placed > 0 || len(s.failures) > 0
+}
+
+func (s *Session) HasFailures() bool {
+	return len(s.failures) > 0
+}
+
+func (s *Session) Copied(bytes int64, duration time.Duration) {
+	s.bytesCopied += bytes
+	s.copyDuration += duration
+}
+
+func (s *Session) Throughput() float64 {
+	seconds := s.copyDuration.Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(s.bytesCopied) / seconds
+}
+
+// Remaining estimates time left from the average time spent per handled file
+func (s *Session) Remaining() (time.Duration, bool) {
+	handled := s.current - 1
+	if handled <= 0 {
+		return 0, false
+	}
+	perFile := time.Since(s.startedAt) / time.Duration(handled)
+	return perFile * time.Duration(s.total-handled), true
+}
+
+func (s *Session) Status() string {
+	line := fmt.Sprintf(tr("Files: %d/%d, copied: %s"), s.current, s.total, humanize.Bytes(uint64(s.bytesCopied)))
+	if throughput := s.Throughput(); throughput > 0 {
+		line += fmt.Sprintf(tr(", throughput: %s/s"), humanize.Bytes(uint64(throughput)))
+	}
+	if remaining, ok := s.Remaining(); ok {
+		line += fmt.Sprintf(tr(", remaining: %s"), remaining.Round(time.Second))
+	}
+	return line
+}
+
+func (s *Session) Summary() string {
+	elapsed := time.Since(s.startedAt).Round(time.Second)
+	return fmt.Sprintf(tr("Processed %d/%d files, copied %s in %s"), s.current, s.total, humanize.Bytes(uint64(s.bytesCopied)), elapsed)
+}
+
+// Report is the end-of-run summary including all failures
+func (s *Session) Report() string {
+	var b strings.Builder
+	fmt.Fprintln(&b, s.Summary())
+	fmt.Fprintf(&b, tr("Placed: %d, skipped: %d, failed: %d\n"), s.placed, s.skipped, len(s.failures))
+	if s.deferred > 0 {
+		fmt.Fprintf(&b, tr("Deferred to the next run: %d\n"), s.deferred)
+	}
+	if len(s.failures) > 0 {
+		fmt.Fprintln(&b, tr("Failures:"))
+		for _, f := range s.failures {
+			fmt.Fprintf(&b, "  %s: %s\n", f.file, f.err)
+		}
+	}
+	if len(s.reviews) > 0 {
+		fmt.Fprintln(&b, tr("Needs review:"))
+		for _, f := range s.reviews {
+			fmt.Fprintf(&b, "  %s: %s\n", f.file, f.err)
+		}
+	}
+	return b.String()
+}
+
+func (s *Session) Subject() string {
+	if s.HasFailures() {
+		return fmt.Sprintf("%s: %d failed, %d placed", BinName, len(s.failures), s.placed)
+	}
+	return fmt.Sprintf("%s: %d placed", BinName, s.placed)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// sidecar files other than subtitles that media servers read next to a video
+	sidecarExts = []string{".nfo", ".jpg", ".jpeg", ".png", ".tbn"}
+	// artwork may also be named with a type, ie. "Movie-fanart.jpg"
+	artworkExts = []string{".jpg", ".jpeg", ".png", ".tbn"}
+)
+
+// findCompanions returns the files next to moviePath with one of exts that
+// share its file name followed by ".", or by "-" for typedExts, ie.
+// "Movie.en.srt", "Movie.nfo" or "Movie-poster.jpg"
+func findCompanions(moviePath string, exts, typedExts []string) ([]string, error) {
+	companions := []string{}
+	dir := filepath.Dir(moviePath)
+	base := fNameSansExtension(moviePath)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return companions, err
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(f.Name()))
+		if strings.HasPrefix(f.Name(), base+".") && stringSliceContains(exts, ext) {
+			companions = append(companions, filepath.Join(dir, f.Name()))
+		} else if strings.HasPrefix(f.Name(), base+"-") && stringSliceContains(typedExts, ext) {
+			companions = append(companions, filepath.Join(dir, f.Name()))
+		}
+	}
+	return companions, nil
+}
+
+// placeCompanions places the companions of moviePath found with exts and
+// typedExts next to outFile, renamed after it, with place
+func placeCompanions(moviePath, outFile string, exts, typedExts []string, place func(src, dst string, move bool) error, move bool) error {
+	companions, err := findCompanions(moviePath, exts, typedExts)
+	if err != nil {
+		return err
+	}
+
+	for _, companion := range companions {
+		dst := subtitleOutFile(companion, moviePath, outFile)
+		fmt.Printf("%s %s %s\n", ColorStr(RedColor, companion), ColorStr(WhiteColor, arrowStr()), ColorStr(GreenColor, dst))
+		err = place(companion, dst, move)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// placeCopy copies src to dst, removing src when move is set
+func placeCopy(src, dst string, move bool) error {
+	err := CopyFile(src, dst)
+	if err != nil || !move {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// placeSidecars places the nfo and artwork files of moviePath next to outFile,
+// renamed after it
+func placeSidecars(moviePath, outFile string, move bool) error {
+	return placeCompanions(moviePath, outFile, sidecarExts, artworkExts, placeCopy, move)
+}
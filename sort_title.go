@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	keepArticles  = "keep"
+	moveArticles  = "move"
+	stripArticles = "strip"
+)
+
+// sortTitle moves a leading article of title to its end, ie.
+// "Matrix, The", or strips it, depending on mode
+func sortTitle(title, mode string, articles []string) string {
+	if mode != moveArticles && mode != stripArticles {
+		return title
+	}
+
+	words := strings.Fields(title)
+	if len(words) < 2 || !stringSliceContains(articles, strings.ToLower(words[0])) {
+		return title
+	}
+
+	rest := strings.Join(words[1:], " ")
+	if mode == stripArticles {
+		return rest
+	}
+	return fmt.Sprintf("%s, %s", rest, words[0])
+}
+
+// applySortTitle rewrites the title at the start of the top directory of
+// an out path relative to the out dir, so that libraries sorting by folder
+// name ignore articles, while file names keep the title as it is
+func applySortTitle(path string, media Media, mode string, articles []string) string {
+	var title string
+	switch m := media.(type) {
+	case Movie:
+		if m.PathFolder != "" {
+			return path
+		}
+		title = m.GetName()
+	case TvEpisode:
+		if m.PathFolder != "" {
+			return path
+		}
+		title = m.TvName
+	default:
+		return path
+	}
+
+	title = sanitizeFileName(title)
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], title) {
+		return path
+	}
+	return fmt.Sprintf("%s%s/%s", sortTitle(title, mode, articles), strings.TrimPrefix(parts[0], title), parts[1])
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// isStable reports whether path is no longer being written to. Files modified
+// within interval are checked for a size change over the interval, and all
+// files for being open for writing by another process.
+func isStable(path string, interval time.Duration) (bool, error) {
+	if interval <= 0 {
+		return true, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	if time.Since(info.ModTime()) < interval {
+		time.Sleep(interval)
+		after, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		if after.Size() != info.Size() || !after.ModTime().Equal(info.ModTime()) {
+			return false, nil
+		}
+	}
+
+	open, err := openForWriting(path)
+	if err != nil {
+		// not being able to tell is not a reason to hold back the file
+		return true, nil
+	}
+	return !open, nil
+}
+
+// isTooRecent reports whether path was modified less than minAge ago
+func isTooRecent(path string, minAge time.Duration) (bool, error) {
+	if minAge <= 0 {
+		return false, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	return time.Since(info.ModTime()) < minAge, nil
+}
+
+// openForWriting reports whether any process has path open for writing,
+// using /proc when available and lsof otherwise
+func openForWriting(path string) (bool, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := os.Stat("/proc/self/fd"); err == nil {
+		return procOpenForWriting(abs)
+	}
+
+	if _, err := exec.LookPath("lsof"); err == nil {
+		return lsofOpenForWriting(abs)
+	}
+
+	return false, nil
+}
+
+// files open for writing by other processes, from a scan of /proc at the
+// first check of the run
+var (
+	procWritableOnce  sync.Once
+	procWritablePaths map[string]bool
+	procWritableErr   error
+)
+
+func procOpenForWriting(path string) (bool, error) {
+	procWritableOnce.Do(func() {
+		procWritablePaths, procWritableErr = procWritableFiles()
+	})
+	return procWritablePaths[path], procWritableErr
+}
+
+// procWritableFiles returns the files other processes have open for writing
+func procWritableFiles() (map[string]bool, error) {
+	procs, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]bool)
+	self := strconv.Itoa(os.Getpid())
+	for _, proc := range procs {
+		if _, err := strconv.Atoi(proc.Name()); err != nil || proc.Name() == self {
+			continue
+		}
+
+		fdDir := filepath.Join("/proc", proc.Name(), "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			// process exited or belongs to another user
+			continue
+		}
+
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			// sockets, pipes and devices are never in files
+			if err != nil || !strings.HasPrefix(target, "/") || paths[target] ||
+				strings.HasPrefix(target, "/dev/") || strings.HasPrefix(target, "/proc/") {
+				continue
+			}
+			if procFdWritable(filepath.Join("/proc", proc.Name(), "fdinfo", fd.Name())) {
+				paths[target] = true
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// procFdWritable reads the open flags of a file descriptor from its fdinfo
+func procFdWritable(fdInfoPath string) bool {
+	f, err := os.Open(fdInfoPath)
+	if err != nil {
+		// assume the worst when the descriptor can't be inspected
+		return true
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "flags:" {
+			flags, err := strconv.ParseInt(fields[1], 8, 64)
+			if err != nil {
+				return true
+			}
+			return flags&int64(os.O_WRONLY|os.O_RDWR) != 0
+		}
+	}
+	return true
+}
+
+func lsofOpenForWriting(path string) (bool, error) {
+	out, err := exec.Command("lsof", "-F", "a", "--", path).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			// lsof exits with 1 when no process has the file open
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "a") && strings.ContainsAny(line[1:], "wu") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
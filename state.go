@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// DirDecision remembers what was chosen for the in files of a directory,
+// so files added to it later are matched without prompting for the show:
+// the type they are matched as, the tv show and the stop words dropped from
+// their names, which keep being dropped when the stop words change
+type DirDecision struct {
+	Type      string    `json:"type"`
+	Provider  string    `json:"provider,omitempty"`
+	TvId      int64     `json:"tv_id,omitempty"`
+	TvName    string    `json:"tv_name,omitempty"`
+	StopWords []string  `json:"stop_words,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// State is read from the state file, decisions are keyed by in file directory
+type State struct {
+	Dirs map[string]DirDecision `json:"dirs"`
+}
+
+func readState(statePath string) (*State, error) {
+	state := &State{Dirs: make(map[string]DirDecision)}
+
+	exists, err := fileExists(statePath)
+	if err != nil || !exists {
+		return state, err
+	}
+
+	b, err := ioutil.ReadFile(statePath)
+	if err != nil {
+		return state, err
+	}
+
+	err = json.Unmarshal(b, state)
+	if err != nil {
+		return state, fmt.Errorf("parsing %s: %w", statePath, err)
+	}
+
+	if state.Dirs == nil {
+		state.Dirs = make(map[string]DirDecision)
+	}
+	return state, nil
+}
+
+func writeState(statePath string, state *State) error {
+	stateJson, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(statePath, stateJson, 0644)
+}
+
+// decide records the media selected with provider for an in file of dir,
+// along with the stop words dropped from its name, it returns false when the
+// decision did not change
+func (s *State) decide(dir, provider string, media Media, stopWords []string) bool {
+	decision := DirDecision{Type: media.GetType(), Provider: provider, StopWords: sortUniq(stopWords), UpdatedAt: time.Now()}
+	if episode, ok := media.(TvEpisode); ok {
+		decision.TvId = episode.TvId
+		decision.TvName = episode.TvName
+	}
+
+	previous, ok := s.Dirs[dir]
+	if ok && previous.Type == decision.Type && previous.Provider == decision.Provider && previous.TvId == decision.TvId {
+		decision.StopWords = sortUniq(append(decision.StopWords, previous.StopWords...))
+		if strings.Join(decision.StopWords, ",") == strings.Join(previous.StopWords, ",") {
+			return false
+		}
+	}
+	s.Dirs[dir] = decision
+	return true
+}
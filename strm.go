@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// .strm files hold the url of remote media, which kodi and jellyfin play
+// like a local file
+const strmExt = ".strm"
+
+// strmVerb writes a .strm file pointing at the in file instead of placing it
+const strmVerb = "stream"
+
+func isStrm(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == strmExt
+}
+
+// readStrmUrl returns the url of a .strm file, its first line that is not
+// empty or a comment
+func readStrmUrl(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s has no url", path)
+}
+
+// strmUrl returns the url of an in file below baseUrl, by its path relative
+// to its in dir, ie. an in dir of a cloud drive that is also served over http
+func strmUrl(baseUrl, moviePath, inDir string) (string, error) {
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return "", err
+	}
+	relativePath, err := filepath.Rel(inDir, moviePath)
+	if err != nil {
+		return "", err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + filepath.ToSlash(relativePath)
+	return u.String(), nil
+}
+
+// strmOutFile returns outFile with the .strm extension
+func strmOutFile(outFile string) string {
+	return strings.TrimSuffix(outFile, filepath.Ext(outFile)) + strmExt
+}
+
+func writeStrm(outFile, mediaUrl string) error {
+	return ioutil.WriteFile(outFile, []byte(mediaUrl+"\n"), 0644)
+}
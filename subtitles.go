@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+var (
+	subtitleExts = []string{".srt", ".sub", ".idx", ".ass", ".ssa", ".vtt", ".smi"}
+	// binary or ambiguous formats that are never converted
+	binarySubtitleExts = []string{".sub", ".idx"}
+	utf8Bom            = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// subtitleOutFile names the subtitle or sidecar after the out file, keeping its
+// language suffix or artwork type, ie. ".en.srt" or "-poster.jpg"
+func subtitleOutFile(subtitlePath, moviePath, outFile string) string {
+	suffix := strings.TrimPrefix(filepath.Base(subtitlePath), fNameSansExtension(moviePath))
+	ext := filepath.Ext(suffix)
+	suffix = suffix[0:len(suffix)-len(ext)] + strings.ToLower(ext)
+	outExt := filepath.Ext(outFile)
+	return outFile[0:len(outFile)-len(outExt)] + suffix
+}
+
+// isGbk reports whether every non-ascii byte of b is part of a valid GBK pair
+func isGbk(b []byte) bool {
+	pairs := 0
+	for i := 0; i < len(b); i++ {
+		if b[i] < 0x80 {
+			continue
+		}
+		if b[i] == 0x80 || b[i] == 0xFF || i+1 >= len(b) || b[i+1] < 0x40 || b[i+1] == 0x7F || b[i+1] == 0xFF {
+			return false
+		}
+		pairs += 1
+		i++
+	}
+	return pairs > 0
+}
+
+// letterScore counts the non-ascii letters of b decoded with enc,
+// the decoding producing the most letters is the most plausible
+func letterScore(b []byte, enc encoding.Encoding) int {
+	decoded, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return -1
+	}
+	score := 0
+	for _, r := range string(decoded) {
+		if r >= utf8.RuneSelf {
+			if unicode.IsLetter(r) {
+				score += 1
+			} else {
+				score -= 1
+			}
+		}
+	}
+	return score
+}
+
+// detectSubtitleEncoding returns the encoding of non UTF-8 subtitle content,
+// and its name, or nil when b already is valid UTF-8
+func detectSubtitleEncoding(b []byte) (encoding.Encoding, string) {
+	if utf8.Valid(b) {
+		return nil, "utf-8"
+	}
+
+	if isGbk(b) {
+		return simplifiedchinese.GBK, "gbk"
+	}
+
+	if letterScore(b, charmap.Windows1250) > letterScore(b, charmap.Windows1252) {
+		return charmap.Windows1250, "windows-1250"
+	}
+	return charmap.Windows1252, "windows-1252"
+}
+
+// convertSubtitle returns the subtitle content as UTF-8, with the
+// name of the encoding it was converted from, or "" if unchanged
+func convertSubtitle(b []byte) ([]byte, string, error) {
+	b = bytes.TrimPrefix(b, utf8Bom)
+	enc, name := detectSubtitleEncoding(b)
+	if enc == nil {
+		return b, "", nil
+	}
+	converted, err := enc.NewDecoder().Bytes(b)
+	return converted, name, err
+}
+
+// placeSubtitle copies or moves a subtitle to dst, converting
+// text subtitles to UTF-8 when subtitle-utf8 is set
+func placeSubtitle(src, dst string, move bool) error {
+	ext := strings.ToLower(filepath.Ext(src))
+	if !*subtitleUtf8Flag || stringSliceContains(binarySubtitleExts, ext) {
+		return placeCopy(src, dst, move)
+	}
+
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	converted, from, err := convertSubtitle(b)
+	if err != nil {
+		return fmt.Errorf("converting %s: %w", src, err)
+	}
+	if from != "" {
+		fmt.Printf(tr("Converted subtitle %s from %s to utf-8\n"), src, from)
+	}
+
+	err = ioutil.WriteFile(dst, converted, 0644)
+	if err != nil || !move {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// placeSubtitles places the companion subtitles of moviePath next to outFile
+func placeSubtitles(moviePath, outFile string, move bool) error {
+	return placeCompanions(moviePath, outFile, subtitleExts, nil, placeSubtitle, move)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	mkvpropeditBin = "mkvpropedit"
+	ffmpegBin      = "ffmpeg"
+)
+
+type mkvSimpleTag struct {
+	Name   string `xml:"Name"`
+	String string `xml:"String"`
+}
+
+type mkvTags struct {
+	XMLName         xml.Name       `xml:"Tags"`
+	TargetTypeValue int            `xml:"Tag>Targets>TargetTypeValue"`
+	Simple          []mkvSimpleTag `xml:"Tag>Simple"`
+}
+
+// tmdbTag identifies media as "movie/603" or "tv/1399"
+func tmdbTag(media Media) string {
+	mediaType := media.GetType()
+	if mediaType == "tv_episode" {
+		mediaType = "tv"
+	}
+	return fmt.Sprintf("%s/%d", mediaType, media.GetId())
+}
+
+// breakHardLink replaces outFile with a copy of itself when it shares an
+// inode with inFile, so editing tags in place leaves inFile untouched
+func breakHardLink(inFile, outFile string) error {
+	inInfo, err := os.Stat(inFile)
+	if err != nil {
+		// in file is gone (moved), nothing is shared
+		return nil
+	}
+	outInfo, err := os.Stat(outFile)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(inInfo, outInfo) {
+		return nil
+	}
+
+	tmp := fmt.Sprintf("%s.%s-tmp", outFile, BinName)
+	err = copyFileContents(inFile, tmp)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, outFile)
+}
+
+func tagMkv(outFile string, media Media) error {
+	tags := mkvTags{
+		TargetTypeValue: 50,
+		Simple: []mkvSimpleTag{
+			{"TITLE", media.GetName()},
+			{"DATE_RELEASED", media.GetYear()},
+			{"TMDB", tmdbTag(media)},
+		},
+	}
+	b, err := xml.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tagsFile, err := ioutil.TempFile("", fmt.Sprintf("%s-tags-*.xml", BinName))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tagsFile.Name())
+
+	_, err = tagsFile.Write(append([]byte(xml.Header), b...))
+	tagsFile.Close()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(mkvpropeditBin, outFile,
+		"--edit", "info", "--set", fmt.Sprintf("title=%s", media.GetName()),
+		"--tags", fmt.Sprintf("global:%s", tagsFile.Name()))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// tagWithFfmpeg rewrites the container with new metadata without re-encoding.
+// It copies the whole file, so it is only used for containers without an in
+// place writer, ie. avi.
+func tagWithFfmpeg(outFile string, media Media) error {
+	ext := filepath.Ext(outFile)
+	tmp := fmt.Sprintf("%s.%s-tmp%s", outFile[0:len(outFile)-len(ext)], BinName, ext)
+
+	cmd := exec.Command(ffmpegBin, "-v", "error", "-y", "-i", outFile,
+		"-map", "0", "-c", "copy",
+		"-metadata", fmt.Sprintf("title=%s", media.GetName()),
+		"-metadata", fmt.Sprintf("date=%s", media.GetYear()),
+		"-metadata", fmt.Sprintf("comment=tmdb:%s", tmdbTag(media)),
+		tmp)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return os.Rename(tmp, outFile)
+}
+
+// tagMetadata writes title, year and moviedb id into the container of
+// the placed out file, so identification survives outside renames
+func tagMetadata(inFile, outFile string, media Media) error {
+	if strings.ToLower(filepath.Ext(outFile)) == ".mkv" {
+		if _, err := exec.LookPath(mkvpropeditBin); err != nil {
+			return fmt.Errorf("%s not found", mkvpropeditBin)
+		}
+		err := breakHardLink(inFile, outFile)
+		if err != nil {
+			return err
+		}
+		return tagMkv(outFile, media)
+	} else if stringSliceContains(mp4Exts, strings.ToLower(filepath.Ext(outFile))) {
+		err := breakHardLink(inFile, outFile)
+		if err != nil {
+			return err
+		}
+		return tagMp4(outFile, media)
+	}
+
+	if _, err := exec.LookPath(ffmpegBin); err != nil {
+		return fmt.Errorf("%s not found", ffmpegBin)
+	}
+	return tagWithFfmpeg(outFile, media)
+}
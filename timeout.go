@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrOpTimeout is returned when a file operation does not finish within
+// op-timeout, ie. because of a hung network mount
+var ErrOpTimeout = errors.New("operation timed out")
+
+// timedOutPaths are the paths of operations that timed out and are still
+// running in the background, which must not be operated on again until they
+// finish, ie. by the retry of a copy to the same out file
+var timedOutPaths = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: make(map[string]bool)}
+
+func setTimedOut(path string, running bool) {
+	if path == "" {
+		return
+	}
+	timedOutPaths.Lock()
+	defer timedOutPaths.Unlock()
+	if running {
+		timedOutPaths.paths[path] = true
+	} else {
+		delete(timedOutPaths.paths, path)
+	}
+}
+
+func timedOut(path string) bool {
+	timedOutPaths.Lock()
+	defer timedOutPaths.Unlock()
+	return timedOutPaths.paths[path]
+}
+
+// withTimeout runs fn, giving up after op-timeout. The operation itself
+// cannot be interrupted and keeps running in the background.
+func withTimeout(op string, fn func() error) error {
+	_, err := withPathTimeout(op, "", func() (interface{}, error) {
+		return nil, fn()
+	}, nil)
+	return err
+}
+
+// withPathTimeout runs fn on path, giving up after op-timeout, and returns
+// its result over a channel so that nothing is shared with an operation left
+// running in the background. Until such an operation finishes, further
+// operations on path fail right away, and abandon is called once it does.
+func withPathTimeout(op, path string, fn func() (interface{}, error), abandon func()) (interface{}, error) {
+	if path != "" && timedOut(path) {
+		return nil, fmt.Errorf("%s: an earlier operation on %s is still running: %w", op, path, ErrOpTimeout)
+	}
+	if *opTimeoutFlag <= 0 {
+		return fn()
+	}
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan result, 1)
+	var mutex sync.Mutex
+	given := false
+	go func() {
+		value, err := fn()
+		mutex.Lock()
+		defer mutex.Unlock()
+		if given {
+			if abandon != nil {
+				abandon()
+			}
+			setTimedOut(path, false)
+			return
+		}
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-time.After(*opTimeoutFlag):
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	select {
+	case r := <-done:
+		// finished while timing out
+		return r.value, r.err
+	default:
+	}
+	given = true
+	setTimedOut(path, true)
+	return nil, fmt.Errorf("%s after %s: %w", op, *opTimeoutFlag, ErrOpTimeout)
+}
+
+func statWithTimeout(path string) (os.FileInfo, error) {
+	info, err := withPathTimeout(fmt.Sprintf("stat %s", path), "", func() (interface{}, error) {
+		return os.Stat(path)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return info.(os.FileInfo), nil
+}
+
+func sameFileWithTimeout(file1, file2 string) (bool, error) {
+	same, err := withPathTimeout(fmt.Sprintf("compare %s", file1), "", func() (interface{}, error) {
+		return SameFile(file1, file2)
+	}, nil)
+	if err != nil {
+		return false, err
+	}
+	return same.(bool), nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type tokenStat struct {
+	Token string   `json:"token"`
+	Count int      `json:"count"`
+	Files []string `json:"files"`
+}
+
+type tokenReport struct {
+	Files              int         `json:"files"`
+	Tokens             []tokenStat `json:"tokens"`
+	StopWordCandidates []string    `json:"stop_word_candidates"`
+}
+
+// buildTokenReport counts, for each query token, the in files it was found in.
+// Tokens found in at least threshold percent of files are stop word candidates.
+func buildTokenReport(movieList []string, inDirs []string, stopWords []string, threshold float64) tokenReport {
+	byToken := make(map[string][]string)
+	for _, moviePath := range movieList {
+		query := splitSortUniq(GetQuery(moviePath, inDirFor(inDirs, moviePath), stopWords))
+		myQuery, _, _, _ := extractTvSeasonEpisodeFromQuery(strings.Join(query, " "))
+		for _, token := range sortUniq(strings.Fields(myQuery)) {
+			byToken[token] = append(byToken[token], moviePath)
+		}
+	}
+
+	report := tokenReport{
+		Files:              len(movieList),
+		Tokens:             []tokenStat{},
+		StopWordCandidates: []string{},
+	}
+	for token, files := range byToken {
+		report.Tokens = append(report.Tokens, tokenStat{token, len(files), files})
+	}
+	sort.Slice(report.Tokens, func(i, j int) bool {
+		if report.Tokens[i].Count != report.Tokens[j].Count {
+			return report.Tokens[i].Count > report.Tokens[j].Count
+		}
+		return report.Tokens[i].Token < report.Tokens[j].Token
+	})
+
+	for _, stat := range report.Tokens {
+		if len(movieList) > 0 && float64(stat.Count)*100/float64(len(movieList)) >= threshold {
+			report.StopWordCandidates = append(report.StopWordCandidates, stat.Token)
+		}
+	}
+	sort.Strings(report.StopWordCandidates)
+
+	return report
+}
+
+// printTokens prints the unique query tokens of all in files, with
+// token-stats their frequencies, files and stop word candidates
+func printTokens(movieList []string, inDirs []string, stopWords []string) error {
+	if !*tokenStatsFlag && !*tokenJsonFlag {
+		tokens := []string{}
+		for _, moviePath := range movieList {
+			query := splitSortUniq(GetQuery(moviePath, inDirFor(inDirs, moviePath), stopWords))
+			myQuery, _, _, _ := extractTvSeasonEpisodeFromQuery(strings.Join(query, " "))
+			tokens = append(tokens, strings.Fields(myQuery)...)
+		}
+		for _, token := range sortUniq(tokens) {
+			fmt.Println(token)
+		}
+		return nil
+	}
+
+	report := buildTokenReport(movieList, inDirs, stopWords, *stopWordThresholdFlag)
+
+	if *tokenJsonFlag {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(report)
+	}
+
+	for _, stat := range report.Tokens {
+		fmt.Printf("%s %d\n", ColorStr(WhiteColor, stat.Token), stat.Count)
+		for _, file := range stat.Files {
+			fmt.Printf("    %s\n", file)
+		}
+	}
+	fmt.Printf(tr("\nStop word candidates (in at least %g%% of %d files):\n%s\n"), *stopWordThresholdFlag, report.Files, strings.Join(report.StopWordCandidates, ","))
+	return nil
+}
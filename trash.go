@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const quarantineTimeFormat = "20060102T150405"
+
+// moveFile renames src to dst, falling back to copy and remove across file systems
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	err = copyFileContents(src, dst)
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// uniquePath returns dir/name, or dir/name.N if it already exists
+func uniquePath(dir, name string) (string, error) {
+	candidate := filepath.Join(dir, name)
+	for n := 2; ; n++ {
+		exists, err := fileExists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s.%d", name, n))
+	}
+}
+
+// xdgTrashDir returns the trash path is moved to, the home trash when path
+// is on the file system of the home directory, or the trash in the top
+// directory of the mount of path, along with that top directory
+func xdgTrashDir(path string) (string, string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	homeTrash := filepath.Join(dataHome, "Trash")
+
+	dev, err := fileDevice(filepath.Dir(path))
+	if err != nil {
+		// not supported on this platform
+		return homeTrash, "", nil
+	}
+	for dir := homeTrash; ; dir = filepath.Dir(dir) {
+		if homeDev, err := fileDevice(dir); err == nil {
+			if homeDev == dev {
+				return homeTrash, "", nil
+			}
+			break
+		} else if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	topDir := filepath.Dir(path)
+	for parent := filepath.Dir(topDir); parent != topDir; parent = filepath.Dir(topDir) {
+		if parentDev, err := fileDevice(parent); err != nil || parentDev != dev {
+			break
+		}
+		topDir = parent
+	}
+
+	// a trash set up by the administrator, sticky and not a symlink, is
+	// preferred to one of our own
+	uid := os.Getuid()
+	if info, err := os.Lstat(filepath.Join(topDir, ".Trash")); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		return filepath.Join(topDir, ".Trash", fmt.Sprintf("%d", uid)), topDir, nil
+	}
+	return filepath.Join(topDir, fmt.Sprintf(".Trash-%d", uid)), topDir, nil
+}
+
+// xdgTrash moves path to the freedesktop.org trash so it can be
+// restored with the desktop's file manager
+func xdgTrash(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	trashDir, topDir, err := xdgTrashDir(path)
+	if err != nil {
+		return err
+	}
+
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			return err
+		}
+	}
+
+	dst, err := uniquePath(filesDir, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	// paths in the trash of a mount are relative to its top directory
+	trashedPath := path
+	if topDir != "" {
+		trashedPath, err = filepath.Rel(topDir, path)
+		if err != nil {
+			return err
+		}
+	}
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: filepath.ToSlash(trashedPath)}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	infoPath := filepath.Join(infoDir, fmt.Sprintf("%s.trashinfo", filepath.Base(dst)))
+	err = ioutil.WriteFile(infoPath, []byte(info), 0600)
+	if err != nil {
+		return err
+	}
+
+	err = moveFile(path, dst)
+	if err != nil {
+		os.Remove(infoPath)
+	}
+	return err
+}
+
+// quarantine moves path into dir, prefixed with the current time
+// so that purgeQuarantine can remove it after the retention period.
+// It returns the new path of the file.
+func quarantine(path, dir string) (string, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s-%s", time.Now().Format(quarantineTimeFormat), filepath.Base(path))
+	dst, err := uniquePath(dir, name)
+	if err != nil {
+		return "", err
+	}
+
+	return dst, moveFile(path, dst)
+}
+
+// purgeQuarantine removes files quarantined longer than retention ago
+func purgeQuarantine(dir string, retention time.Duration) error {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		parts := strings.SplitN(f.Name(), "-", 2)
+		quarantinedAt, err := time.ParseInLocation(quarantineTimeFormat, parts[0], time.Local)
+		if err != nil {
+			// not quarantined by us
+			continue
+		}
+		if time.Since(quarantinedAt) > retention {
+			err = os.RemoveAll(filepath.Join(dir, f.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// trashSource removes a moved in file recoverably, either to the
+// quarantine dir when configured or to the desktop trash
+func trashSource(path string) error {
+	if *quarantineDirFlag != "" {
+		_, err := quarantine(path, *quarantineDirFlag)
+		return err
+	}
+	return xdgTrash(path)
+}
+
+// recycle moves an out file about to be overwritten into the recycle dir,
+// returning its new path
+func recycle(path string) (string, error) {
+	return quarantine(path, *recycleDirFlag)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// number of tv shows whose seasons are kept, the seasons
+	// of the least recently used show are dropped beyond it
+	tvCacheShows = 4
+	// shows are refetched after this long, so that long running
+	// watch sessions pick up newly listed episodes
+	tvCacheMaxAge = 6 * time.Hour
+)
+
+// tvCache keeps resolved tv shows and seasons for the whole session,
+// unlike the response cache which expires after a minute
+type tvCache struct {
+	mutex     sync.Mutex
+	shows     map[int64]Tv
+	seasons   map[int64]map[int]TvSeason
+	fetchedAt map[int64]time.Time
+	recent    []int64
+}
+
+func newTvCache() *tvCache {
+	return &tvCache{
+		shows:     make(map[int64]Tv),
+		seasons:   make(map[int64]map[int]TvSeason),
+		fetchedAt: make(map[int64]time.Time),
+	}
+}
+
+func (c *tvCache) evict(tvId int64) {
+	delete(c.shows, tvId)
+	delete(c.seasons, tvId)
+	delete(c.fetchedAt, tvId)
+}
+
+// expire drops tvId when it was fetched longer than tvCacheMaxAge ago
+func (c *tvCache) expire(tvId int64) {
+	if fetchedAt, ok := c.fetchedAt[tvId]; ok && time.Since(fetchedAt) > tvCacheMaxAge {
+		c.evict(tvId)
+	}
+}
+
+// touch marks tvId as most recently used and drops the least recently used shows
+func (c *tvCache) touch(tvId int64) {
+	recent := []int64{tvId}
+	for _, id := range c.recent {
+		if id != tvId {
+			recent = append(recent, id)
+		}
+	}
+
+	for len(recent) > tvCacheShows {
+		c.evict(recent[len(recent)-1])
+		recent = recent[:len(recent)-1]
+	}
+	c.recent = recent
+}
+
+func (c *tvCache) getTv(tvId int64) (Tv, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.expire(tvId)
+	tv, ok := c.shows[tvId]
+	if ok {
+		c.touch(tvId)
+	}
+	return tv, ok
+}
+
+func (c *tvCache) putTv(tv Tv) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.shows[tv.Id] = tv
+	if _, ok := c.fetchedAt[tv.Id]; !ok {
+		c.fetchedAt[tv.Id] = time.Now()
+	}
+	c.touch(tv.Id)
+}
+
+func (c *tvCache) getSeason(tvId int64, seasonNumber int) (TvSeason, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.expire(tvId)
+	tvSeason, ok := c.seasons[tvId][seasonNumber]
+	return tvSeason, ok
+}
+
+func (c *tvCache) putSeason(tvId int64, tvSeason TvSeason) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.seasons[tvId]; !ok {
+		c.seasons[tvId] = make(map[int]TvSeason)
+	}
+	if _, ok := c.fetchedAt[tvId]; !ok {
+		c.fetchedAt[tvId] = time.Now()
+	}
+	c.seasons[tvId][tvSeason.SeasonNumber] = tvSeason
+	c.touch(tvId)
+}
@@ -0,0 +1,728 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var tvdbUrlBase = "https://api4.thetvdb.com/v4"
+
+// tvdb names are translated to this language when they are in another one
+const tvdbLanguage = "eng"
+
+// Tvdb is a MetadataProvider backed by the TheTVDB v4 api
+type Tvdb struct {
+	ApiKey                string
+	Client                http.Client
+	loginClient           http.Client
+	replaying             bool
+	token                 string
+	tokenMutex            sync.Mutex
+	cache                 map[string]cacheResult
+	cacheMutex            sync.Mutex
+	cacheRetensionSeconds float64
+	tvCache               *tvCache
+}
+
+type tvdbLinks struct {
+	Next       *string `json:"next"`
+	TotalItems int     `json:"total_items"`
+	PageSize   int     `json:"page_size"`
+}
+
+type tvdbRemoteId struct {
+	Id         string `json:"id"`
+	SourceName string `json:"sourceName"`
+}
+
+type tvdbSearchResult struct {
+	TvdbId          string            `json:"tvdb_id"`
+	Name            string            `json:"name"`
+	Year            string            `json:"year"`
+	FirstAirTime    string            `json:"first_air_time"`
+	Overview        string            `json:"overview"`
+	PrimaryLanguage string            `json:"primary_language"`
+	Country         string            `json:"country"`
+	Translations    map[string]string `json:"translations"`
+	Overviews       map[string]string `json:"overviews"`
+}
+
+type tvdbSeries struct {
+	Id               int64          `json:"id"`
+	Name             string         `json:"name"`
+	FirstAired       string         `json:"firstAired"`
+	Overview         string         `json:"overview"`
+	OriginalLanguage string         `json:"originalLanguage"`
+	OriginalCountry  string         `json:"originalCountry"`
+	AverageRuntime   int            `json:"averageRuntime"`
+	NameTranslations []string       `json:"nameTranslations"`
+	Genres           []Genre        `json:"genres"`
+	RemoteIds        []tvdbRemoteId `json:"remoteIds"`
+	Seasons          []struct {
+		Number int `json:"number"`
+		Type   struct {
+			Type string `json:"type"`
+		} `json:"type"`
+	} `json:"seasons"`
+	SeasonTypes []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"seasonTypes"`
+}
+
+type tvdbEpisode struct {
+	Id           int64  `json:"id"`
+	Name         string `json:"name"`
+	Aired        string `json:"aired"`
+	SeasonNumber int    `json:"seasonNumber"`
+	Number       int    `json:"number"`
+	Overview     string `json:"overview"`
+	Image        string `json:"image"`
+}
+
+type tvdbCharacter struct {
+	Name       string `json:"name"`
+	PersonName string `json:"personName"`
+	PeopleType string `json:"peopleType"`
+	Sort       int    `json:"sort"`
+}
+
+type tvdbMovie struct {
+	Id               int64          `json:"id"`
+	Name             string         `json:"name"`
+	Year             string         `json:"year"`
+	OriginalLanguage string         `json:"originalLanguage"`
+	Runtime          int            `json:"runtime"`
+	NameTranslations []string       `json:"nameTranslations"`
+	Genres           []Genre        `json:"genres"`
+	RemoteIds        []tvdbRemoteId `json:"remoteIds"`
+	FirstRelease     struct {
+		Date string `json:"date"`
+	} `json:"first_release"`
+	Characters          []tvdbCharacter `json:"characters"`
+	ProductionCountries []struct {
+		Name string `json:"name"`
+	} `json:"production_countries"`
+}
+
+func NewTvdb(apiKey string) *Tvdb {
+	return &Tvdb{
+		ApiKey:                apiKey,
+		Client:                http.Client{Timeout: time.Second * 5},
+		loginClient:           http.Client{Timeout: time.Second * 5},
+		cache:                 make(map[string]cacheResult),
+		cacheRetensionSeconds: 60.0,
+		tvCache:               newTvCache(),
+	}
+}
+
+func (c *Tvdb) Name() string {
+	return tvdbProvider
+}
+
+// RecordHttp saves every api response in dir, except for the login
+// response which contains the session token
+func (c *Tvdb) RecordHttp(dir string) {
+	c.Client.Transport = &fixtureTransport{dir: dir, replay: false, next: http.DefaultTransport}
+}
+
+// ReplayHttp answers every api request from responses previously recorded in dir
+func (c *Tvdb) ReplayHttp(dir string) {
+	c.Client.Transport = &fixtureTransport{dir: dir, replay: true}
+	c.replaying = true
+}
+
+func newTvdbError(req *http.Request, res *http.Response, body []byte) *MovieDbError {
+	e := newMovieDbError(req, res, body)
+	tvdbErr := struct {
+		Message string `json:"message"`
+	}{}
+	if e.StatusMessage == "" && json.Unmarshal(body, &tvdbErr) == nil {
+		e.StatusMessage = tvdbErr.Message
+	}
+	return e
+}
+
+// login exchanges the api key for a session token, once per run
+func (c *Tvdb) login() (string, error) {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+
+	if c.replaying {
+		return "replay", nil
+	}
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	reqBody, err := json.Marshal(map[string]string{"apikey": c.ApiKey})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/login", tvdbUrlBase), bytes.NewReader(reqBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", "application/json")
+
+	apiUsage.Count()
+	res, err := c.loginClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return "", newTvdbError(req, res, body)
+	}
+
+	response := struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}{}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = response.Data.Token
+	return c.token, nil
+}
+
+// expireToken drops the session token unless another request has replaced
+// it already, so that the next request logs in again
+func (c *Tvdb) expireToken(token string) {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+func (c *Tvdb) get(path string, query url.Values) ([]byte, error) {
+	u := fmt.Sprintf("%s%s", tvdbUrlBase, path)
+	if len(query) > 0 {
+		u = fmt.Sprintf("%s?%s", u, query.Encode())
+	}
+
+	c.cacheMutex.Lock()
+	for k, entry := range c.cache {
+		if time.Since(entry.createdAt).Seconds() > c.cacheRetensionSeconds {
+			delete(c.cache, k)
+		}
+	}
+	if cacheResult, ok := c.cache[u]; ok {
+		c.cacheMutex.Unlock()
+		return cacheResult.body, nil
+	}
+	c.cacheMutex.Unlock()
+
+	var body []byte
+	for attempt := 0; ; attempt++ {
+		token, err := c.login()
+		if err != nil {
+			return nil, err
+		}
+
+		err = withApiRetry(func() error {
+			var err error
+			body, err = c.send(u, token)
+			return err
+		})
+		var tvdbErr *MovieDbError
+		if attempt == 0 && errors.As(err, &tvdbErr) && tvdbErr.StatusCode == http.StatusUnauthorized {
+			// session tokens expire after a month, long watch runs log in again
+			c.expireToken(token)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		break
+	}
+
+	c.cacheMutex.Lock()
+	c.cache[u] = cacheResult{body, time.Now()}
+	c.cacheMutex.Unlock()
+
+	return body, nil
+}
+
+func (c *Tvdb) send(u, token string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	apiUsage.Count()
+	res, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, newTvdbError(req, res, body)
+	}
+	return body, nil
+}
+
+// getData unmarshals the data of an api response into data, returning its links
+func (c *Tvdb) getData(path string, query url.Values, data interface{}) (tvdbLinks, error) {
+	response := struct {
+		Data  interface{} `json:"data"`
+		Links tvdbLinks   `json:"links"`
+	}{Data: data}
+
+	body, err := c.get(path, query)
+	if err != nil {
+		return response.Links, err
+	}
+
+	err = json.Unmarshal(body, &response)
+	return response.Links, err
+}
+
+// Ping checks that the api is reachable and the api key is accepted
+func (c *Tvdb) Ping() error {
+	_, err := c.login()
+	return err
+}
+
+func (c *Tvdb) search(kind, query string, page, year int) ([]tvdbSearchResult, int, int, error) {
+	const limit = 20
+
+	q := url.Values{}
+	q.Set("query", query)
+	q.Set("type", kind)
+	q.Set("limit", strconv.Itoa(limit))
+	q.Set("offset", strconv.Itoa((page-1)*limit))
+	if year > 0 {
+		q.Set("year", strconv.Itoa(year))
+	}
+
+	results := []tvdbSearchResult{}
+	links, err := c.getData("/search", q, &results)
+	if err != nil {
+		return results, 0, 0, err
+	}
+
+	totalPages := (links.TotalItems + limit - 1) / limit
+	if totalPages < 1 {
+		totalPages = 1
+	}
+	return results, links.TotalItems, totalPages, nil
+}
+
+// translated returns the tvdbLanguage translation of name, if there is one
+func (r tvdbSearchResult) translated() string {
+	if name, ok := r.Translations[tvdbLanguage]; ok && name != "" {
+		return name
+	}
+	return r.Name
+}
+
+func (r tvdbSearchResult) translatedOverview() string {
+	if overview, ok := r.Overviews[tvdbLanguage]; ok && overview != "" {
+		return overview
+	}
+	return r.Overview
+}
+
+func (r tvdbSearchResult) date() string {
+	if r.FirstAirTime != "" {
+		return r.FirstAirTime
+	}
+	return r.Year
+}
+
+func (c *Tvdb) SearchMovie(query string, page, year int) (SearchMovieResponse, error) {
+	results, total, totalPages, err := c.search("movie", query, page, year)
+	response := SearchMovieResponse{Page: page, TotalResults: total, TotalPages: totalPages}
+	if err != nil {
+		return response, err
+	}
+
+	for _, result := range results {
+		id, err := strconv.ParseInt(result.TvdbId, 10, 64)
+		if err != nil {
+			continue
+		}
+		response.Results = append(response.Results, Movie{
+			Id:               id,
+			Title:            result.translated(),
+			OriginalTitle:    result.Name,
+			ReleaseDate:      result.date(),
+			Overview:         result.translatedOverview(),
+			OriginalLanguage: result.PrimaryLanguage,
+		})
+	}
+	return response, nil
+}
+
+func (c *Tvdb) SearchTv(query string, page, year int) (SearchTvResponse, error) {
+	results, total, totalPages, err := c.search("series", query, page, year)
+	response := SearchTvResponse{Page: page, TotalResults: total, TotalPages: totalPages}
+	if err != nil {
+		return response, err
+	}
+
+	for _, result := range results {
+		id, err := strconv.ParseInt(result.TvdbId, 10, 64)
+		if err != nil {
+			continue
+		}
+		tv := Tv{
+			Id:               id,
+			Name:             result.translated(),
+			OriginalName:     result.Name,
+			FirstAirDate:     result.date(),
+			Overview:         result.translatedOverview(),
+			OriginalLanguage: result.PrimaryLanguage,
+		}
+		if result.Country != "" {
+			tv.OriginCountry = []string{result.Country}
+		}
+		response.Results = append(response.Results, tv)
+	}
+	return response, nil
+}
+
+// translatedName fetches the tvdbLanguage name of a series or movie
+// when its original name is in another language
+func (c *Tvdb) translatedName(kind string, id int64, name, language string, translations []string) string {
+	if language == tvdbLanguage || !stringSliceContains(translations, tvdbLanguage) {
+		return name
+	}
+
+	translation := struct {
+		Name string `json:"name"`
+	}{}
+	_, err := c.getData(fmt.Sprintf("/%s/%d/translations/%s", kind, id, tvdbLanguage), nil, &translation)
+	if err != nil || translation.Name == "" {
+		return name
+	}
+	return translation.Name
+}
+
+func (c *Tvdb) getMovie(movieId int64) (tvdbMovie, error) {
+	movie := tvdbMovie{}
+	_, err := c.getData(fmt.Sprintf("/movies/%d/extended", movieId), url.Values{"short": {"true"}}, &movie)
+	return movie, err
+}
+
+func (c *Tvdb) GetMovie(movieId int64) (Movie, error) {
+	m, err := c.getMovie(movieId)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	releaseDate := m.FirstRelease.Date
+	if releaseDate == "" {
+		releaseDate = m.Year
+	}
+
+	movie := Movie{
+		Id:               m.Id,
+		Title:            c.translatedName("movies", m.Id, m.Name, m.OriginalLanguage, m.NameTranslations),
+		OriginalTitle:    m.Name,
+		ReleaseDate:      releaseDate,
+		OriginalLanguage: m.OriginalLanguage,
+		Runtime:          m.Runtime,
+		Genres:           m.Genres,
+		ImdbId:           tvdbImdbId(m.RemoteIds),
+	}
+	for _, country := range m.ProductionCountries {
+		movie.ProductionCountries = append(movie.ProductionCountries, ProductionCountry{Name: country.Name})
+	}
+	return movie, nil
+}
+
+func (c *Tvdb) getSeries(tvId int64) (tvdbSeries, error) {
+	series := tvdbSeries{}
+	_, err := c.getData(fmt.Sprintf("/series/%d/extended", tvId), url.Values{"short": {"true"}}, &series)
+	return series, err
+}
+
+func (c *Tvdb) GetTv(tvId int64) (Tv, error) {
+	if tv, ok := c.tvCache.getTv(tvId); ok {
+		return tv, nil
+	}
+
+	series, err := c.getSeries(tvId)
+	if err != nil {
+		return Tv{}, err
+	}
+
+	tv := Tv{
+		Id:               series.Id,
+		Name:             c.translatedName("series", series.Id, series.Name, series.OriginalLanguage, series.NameTranslations),
+		OriginalName:     series.Name,
+		FirstAirDate:     series.FirstAired,
+		Overview:         series.Overview,
+		OriginalLanguage: series.OriginalLanguage,
+		Genres:           series.Genres,
+	}
+	if series.OriginalCountry != "" {
+		tv.OriginCountry = []string{series.OriginalCountry}
+	}
+	if series.AverageRuntime > 0 {
+		tv.EpisodeRunTime = []int{series.AverageRuntime}
+	}
+	for _, season := range series.Seasons {
+		if season.Type.Type == "official" && season.Number > tv.NumberOfSeasons {
+			tv.NumberOfSeasons = season.Number
+		}
+	}
+
+	c.tvCache.putTv(tv)
+	return tv, nil
+}
+
+// getSeason fetches the episodes of a season in the given season type, ie.
+// "official" for aired order or "dvd" for dvd order
+func (c *Tvdb) getSeason(tv Tv, seasonType string, seasonNumber int) (TvSeason, error) {
+	tvSeason := TvSeason{
+		Name:         fmt.Sprintf("Season %d", seasonNumber),
+		SeasonNumber: seasonNumber,
+		TvName:       tv.Name,
+	}
+
+	for page := 0; ; page++ {
+		data := struct {
+			Episodes []tvdbEpisode `json:"episodes"`
+		}{}
+		q := url.Values{}
+		q.Set("season", strconv.Itoa(seasonNumber))
+		q.Set("page", strconv.Itoa(page))
+		links, err := c.getData(fmt.Sprintf("/series/%d/episodes/%s", tv.Id, seasonType), q, &data)
+		if err != nil {
+			return tvSeason, err
+		}
+
+		for _, e := range data.Episodes {
+			tvSeason.Episodes = append(tvSeason.Episodes, TvEpisode{
+				Id:             e.Id,
+				Name:           e.Name,
+				AirDate:        e.Aired,
+				EpisonNumber:   e.Number,
+				SeasonNumber:   e.SeasonNumber,
+				Overview:       e.Overview,
+				StillPath:      e.Image,
+				TvId:           tv.Id,
+				TvName:         tv.Name,
+				TvOriginalName: tv.OriginalName,
+				SeasonName:     tvSeason.Name,
+				FirstAirDate:   tv.FirstAirDate,
+			})
+		}
+
+		if links.Next == nil || *links.Next == "" || len(data.Episodes) == 0 {
+			break
+		}
+	}
+
+	if len(tvSeason.Episodes) > 0 {
+		tvSeason.AirDate = tvSeason.Episodes[0].AirDate
+	}
+	return tvSeason, nil
+}
+
+func (c *Tvdb) GetTvSeason(tv Tv, seasonNumber int) (TvSeason, error) {
+	if tvSeason, ok := c.tvCache.getSeason(tv.Id, seasonNumber); ok {
+		return tvSeason, nil
+	}
+
+	tvSeason, err := c.getSeason(tv, "official", seasonNumber)
+	if err != nil {
+		return tvSeason, err
+	}
+
+	c.tvCache.putSeason(tv.Id, tvSeason)
+	return tvSeason, nil
+}
+
+// PrefetchTvSeasons fetches seasons of tv one after the other in the
+// background, throttled by season-fetch-rate, so that switching to
+// them later is served from the cache
+func (c *Tvdb) PrefetchTvSeasons(tv Tv, seasonNumbers ...int) {
+	go func() {
+		for _, seasonNumber := range seasonNumbers {
+			if seasonNumber < 1 || seasonNumber > tv.NumberOfSeasons {
+				continue
+			}
+			if _, ok := c.tvCache.getSeason(tv.Id, seasonNumber); ok {
+				continue
+			}
+			seasonFetchLimiter.Wait()
+			c.GetTvSeason(tv, seasonNumber)
+		}
+	}()
+}
+
+// GetTvEpisodeGroups returns the alternative season types of a series,
+// ie. dvd or absolute order, as episode groups
+func (c *Tvdb) GetTvEpisodeGroups(tvId int64) (EpisodeGroupsResponse, error) {
+	response := EpisodeGroupsResponse{Id: tvId}
+
+	series, err := c.getSeries(tvId)
+	if err != nil {
+		return response, err
+	}
+
+	for _, seasonType := range series.SeasonTypes {
+		if seasonType.Type == "official" {
+			continue
+		}
+		group := EpisodeGroup{Id: seasonType.Type, Name: seasonType.Name}
+		for _, season := range series.Seasons {
+			if season.Type.Type == seasonType.Type {
+				group.GroupCount += 1
+			}
+		}
+		response.Results = append(response.Results, group)
+	}
+	return response, nil
+}
+
+// GetEpisodeGroupSeason returns a season of the season type groupId
+func (c *Tvdb) GetEpisodeGroupSeason(tv Tv, groupId string, seasonNumber int) (TvSeason, error) {
+	return c.getSeason(tv, groupId, seasonNumber)
+}
+
+func (c *Tvdb) GetMovieCredits(movieId int64) (Credits, error) {
+	credits := Credits{Id: movieId}
+
+	movie, err := c.getMovie(movieId)
+	if err != nil {
+		return credits, err
+	}
+
+	for _, character := range movie.Characters {
+		if character.PeopleType == "Director" {
+			credits.Crew = append(credits.Crew, CrewMember{Name: character.PersonName, Job: "Director"})
+		}
+	}
+	credits.Cast = tvdbCast(movie.Characters)
+	return credits, nil
+}
+
+// GetTvCredits returns the actors of a series, the short series record of
+// GetTv leaves out its characters
+func (c *Tvdb) GetTvCredits(tvId int64) (Credits, error) {
+	credits := Credits{Id: tvId}
+
+	series := struct {
+		Characters []tvdbCharacter `json:"characters"`
+	}{}
+	_, err := c.getData(fmt.Sprintf("/series/%d/extended", tvId), nil, &series)
+	if err != nil {
+		return credits, err
+	}
+
+	credits.Cast = tvdbCast(series.Characters)
+	return credits, nil
+}
+
+func tvdbCast(characters []tvdbCharacter) []CastMember {
+	cast := []CastMember{}
+	for _, character := range characters {
+		if character.PeopleType == "Actor" {
+			cast = append(cast, CastMember{Name: character.PersonName, Character: character.Name, Order: character.Sort})
+		}
+	}
+	return cast
+}
+
+func tvdbImdbId(remoteIds []tvdbRemoteId) string {
+	for _, remoteId := range remoteIds {
+		if strings.EqualFold(remoteId.SourceName, "imdb") {
+			return remoteId.Id
+		}
+	}
+	return ""
+}
+
+func (c *Tvdb) GetMovieExternalIds(movieId int64) (ExternalIds, error) {
+	movie, err := c.getMovie(movieId)
+	if err != nil {
+		return ExternalIds{}, err
+	}
+	return ExternalIds{ImdbId: tvdbImdbId(movie.RemoteIds)}, nil
+}
+
+func (c *Tvdb) GetTvExternalIds(tvId int64) (ExternalIds, error) {
+	series, err := c.getSeries(tvId)
+	if err != nil {
+		return ExternalIds{}, err
+	}
+	return ExternalIds{ImdbId: tvdbImdbId(series.RemoteIds), TvdbId: tvId}, nil
+}
+
+func (c *Tvdb) GetEpisodeExternalIds(episode TvEpisode) (ExternalIds, error) {
+	extended := struct {
+		RemoteIds []tvdbRemoteId `json:"remoteIds"`
+	}{}
+	_, err := c.getData(fmt.Sprintf("/episodes/%d/extended", episode.Id), nil, &extended)
+	if err != nil {
+		return ExternalIds{}, err
+	}
+	return ExternalIds{ImdbId: tvdbImdbId(extended.RemoteIds)}, nil
+}
+
+// FindByImdbId looks up the movies and series with an imdb remote id
+func (c *Tvdb) FindByImdbId(imdbId string) (FindResponse, error) {
+	response := FindResponse{}
+
+	results := []struct {
+		Series *struct {
+			Id int64 `json:"id"`
+		} `json:"series"`
+		Movie *struct {
+			Id int64 `json:"id"`
+		} `json:"movie"`
+	}{}
+	_, err := c.getData(fmt.Sprintf("/search/remoteid/%s", url.PathEscape(imdbId)), nil, &results)
+	if err != nil {
+		return response, err
+	}
+
+	for _, result := range results {
+		if result.Movie != nil {
+			movie, err := c.GetMovie(result.Movie.Id)
+			if err != nil {
+				return response, err
+			}
+			response.MovieResults = append(response.MovieResults, movie)
+		}
+		if result.Series != nil {
+			tv, err := c.GetTv(result.Series.Id)
+			if err != nil {
+				return response, err
+			}
+			response.TvResults = append(response.TvResults, tv)
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// undoEntry reverses the placement recorded by entry. The out file is removed
+// when the in file still exists, otherwise it was moved and is moved back.
+// A file displaced by the placement is restored to the out file path. Out
+// files the placement did not create, ie. ones already in place, are left
+// alone.
+func undoEntry(entry ManifestEntry) error {
+	if sameManifestPath(entry.InFile, entry.OutFile) || (entry.Created != nil && !*entry.Created) {
+		fmt.Printf(tr("Leaving %s, it was not placed by %s\n"), entry.OutFile, BinName)
+		return nil
+	}
+
+	outExists, err := fileExists(entry.OutFile)
+	if err != nil {
+		return err
+	}
+	inExists, err := fileExists(entry.InFile)
+	if err != nil {
+		return err
+	}
+
+	if outExists && inExists {
+		fmt.Printf(tr("Removing %s\n"), entry.OutFile)
+		err = os.Remove(entry.OutFile)
+	} else if outExists {
+		fmt.Printf("%s %s %s\n", ColorStr(RedColor, entry.OutFile), ColorStr(WhiteColor, arrowStr()), ColorStr(GreenColor, entry.InFile))
+		err = os.MkdirAll(filepath.Dir(entry.InFile), 0755)
+		if err == nil {
+			err = moveFile(entry.OutFile, entry.InFile)
+		}
+	} else {
+		fmt.Printf(tr("Out file %s no longer exists\n"), entry.OutFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	if entry.Displaced != "" {
+		displacedExists, err := fileExists(entry.Displaced)
+		if err != nil {
+			return err
+		}
+		if displacedExists {
+			fmt.Printf("%s %s %s\n", ColorStr(RedColor, entry.Displaced), ColorStr(WhiteColor, arrowStr()), ColorStr(GreenColor, entry.OutFile))
+			return moveFile(entry.Displaced, entry.OutFile)
+		}
+	}
+
+	// remove the out directory if nothing else was placed there,
+	// ignoring the error when it is not empty
+	os.Remove(filepath.Dir(entry.OutFile))
+	return nil
+}
+
+// runUndoCommand reverses the last n placements of the manifest, newest first,
+// and removes their entries. n defaults to 1.
+func runUndoCommand(args []string, manifestPath string, reader *bufio.Reader) error {
+	n := 1
+	if len(args) > 1 {
+		return fmt.Errorf("Usage: %s %s [flags] [count]", BinName, undoCommand)
+	} else if len(args) == 1 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid count %q, must be a positive number", args[0])
+		}
+	}
+
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	if n > len(manifest) {
+		n = len(manifest)
+	}
+	if n == 0 {
+		fmt.Println(tr("Manifest is empty, nothing to undo"))
+		return nil
+	}
+
+	undo := manifest[len(manifest)-n:]
+	for i := len(undo) - 1; i >= 0; i-- {
+		fmt.Printf("%s %s %s\n", ColorStr(GreenColor, undo[i].OutFile), ColorStr(WhiteColor, arrowStr()), ColorStr(RedColor, undo[i].InFile))
+	}
+	if *dryRunFlag {
+		return nil
+	}
+	if isInteractive() && !confirm(promptStr(fmt.Sprintf(tr("Undo %d placements? [yN]"), n)), reader) {
+		return nil
+	}
+
+	undone := 0
+	for i := len(undo) - 1; i >= 0; i-- {
+		err = undoEntry(undo[i])
+		if err != nil {
+			// keep the entries that were not undone
+			log.Println("Error undoing placement:", err)
+			break
+		}
+		undone += 1
+	}
+
+	fmt.Printf(tr("Undid %d placements\n"), undone)
+	return writeManifest(manifestPath, manifest[:len(manifest)-undone])
+}
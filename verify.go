@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	sha256Verify = "sha256"
+	verifyAction = "verify"
+)
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+func fileSha256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFileVerified copies src to dst, hashing src while it is read, then
+// re-reads dst and compares the checksums. dst is removed on a mismatch.
+func copyFileVerified(src, dst string) (sum string, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return
+	}
+
+	bufferSize := copySettings.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultCopyBufferSize
+	}
+	stop := func() {}
+	if info, err := in.Stat(); err == nil {
+		stop = startCopyProgress(out, info.Size())
+	}
+	h := sha256.New()
+	_, err = io.CopyBuffer(out, io.TeeReader(in, h), make([]byte, bufferSize))
+	stop()
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return
+	}
+
+	sum = hex.EncodeToString(h.Sum(nil))
+	actual, err := fileSha256(dst)
+	if err != nil {
+		return
+	}
+	if actual != sum {
+		os.Remove(dst)
+		return "", fmt.Errorf("%w: %s is %s, %s was %s", ErrChecksumMismatch, dst, actual, src, sum)
+	}
+	return
+}
+
+// verifyManifest re-hashes the out files of manifest entries with a checksum,
+// reporting files that changed or disappeared since they were placed
+func verifyManifest(manifestPath string) error {
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	checked, failed := 0, 0
+	for _, entry := range manifest {
+		if entry.Sha256 == "" {
+			continue
+		}
+		checked += 1
+		sum, err := fileSha256(entry.OutFile)
+		if err != nil {
+			fmt.Printf("%s: %s\n", ColorStr(RedColor, entry.OutFile), err)
+			failed += 1
+		} else if sum != entry.Sha256 {
+			fmt.Printf(tr("%s: checksum is %s, expected %s\n"), ColorStr(RedColor, entry.OutFile), sum, entry.Sha256)
+			failed += 1
+		}
+	}
+
+	fmt.Printf(tr("Verified %d out files, %d failed\n"), checked, failed)
+	if failed > 0 {
+		return fmt.Errorf("%w in %d out files", ErrChecksumMismatch, failed)
+	}
+	return nil
+}
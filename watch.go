@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// watch periodically rescans the in dir, either every interval
+// or according to a cron-style schedule, until the user quits
+func watch(organizer *Organizer, interval time.Duration, scheduleSpec string, status *WatchStatus) error {
+	var schedule *Schedule
+	if scheduleSpec != "" {
+		var err error
+		schedule, err = parseSchedule(scheduleSpec)
+		if err != nil {
+			return err
+		}
+	} else if interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	for {
+		status.ScanStarted()
+		session := organizer.Run()
+		if session.Worked() {
+			finishRun(session)
+		}
+		if session.HasQuit() {
+			return nil
+		}
+
+		var next time.Time
+		if schedule != nil {
+			next = schedule.Next(time.Now())
+		} else {
+			next = time.Now().Add(interval)
+		}
+		status.ScanFinished(next)
+
+		fmt.Printf(tr("Next scan at %s\n"), next.Format("2006-01-02 15:04"))
+		time.Sleep(time.Until(next))
+	}
+}